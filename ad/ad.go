@@ -10,7 +10,10 @@
 //     b) returns of float64;
 //     c) standalone calls to methods on the type implementing
 //     model.Model (apparently called for side  effects on
-//     the model).
+//     the model);
+//     d) composite literals of float64 slices in assignments,
+//     returns and standalone calls, as well as float64 arrays
+//     and structs with float64 fields assigned to variables.
 //  3. Imported package name "ad" is reserved.
 //  4. Non-dummy identifiers starting with the prefix for
 //     generated identifiers ("_" by default) are reserved.
@@ -46,10 +49,16 @@
 //
 // The differentiated model is put into subpackage "ad" of the
 // model's package, with the same name as the original package.
+// Each differentiated method M gets a counterpart MTape with an
+// explicit tape, of type *Tape, as the first parameter; M calls
+// MTape on the current tape. A model evaluated through
+// ObserveTape on a tape created by NewTape can run in parallel
+// with other models on other tapes, without MTSafeOn.
 package ad
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/constant"
@@ -134,6 +143,12 @@ func Deriv(mpath string, prefix string) (err error) {
 		return err
 	}
 
+	// Pass the tape explicitly to the differentiated methods.
+	err = m.threadTape()
+	if err != nil {
+		return err
+	}
+
 	// Finally write the model.
 	err = m.write()
 
@@ -238,6 +253,10 @@ func (m *model) deriv() (err error) {
 		if err != nil {
 			return err
 		}
+		err = m.expand(method)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Rewrite file imports, adding the infergo import to all
@@ -527,6 +546,382 @@ func (m *model) desugar(method *ast.FuncDecl) (err error) {
 	return err
 }
 
+// expand expands composite literals with float64 elements
+// into assignments, which are then differentiated as any other
+// assignment. A slice literal in a statement is built before
+// the statement in a generated variable; an array or struct
+// literal assigned to a variable is assigned element by
+// element.
+func (m *model) expand(method *ast.FuncDecl) (err error) {
+	defer errOnPanic(
+		&err,
+		m.fset.Position(method.Pos()),
+	)()
+
+	nlit := 0 // number of generated variables
+	astutil.Apply(method, nil,
+		// Literals are expanded bottom-up, so that nested
+		// literals are built before the enclosing ones.
+		func(c *astutil.Cursor) bool {
+			if _, ok := c.Parent().(*ast.BlockStmt); !ok {
+				return true
+			}
+			n := c.Node()
+			if n != nil && n.Pos() != token.NoPos {
+				defer errOnPanic(
+					&err,
+					m.fset.Position(n.Pos()),
+				)()
+			}
+
+			switch n.(type) {
+			case *ast.AssignStmt, *ast.ExprStmt, *ast.ReturnStmt:
+			default:
+				return true
+			}
+
+			// Slice literals are built in generated variables
+			// before the statement.
+			var hoist func(root ast.Node) ast.Node
+			hoist = func(root ast.Node) ast.Node {
+				return astutil.Apply(root,
+					func(c *astutil.Cursor) bool {
+						switch n := c.Node().(type) {
+						case *ast.FuncLit:
+							// Statements in the body were
+							// already expanded.
+							return false
+						case *ast.BinaryExpr:
+							if n.Op == token.LAND || n.Op == token.LOR {
+								// The right operand is not always
+								// evaluated and must stay in
+								// place.
+								n.X = hoist(n.X).(ast.Expr)
+								return false
+							}
+						}
+						return true
+					},
+					func(cl *astutil.Cursor) bool {
+						lit, ok := cl.Node().(*ast.CompositeLit)
+						if !ok || len(lit.Elts) == 0 ||
+							!isFloatSlice(m.info.TypeOf(lit)) {
+							return true
+						}
+						name := fmt.Sprintf("literal%d", nlit)
+						nlit++
+						for _, stmt := range m.expandSlice(
+							name, lit, n.Pos()) {
+							c.InsertBefore(stmt)
+						}
+						v := m.genIdent(name)
+						m.info.Types[v] = m.info.Types[lit]
+						cl.Replace(v)
+						return true
+					})
+			}
+			hoist(n)
+
+			// An array or struct literal assigned to a variable
+			// is assigned element by element.
+			if n, ok := n.(*ast.AssignStmt); ok &&
+				len(n.Lhs) == 1 && len(n.Rhs) == 1 &&
+				n.Tok == token.ASSIGN {
+				lit, ok := n.Rhs[0].(*ast.CompositeLit)
+				if !ok {
+					return true
+				}
+				name := fmt.Sprintf("literal%d", nlit)
+				nlit++
+				stmts := m.expandAssignment(
+					name, n.Lhs[0], lit, n.Pos())
+				if stmts == nil {
+					return true
+				}
+				for _, stmt := range stmts[:len(stmts)-1] {
+					c.InsertBefore(stmt)
+				}
+				c.Replace(stmts[len(stmts)-1])
+			}
+			return true
+		})
+
+	return err
+}
+
+// expandSlice returns statements building slice literal lit in
+// variable name: the slice is allocated, and the elements are
+// assigned. The statements are positioned at pos.
+func (m *model) expandSlice(
+	name string,
+	lit *ast.CompositeLit,
+	pos token.Pos,
+) []ast.Stmt {
+	t := m.info.TypeOf(lit)
+	indices, length := m.literalIndices(lit)
+	alloc := &ast.AssignStmt{
+		Lhs:    []ast.Expr{m.genIdent(name)},
+		TokPos: pos,
+		Tok:    token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.Ident{Name: "make"},
+				Args: []ast.Expr{
+					m.typeAst(t, lit.Pos()),
+					intExpr(length),
+				},
+			},
+		},
+	}
+	asgn := &ast.AssignStmt{
+		TokPos: pos,
+		Tok:    token.ASSIGN,
+	}
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		asgn.Lhs = append(asgn.Lhs,
+			m.indexExpr(m.genIdent(name), indices[i]))
+		asgn.Rhs = append(asgn.Rhs, elt)
+	}
+	return []ast.Stmt{alloc, asgn}
+}
+
+// expandAssignment returns statements assigning array or
+// struct literal lit to lhs element by element, or nil if
+// the literal has no float64 elements. All elements of the
+// literal are evaluated before lhs is assigned, and lhs is
+// evaluated once: unless lhs is a variable, the statements
+// assign through a pointer to lhs. A struct literal with
+// fields other than float64 is built in variable name. The
+// statements are positioned at pos.
+func (m *model) expandAssignment(
+	name string,
+	lhs ast.Expr,
+	lit *ast.CompositeLit,
+	pos token.Pos,
+) []ast.Stmt {
+	t := m.info.TypeOf(lit)
+	var stmts []ast.Stmt
+	switch u := t.Underlying().(type) {
+	case *types.Array:
+		if !isFloat(u.Elem()) {
+			return nil
+		}
+		place, _, ok := m.place(name, lhs, pos, &stmts)
+		if !ok {
+			return nil
+		}
+		// All elements are assigned, the omitted ones are
+		// zeroed.
+		rhs := make([]ast.Expr, u.Len())
+		indices, _ := m.literalIndices(lit)
+		for i, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				elt = kv.Value
+			}
+			rhs[indices[i]] = elt
+		}
+		asgn := &ast.AssignStmt{
+			TokPos: pos,
+			Tok:    token.ASSIGN,
+		}
+		for i := range rhs {
+			asgn.Lhs = append(asgn.Lhs, m.indexExpr(place, i))
+			if rhs[i] == nil {
+				rhs[i] = m.zeroExpr()
+			}
+		}
+		asgn.Rhs = rhs
+		return append(stmts, asgn)
+	case *types.Struct:
+		// Values of fields, by field index.
+		values := make([]ast.Expr, u.NumFields())
+		for i, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				name := kv.Key.(*ast.Ident).Name
+				for j := 0; j != u.NumFields(); j++ {
+					if u.Field(j).Name() == name {
+						values[j] = kv.Value
+						break
+					}
+				}
+			} else {
+				values[i] = elt
+			}
+		}
+
+		// Accessible float64 fields are assigned in parallel,
+		// the other fields are initialized by a literal.
+		var floats []*types.Var
+		var rhs []ast.Expr
+		others := &ast.CompositeLit{
+			Type:   lit.Type,
+			Lbrace: lit.Lbrace,
+			Rbrace: lit.Rbrace,
+		}
+		hasOthers := false
+		for i := 0; i != u.NumFields(); i++ {
+			field := u.Field(i)
+			if isFloat(field.Type()) &&
+				(field.Exported() || field.Pkg().Path() == m.path) {
+				floats = append(floats, field)
+				if values[i] == nil {
+					values[i] = m.zeroExpr()
+				}
+				rhs = append(rhs, values[i])
+			} else {
+				hasOthers = true
+				if values[i] != nil {
+					others.Elts = append(others.Elts,
+						&ast.KeyValueExpr{
+							Key:   &ast.Ident{Name: field.Name()},
+							Value: values[i],
+						})
+				}
+			}
+		}
+		if len(floats) == 0 {
+			return nil
+		}
+		place, value, ok := m.place(name, lhs, pos, &stmts)
+		if !ok {
+			return nil
+		}
+		parallel := func(lhs ast.Expr, rhs []ast.Expr) ast.Stmt {
+			asgn := &ast.AssignStmt{
+				TokPos: pos,
+				Tok:    token.ASSIGN,
+				Rhs:    rhs,
+			}
+			for _, field := range floats {
+				asgn.Lhs = append(asgn.Lhs, m.fieldExpr(lhs, field))
+			}
+			return asgn
+		}
+		if !hasOthers {
+			return append(stmts, parallel(place, rhs))
+		}
+
+		// The literal is built in a variable, and then assigned
+		// to lhs; float64 fields are assigned separately to be
+		// differentiated.
+		built := make([]ast.Expr, len(floats))
+		for i, field := range floats {
+			built[i] = m.fieldExpr(m.genIdent(name), field)
+		}
+		return append(stmts,
+			&ast.AssignStmt{
+				Lhs:    []ast.Expr{m.genIdent(name)},
+				TokPos: pos,
+				Tok:    token.DEFINE,
+				Rhs:    []ast.Expr{others},
+			},
+			parallel(m.genIdent(name), rhs),
+			&ast.AssignStmt{
+				Lhs:    []ast.Expr{value},
+				TokPos: pos,
+				Tok:    token.ASSIGN,
+				Rhs:    []ast.Expr{m.genIdent(name)},
+			},
+			parallel(place, built))
+	}
+	return nil
+}
+
+// place returns an expression place evaluating to the place
+// of lhs without side effects, and an expression value for
+// the value in the place. If lhs is a variable, both are lhs.
+// Otherwise, place is a pointer to lhs, named after name and
+// stored by a statement appended to stmts. ok is false if the
+// place of lhs cannot be taken, that is if lhs is a map
+// entry.
+func (m *model) place(
+	name string,
+	lhs ast.Expr,
+	pos token.Pos,
+	stmts *[]ast.Stmt,
+) (place, value ast.Expr, ok bool) {
+	if _, ok := lhs.(*ast.Ident); ok {
+		return lhs, lhs, true
+	}
+	if ix, ok := lhs.(*ast.IndexExpr); ok {
+		if _, ok := m.info.TypeOf(ix.X).Underlying().(*types.Map); ok {
+			return nil, nil, false
+		}
+	}
+	place = m.genIdent(name + "p")
+	*stmts = append(*stmts, &ast.AssignStmt{
+		Lhs:    []ast.Expr{place},
+		TokPos: pos,
+		Tok:    token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.UnaryExpr{
+				Op: token.AND,
+				X:  lhs,
+			},
+		},
+	})
+	return place, &ast.StarExpr{X: m.genIdent(name + "p")}, true
+}
+
+// literalIndices returns the indices of elements of a slice or
+// array literal, and the length of the literal.
+func (m *model) literalIndices(lit *ast.CompositeLit) (
+	indices []int,
+	length int,
+) {
+	index := 0
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			key, _ := constant.Int64Val(m.info.Types[kv.Key].Value)
+			index = int(key)
+		}
+		indices = append(indices, index)
+		index++
+		if index > length {
+			length = index
+		}
+	}
+	return indices, length
+}
+
+// indexExpr returns a float64 expression for x[i].
+func (m *model) indexExpr(x ast.Expr, i int) ast.Expr {
+	expr := &ast.IndexExpr{
+		X:     x,
+		Index: intExpr(i),
+	}
+	m.info.Types[expr] = types.TypeAndValue{
+		Type: types.Typ[types.Float64],
+	}
+	return expr
+}
+
+// fieldExpr returns a float64 expression for x.field.
+func (m *model) fieldExpr(x ast.Expr, field *types.Var) ast.Expr {
+	sel := &ast.Ident{Name: field.Name()}
+	expr := &ast.SelectorExpr{
+		X:   x,
+		Sel: sel,
+	}
+	m.info.Uses[sel] = field
+	m.info.Types[expr] = types.TypeAndValue{
+		Type: types.Typ[types.Float64],
+	}
+	return expr
+}
+
+// zeroExpr returns a float64 expression for 0.
+func (m *model) zeroExpr() ast.Expr {
+	expr := floatExpr(0)
+	m.info.Types[expr] = types.TypeAndValue{
+		Type: types.Typ[types.Float64],
+	}
+	return expr
+}
+
 // typeAst returns the AST for the given type. Used to
 // generate variable declarations.
 func (m *model) typeAst(t types.Type, p token.Pos) ast.Expr {
@@ -615,11 +1010,15 @@ func (m *model) rewrite(method *ast.FuncDecl) (err error) {
 					return false
 				}
 			case *ast.CompositeLit:
-				pos := m.fset.Position(n.Pos())
-				log.Printf("WARNING: %v:%v:%v: composite literals "+
-					"are not differentiated yet; see "+
-					"https://bitbucket.org/dtolpin/infergo/issues/1.",
-					pos.Filename, pos.Line, pos.Column)
+				// Literals with float64 elements in statements
+				// were expanded; the remaining ones cannot be
+				// differentiated.
+				if hasFloats(m.info.TypeOf(n)) {
+					pos := m.fset.Position(n.Pos())
+					log.Printf("WARNING: %v:%v:%v: composite literal "+
+						"is not differentiated in this context",
+						pos.Filename, pos.Line, pos.Column)
+				}
 				return false
 			case *ast.IndexExpr, *ast.SelectorExpr,
 				*ast.StarExpr, *ast.UnaryExpr, *ast.BinaryExpr:
@@ -1175,6 +1574,36 @@ func (m *model) isVlemental(call *ast.CallExpr) bool {
 	return true
 }
 
+// isFloatSlice returns true iff typ is a slice of float64.
+func isFloatSlice(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+	st, ok := typ.Underlying().(*types.Slice)
+	return ok && isFloat(st.Elem())
+}
+
+// hasFloats returns true iff typ is a slice or an array of
+// float64, or a struct with a float64 field.
+func hasFloats(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Slice:
+		return isFloat(t.Elem())
+	case *types.Array:
+		return isFloat(t.Elem())
+	case *types.Struct:
+		for i := 0; i != t.NumFields(); i++ {
+			if isFloat(t.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // isFloat returns true iff the kind is a float kind
 func isFloat(typ types.Type) bool {
 	bt, ok := typ.(*types.Basic)
@@ -1250,6 +1679,207 @@ func callWrapper(
 	}
 }
 
+// Threading the tape
+
+// tapeMethods are methods of ad.Tape called by the rewritten
+// code.
+var tapeMethods = map[string]bool{
+	"Setup":              true,
+	"Called":             true,
+	"Enter":              true,
+	"Return":             true,
+	"Value":              true,
+	"Arithmetic":         true,
+	"Assignment":         true,
+	"ParallelAssignment": true,
+	"Elemental":          true,
+	"Vlemental":          true,
+	"Call":               true,
+}
+
+// threadTape makes the tape an explicit parameter of the
+// differentiated methods, so that a model can run against any
+// tape, in any goroutine. Each differentiated method M becomes
+// method MTape, with the tape as the first parameter, and the
+// tape-writing calls are made on the tape parameter. Method M
+// calls MTape on the current tape. Calls to differentiated
+// methods through an interface are not rewritten, except for
+// Observe, which is called through ad.Tape.Observe.
+func (m *model) threadTape() (err error) {
+	methods, err := m.collectMethods()
+	if err != nil {
+		return err
+	}
+	for _, method := range methods {
+		m.tapeBody(method)
+	}
+	for _, file := range m.pkg.Files {
+		var decls []ast.Decl
+		for _, d := range file.Decls {
+			decls = append(decls, d)
+			if d, ok := d.(*ast.FuncDecl); ok &&
+				m.isMethodType(m.info.TypeOf(d.Name)) {
+				wrapper, err := m.tapeWrapper(d)
+				if err != nil {
+					return err
+				}
+				decls = append(decls, wrapper)
+			}
+		}
+		file.Decls = decls
+	}
+	return err
+}
+
+// tapeBody rewrites the body of a differentiated method to call
+// the tape parameter.
+func (m *model) tapeBody(method *ast.FuncDecl) {
+	tape := m.genIdent("tape")
+	astutil.Apply(method.Body, nil,
+		func(c *astutil.Cursor) bool {
+			call, ok := c.Node().(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			// A tape-writing call, ad.Name(...).
+			if x, ok := sel.X.(*ast.Ident); ok &&
+				x.Name == "ad" && tapeMethods[sel.Sel.Name] {
+				sel.X = tape
+				return true
+			}
+			// A call to a differentiated method.
+			t, ok := m.info.Selections[sel]
+			if !ok ||
+				t.Kind() != types.MethodVal ||
+				!m.isMethodType(t.Type()) {
+				return true
+			}
+			if types.IsInterface(t.Recv()) {
+				if sel.Sel.Name == "Observe" {
+					c.Replace(&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   tape,
+							Sel: &ast.Ident{Name: "Observe"},
+						},
+						Args:     append([]ast.Expr{sel.X}, call.Args...),
+						Ellipsis: call.Ellipsis,
+					})
+				}
+				return true
+			}
+			sel.Sel = &ast.Ident{
+				NamePos: sel.Sel.NamePos,
+				Name:    sel.Sel.Name + "Tape",
+			}
+			call.Args = append([]ast.Expr{tape}, call.Args...)
+			return true
+		})
+}
+
+// tapeWrapper turns a differentiated method into the method
+// with the tape parameter, and returns the declaration of the
+// original method calling the former on the current tape. The
+// wrapper goes right after the method.
+func (m *model) tapeWrapper(
+	method *ast.FuncDecl,
+) (*ast.FuncDecl, error) {
+	// The receiver and the parameters are referenced by the
+	// wrapper and must be named.
+	recv := *method.Recv.List[0]
+	if len(recv.Names) == 0 || recv.Names[0].Name == "_" {
+		recv.Names = []*ast.Ident{m.genIdent("recv")}
+	}
+	var params []*ast.Field
+	var args []ast.Expr
+	for _, field := range method.Type.Params.List {
+		param := *field
+		param.Names = nil
+		if len(field.Names) == 0 {
+			param.Names = append(param.Names,
+				m.genIdent(fmt.Sprintf("param%d", len(args))))
+		}
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				name = m.genIdent(fmt.Sprintf("param%d", len(args)))
+			}
+			param.Names = append(param.Names, name)
+		}
+		for _, name := range param.Names {
+			args = append(args, &ast.Ident{Name: name.Name})
+		}
+		params = append(params, &param)
+	}
+	ellipsis := token.NoPos
+	if t := m.info.TypeOf(method.Name).(*types.Signature); t.Variadic() {
+		ellipsis = 1
+	}
+	call := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{Name: recv.Names[0].Name},
+			Sel: &ast.Ident{Name: method.Name.Name + "Tape"},
+		},
+		Args:     append([]ast.Expr{callExpr("CurrentTape")}, args...),
+		Ellipsis: ellipsis,
+	}
+	var stmt ast.Stmt = &ast.ExprStmt{X: call}
+	if method.Type.Results != nil && len(method.Type.Results.List) > 0 {
+		stmt = &ast.ReturnStmt{Results: []ast.Expr{call}}
+	}
+	signature := &ast.FuncDecl{
+		Recv: &ast.FieldList{
+			List: []*ast.Field{&recv},
+		},
+		Name: &ast.Ident{Name: method.Name.Name},
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: params},
+			Results: method.Type.Results,
+		},
+	}
+
+	// The wrapper is printed and parsed back as a file of its
+	// own, so that it is formatted as if written by hand: with a
+	// doc comment, an empty line before, and the body on a
+	// separate line.
+	src := &bytes.Buffer{}
+	fmt.Fprintf(src, "package %s\n\n// %s calls %sTape on the current tape.\n",
+		m.pkg.Name, method.Name.Name, method.Name.Name)
+	printer.Fprint(src, m.fset, signature)
+	src.WriteString(" {\n\t")
+	printer.Fprint(src, m.fset, stmt)
+	src.WriteString("\n}\n")
+	file, err := parser.ParseFile(m.fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	wrapper := file.Decls[0].(*ast.FuncDecl)
+
+	// The method itself gets the tape parameter.
+	name := method.Name.Name + "Tape"
+	method.Name = &ast.Ident{
+		NamePos: method.Name.NamePos,
+		Name:    name,
+	}
+	method.Type = &ast.FuncType{
+		Func: method.Type.Func,
+		Params: &ast.FieldList{
+			Opening: method.Type.Params.Opening,
+			List: append([]*ast.Field{{
+				Names: []*ast.Ident{m.genIdent("tape")},
+				Type: &ast.StarExpr{
+					X: varExpr("Tape"),
+				},
+			}}, method.Type.Params.List...),
+			Closing: method.Type.Params.Closing,
+		},
+		Results: method.Type.Results,
+	}
+	return wrapper, nil
+}
+
 // Writing
 
 // write writes the differentiated model as a Go package source.
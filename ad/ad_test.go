@@ -2,10 +2,15 @@ package ad
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 )
 
@@ -1324,10 +1329,199 @@ func (m Model) Observe(x []float64) float64 {
 	} else {
 		ad.Setup(x)
 	}
+	_literal0 := make([]float64, 1)
+	ad.Assignment(&_literal0[0], &x[0])
 	return ad.Return(ad.Call(func (_ []float64) {
-		m.Observe([]float64{x[0]})
+		m.Observe(_literal0)
 	}, 0))
+}`,
+		},
+		//====================================================
+		{`package slicelit
+
+type Model float64
+
+func (m Model) sum(x []float64) float64 {
+	return x[0] + x[1]
+}
+
+func (m Model) Observe(x []float64) float64 {
+	y := []float64{x[0] * x[1], 2: x[1]}
+	return m.sum([]float64{y[2], m.sum([]float64{x[0], 1})})
+}`,
+			//----------------------------------------------------
+			`package slicelit
+
+import "bitbucket.org/dtolpin/infergo/ad"
+
+type Model float64
+
+func (m Model) sum(x []float64) float64 {
+	if ad.Called() {
+		ad.Enter()
+	} else {
+		panic("sum called outside Observe")
+	}
+	return ad.Return(ad.Arithmetic(ad.OpAdd, &x[0], &x[1]))
+}
+
+func (m Model) Observe(x []float64) float64 {
+	if ad.Called() {
+		ad.Enter()
+	} else {
+		ad.Setup(x)
+	}
+	var y []float64
+	_literal0 := make([]float64, 3)
+	ad.ParallelAssignment(&_literal0[0], &_literal0[2],
+		ad.Arithmetic(ad.OpMul, &x[0], &x[1]), &x[1])
+	y = _literal0
+	_literal1 := make([]float64, 2)
+	ad.ParallelAssignment(&_literal1[0], &_literal1[1],
+		&x[0], ad.Value(1))
+	_literal2 := make([]float64, 2)
+	ad.ParallelAssignment(&_literal2[0], &_literal2[1],
+		&y[2], ad.Call(func(_ []float64) {
+			m.sum(_literal1)
+		}, 0))
+	return ad.Return(ad.Call(func(_ []float64) {
+		m.sum(_literal2)
+	}, 0))
+}`,
+		},
+		//====================================================
+		{`package arraylit
+
+type Model float64
+
+func (m Model) Observe(x []float64) float64 {
+	var y [3]float64
+	y = [3]float64{x[0], 2: x[1]}
+	y = [...]float64{y[2], y[1], y[0]}
+	return y[0]
+}`,
+			//----------------------------------------------------
+			`package arraylit
+
+import "bitbucket.org/dtolpin/infergo/ad"
+
+type Model float64
+
+func (m Model) Observe(x []float64) float64 {
+	if ad.Called() {
+		ad.Enter()
+	} else {
+		ad.Setup(x)
+	}
+	var y [3]float64
+	ad.ParallelAssignment(&y[0], &y[1], &y[2], &x[0], ad.Value(0), &x[1])
+	ad.ParallelAssignment(&y[0], &y[1], &y[2], &y[2], &y[1], &y[0])
+	return ad.Return(&y[0])
+}`,
+		},
 		//====================================================
+		{`package structlit
+
+type Params struct {
+	Mu, Sigma float64
+	Name      string
+}
+
+type Model float64
+
+func (m Model) Observe(x []float64) float64 {
+	p := Params{Mu: x[0]}
+	q := Params{x[1], p.Mu, "q"}
+	return p.Mu * q.Sigma
+}`,
+			//----------------------------------------------------
+			`package structlit
+
+import "bitbucket.org/dtolpin/infergo/ad"
+
+type Params struct {
+	Mu, Sigma float64
+	Name      string
+}
+
+type Model float64
+
+func (m Model) Observe(x []float64) float64 {
+	if ad.Called() {
+		ad.Enter()
+	} else {
+		ad.Setup(x)
+	}
+	var p Params
+	_literal0 := Params{}
+	ad.ParallelAssignment(&_literal0.Mu, &_literal0.Sigma,
+		&x[0], ad.Value(0))
+	p = _literal0
+	ad.ParallelAssignment(&p.Mu, &p.Sigma,
+		&_literal0.Mu, &_literal0.Sigma)
+	var q Params
+	_literal1 := Params{Name: "q"}
+	ad.ParallelAssignment(&_literal1.Mu, &_literal1.Sigma,
+		&x[1], &p.Mu)
+	q = _literal1
+	ad.ParallelAssignment(&q.Mu, &q.Sigma,
+		&_literal1.Mu, &_literal1.Sigma)
+	return ad.Return(ad.Arithmetic(ad.OpMul, &p.Mu, &q.Sigma))
+}`,
+		},
+		//====================================================
+		{`package structself
+
+type Params struct {
+	N int
+	X float64
+}
+
+type Model float64
+
+func (m Model) Observe(x []float64) float64 {
+	ps := make([]Params, 2)
+	ps[0] = Params{N: 1, X: x[0]}
+	ps[ps[0].N] = Params{N: ps[0].N + 1, X: ps[0].X * float64(ps[0].N)}
+	var y [2][2]float64
+	y[ps[0].N] = [2]float64{ps[1].X, x[1]}
+	return y[1][0] * y[1][1]
+}`,
+			//----------------------------------------------------
+			`package structself
+
+import "bitbucket.org/dtolpin/infergo/ad"
+
+type Params struct {
+	N int
+	X float64
+}
+
+type Model float64
+
+func (m Model) Observe(x []float64) float64 {
+	if ad.Called() {
+		ad.Enter()
+	} else {
+		ad.Setup(x)
+	}
+	var ps []Params
+	ps = make([]Params, 2)
+	_literal0p := &ps[0]
+	_literal0 := Params{N: 1}
+	ad.Assignment(&_literal0.X, &x[0])
+	*_literal0p = _literal0
+	ad.Assignment(&_literal0p.X, &_literal0.X)
+	_literal1p := &ps[ps[0].N]
+	_literal1 := Params{N: ps[0].N + 1}
+	ad.Assignment(&_literal1.X,
+		ad.Arithmetic(ad.OpMul, &ps[0].X, ad.Value(float64(ps[0].N))))
+	*_literal1p = _literal1
+	ad.Assignment(&_literal1p.X, &_literal1.X)
+	var y [2][2]float64
+	_literal2p := &y[ps[0].N]
+	ad.ParallelAssignment(&_literal2p[0], &_literal2p[1], &ps[1].X, &x[1])
+	return ad.Return(ad.Arithmetic(ad.OpMul, &y[1][0], &y[1][1]))
 }`,
 		},
 	} {
@@ -1428,3 +1622,290 @@ func (m Model) Observe(x []float64) float64 {
 		}
 	}
 }
+
+// The tape is threaded through differentiated methods, and
+// the original methods call them on the current tape.
+func TestThreadTape(t *testing.T) {
+	m, err := parseTestModel(map[string]string{
+		"original.go": `package thread
+
+type Observer interface {
+	Observe(x []float64) float64
+}
+
+type Model struct {
+	Prior Observer
+}
+
+func (m Model) Observe(x []float64) float64 {
+	return m.Prior.Observe(x) + m.Sq(x[0], x[1:]...)
+}
+
+func (Model) Sq(x float64, _ ...float64) float64 {
+	return x * x
+}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	err = m.check()
+	if err != nil {
+		t.Fatalf("failed to check %v: %s", m.pkg.Name, err)
+	}
+	err = m.deriv()
+	if err != nil {
+		t.Fatalf("failed to differentiate %v: %s", m.pkg.Name, err)
+	}
+	err = m.threadTape()
+	if err != nil {
+		t.Fatalf("failed to thread tape %v: %s", m.pkg.Name, err)
+	}
+	file := m.pkg.Files["original.go"]
+	// The expected source is parsed without comments, check
+	// the wrapper comments separately.
+	for _, d := range file.Decls {
+		if d, ok := d.(*ast.FuncDecl); ok && d.Doc != nil {
+			name := d.Name.Name
+			doc := fmt.Sprintf("// %s calls %sTape on the current tape.",
+				name, name)
+			if d.Doc.List[0].Text != doc {
+				t.Errorf("wrong comment for %s: got %q, want %q",
+					name, d.Doc.List[0].Text, doc)
+			}
+			d.Doc = nil
+		}
+	}
+	threaded := `package thread
+
+import "bitbucket.org/dtolpin/infergo/ad"
+
+type Observer interface {
+	Observe(x []float64) float64
+}
+
+type Model struct {
+	Prior Observer
+}
+
+func (m Model) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Call(func(_ []float64) {
+		_tape.Observe(m.Prior, x)
+	}, 0), _tape.Call(func(_ []float64) {
+		m.SqTape(_tape, 0, x[1:]...)
+	}, 1, &x[0])))
+}
+
+func (m Model) Observe(x []float64) float64 {
+	return m.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (Model) SqTape(_tape *ad.Tape, x float64, _ ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&x)
+	} else {
+		panic("Sq called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpMul, &x, &x))
+}
+
+func (_recv Model) Sq(x float64, _param1 ...float64) float64 {
+	return _recv.SqTape(ad.CurrentTape(), x, _param1...)
+}`
+	if !equiv(file, threaded) {
+		b := new(bytes.Buffer)
+		printer.Fprint(b, m.fset, file)
+		t.Errorf("model %v:\n---\n%v\n---\n"+
+			" not equivalent to \n---\n%v\n---\n",
+			m.pkg.Name, b.String(), threaded)
+	}
+}
+
+// Composite literals are expanded into assignments (see
+// slicelit, arraylit, and structlit in TestRewrite). The
+// gradients of the expanded code must agree with finite
+// differences.
+func TestLiteralGradient(t *testing.T) {
+	type params struct {
+		Mu, Sigma float64
+		Name      string
+	}
+	sum := func(y []float64) float64 {
+		Enter()
+		return Return(Arithmetic(OpAdd, &y[0], &y[1]))
+	}
+	for _, c := range []struct {
+		name string
+		f    func(x []float64) float64
+		x    []float64
+	}{
+		{"slicelit",
+			func(x []float64) float64 {
+				var y []float64
+				_literal0 := make([]float64, 3)
+				ParallelAssignment(&_literal0[0], &_literal0[2],
+					Arithmetic(OpMul, &x[0], &x[1]), &x[1])
+				y = _literal0
+				_literal1 := make([]float64, 2)
+				ParallelAssignment(&_literal1[0], &_literal1[1],
+					&x[0], Value(1))
+				_literal2 := make([]float64, 2)
+				ParallelAssignment(&_literal2[0], &_literal2[1],
+					Arithmetic(OpMul, &y[0], &y[2]),
+					Call(func(_ []float64) {
+						sum(_literal1)
+					}, 0))
+				return Return(Call(func(_ []float64) {
+					sum(_literal2)
+				}, 0))
+			},
+			[]float64{0.5, 2}},
+		{"arraylit",
+			func(x []float64) float64 {
+				var y [3]float64
+				ParallelAssignment(&y[0], &y[1], &y[2],
+					&x[0], Value(0), &x[1])
+				ParallelAssignment(&y[0], &y[1], &y[2],
+					&y[2], &y[1], &y[0])
+				return Return(Arithmetic(OpMul, &y[0], &y[2]))
+			},
+			[]float64{1.5, -3}},
+		{"structlit",
+			func(x []float64) float64 {
+				var p params
+				_literal0 := params{}
+				ParallelAssignment(&_literal0.Mu, &_literal0.Sigma,
+					&x[0], Value(0))
+				p = _literal0
+				ParallelAssignment(&p.Mu, &p.Sigma,
+					&_literal0.Mu, &_literal0.Sigma)
+				var q params
+				_literal1 := params{Name: "q"}
+				ParallelAssignment(&_literal1.Mu, &_literal1.Sigma,
+					&x[1], Elemental(math.Exp, &p.Mu))
+				q = _literal1
+				ParallelAssignment(&q.Mu, &q.Sigma,
+					&_literal1.Mu, &_literal1.Sigma)
+				return Return(Arithmetic(OpMul, &q.Mu, &q.Sigma))
+			},
+			[]float64{0.25, 4}},
+	} {
+		value := func(x []float64) float64 {
+			Setup(x)
+			defer Pop()
+			return c.f(x)
+		}
+		g := ddx(c.x, func(x []float64) { c.f(x) })
+		for i := range c.x {
+			const h = 1e-6
+			x := make([]float64, len(c.x))
+			copy(x, c.x)
+			x[i] = c.x[i] + h
+			vp := value(x)
+			x[i] = c.x[i] - h
+			vm := value(x)
+			fd := (vp - vm) / (2 * h)
+			if math.Abs(g[i]-fd) > 1e-6*math.Max(1, math.Abs(fd)) {
+				t.Errorf("%s, x=%v: g[%d]=%.6g, wanted %.6g",
+					c.name, c.x, i, g[i], fd)
+			}
+		}
+	}
+}
+
+// The code generated for literals assigned to places which
+// the literals read must compute the same values as the
+// original code, and the gradient. The model is differentiated
+// by Deriv, and the differentiated model is built and tested
+// by the go tool.
+func TestDerivedLiteral(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a differentiated model")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	// The model package must be inside the module to import
+	// ad.
+	dir, err := os.MkdirTemp(".", "literal")
+	if err != nil {
+		t.Fatalf("failed to create model directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(fname, src string) {
+		err := os.WriteFile(filepath.Join(dir, fname), []byte(src), 0644)
+		if err != nil {
+			t.Fatalf("failed to write %s: %v", fname, err)
+		}
+	}
+
+	write("model.go", `package literal
+
+type Params struct {
+	N int
+	X float64
+}
+
+type Model struct{}
+
+func (m Model) Observe(x []float64) float64 {
+	p := Params{N: 1, X: x[0]}
+	p = Params{N: p.N + 1, X: p.X * float64(p.N)}
+	p = Params{N: p.N + 1, X: p.X * float64(p.N)}
+	ps := make([]Params, 2)
+	ps[p.N-2] = Params{N: p.N, X: p.X * x[1]}
+	var y [2][2]float64
+	y[ps[1].N-2] = [2]float64{x[1], p.X}
+	return p.X*float64(p.N) + ps[1].X + y[1][0]*y[1][1]
+}
+`)
+	err = Deriv(dir, "_")
+	if err != nil {
+		t.Fatalf("failed to differentiate: %v", err)
+	}
+	write("ad/model_test.go", fmt.Sprintf(`package literal
+
+import (
+	"bitbucket.org/dtolpin/infergo/model"
+	original "bitbucket.org/dtolpin/infergo/ad/%s"
+	"math"
+	"testing"
+)
+
+func TestModel(t *testing.T) {
+	x := []float64{1.5, -2}
+	want := original.Model{}.Observe(x)
+	// p.X = 2 x[0], p.N = 3, ps[1].X = 2 x[0] x[1],
+	// y[1] = {x[1], 2 x[0]}.
+	if got := 6*x[0] + 4*x[0]*x[1]; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("wrong value of the original model: got %%v, want %%v",
+			want, got)
+	}
+	m := Model{}
+	if got := m.Observe(x); math.Abs(got-want) > 1e-9 {
+		t.Errorf("wrong value: got %%v, want %%v", got, want)
+	}
+	grad := model.Gradient(m)
+	wantGrad := []float64{6 + 4*x[1], 4 * x[0]}
+	for i := range grad {
+		if math.Abs(grad[i]-wantGrad[i]) > 1e-9 {
+			t.Errorf("wrong gradient: got %%v, want %%v",
+				grad, wantGrad)
+			break
+		}
+	}
+}
+`, filepath.Base(dir)))
+
+	cmd := exec.Command(gotool, "test", "./"+filepath.ToSlash(filepath.Join(dir, "ad")))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Errorf("differentiated model failed: %v\n%s", err, out)
+	}
+}
@@ -33,13 +33,41 @@ func ElementalGradient(f interface{}) (ElementalGradientFunc, bool) {
 	return g, ok
 }
 
+// ElementalHessianFunc accepts the function value and the
+// parameters and returns the matrix of second partial
+// derivatives. Hessians are only needed for second-order
+// differentiation (see hessian.go) and are registered
+// separately from gradients.
+type ElementalHessianFunc func(value float64, params ...float64) [][]float64
+
+var hessians map[uintptr]ElementalHessianFunc
+
+// RegisterElementalHessian registers the Hessian for an
+// elemental function.
+func RegisterElementalHessian(f interface{}, h ElementalHessianFunc) {
+	hessians[fkey(f)] = h
+}
+
+// ElementalHessian returns the Hessian for a function. If the
+// Hessian is not registered, the second returned value is
+// false. Exported for testing.
+func ElementalHessian(f interface{}) (ElementalHessianFunc, bool) {
+	h, ok := hessians[fkey(f)]
+	return h, ok
+}
+
 // Elementals from the math package.
 func init() {
 	elementals = make(map[uintptr]ElementalGradientFunc)
+	hessians = make(map[uintptr]ElementalHessianFunc)
 	RegisterElemental(math.Sqrt,
 		func(value float64, _ ...float64) []float64 {
 			return []float64{0.5 / value}
 		})
+	RegisterElementalHessian(math.Sqrt,
+		func(value float64, _ ...float64) [][]float64 {
+			return [][]float64{{-0.25 / (value * value * value)}}
+		})
 	RegisterElemental(math.Abs,
 		func(value float64, params ...float64) []float64 {
 			deriv := 0.
@@ -51,16 +79,28 @@ func init() {
 			}
 			return []float64{deriv}
 		})
+	RegisterElementalHessian(math.Abs,
+		func(_ float64, _ ...float64) [][]float64 {
+			return [][]float64{{0}}
+		})
 
 	// Exponential and logarithmic
 	RegisterElemental(math.Exp,
 		func(value float64, _ ...float64) []float64 {
 			return []float64{value}
 		})
+	RegisterElementalHessian(math.Exp,
+		func(value float64, _ ...float64) [][]float64 {
+			return [][]float64{{value}}
+		})
 	RegisterElemental(math.Log,
 		func(_ float64, params ...float64) []float64 {
 			return []float64{1 / params[0]}
 		})
+	RegisterElementalHessian(math.Log,
+		func(_ float64, params ...float64) [][]float64 {
+			return [][]float64{{-1 / (params[0] * params[0])}}
+		})
 	RegisterElemental(math.Pow,
 		func(value float64, params ...float64) []float64 {
 			return []float64{
@@ -68,20 +108,42 @@ func init() {
 				value * math.Log(params[0]),
 			}
 		})
+	RegisterElementalHessian(math.Pow,
+		func(value float64, params ...float64) [][]float64 {
+			x, y := params[0], params[1]
+			logx := math.Log(x)
+			dxdy := value * (1 + y*logx) / x
+			return [][]float64{
+				{y * (y - 1) * value / (x * x), dxdy},
+				{dxdy, value * logx * logx},
+			}
+		})
 
 	// Trigonometric
 	RegisterElemental(math.Sin,
 		func(_ float64, params ...float64) []float64 {
 			return []float64{math.Cos(params[0])}
 		})
+	RegisterElementalHessian(math.Sin,
+		func(value float64, _ ...float64) [][]float64 {
+			return [][]float64{{-value}}
+		})
 	RegisterElemental(math.Cos,
 		func(_ float64, params ...float64) []float64 {
 			return []float64{-math.Sin(params[0])}
 		})
+	RegisterElementalHessian(math.Cos,
+		func(value float64, _ ...float64) [][]float64 {
+			return [][]float64{{-value}}
+		})
 	RegisterElemental(math.Tan,
 		func(value float64, _ ...float64) []float64 {
 			return []float64{1 + value*value}
 		})
+	RegisterElementalHessian(math.Tan,
+		func(value float64, _ ...float64) [][]float64 {
+			return [][]float64{{2 * value * (1 + value*value)}}
+		})
 
 	// Error function
 	RegisterElemental(math.Erf,
@@ -89,9 +151,21 @@ func init() {
 			return []float64{
 				2 / math.SqrtPi * math.Exp(-params[0]*params[0])}
 		})
+	RegisterElementalHessian(math.Erf,
+		func(_ float64, params ...float64) [][]float64 {
+			x := params[0]
+			return [][]float64{{
+				-4 * x / math.SqrtPi * math.Exp(-x*x)}}
+		})
 	RegisterElemental(math.Erfc,
 		func(_ float64, params ...float64) []float64 {
 			return []float64{
 				-2 / math.SqrtPi * math.Exp(-params[0]*params[0])}
 		})
+	RegisterElementalHessian(math.Erfc,
+		func(_ float64, params ...float64) [][]float64 {
+			x := params[0]
+			return [][]float64{{
+				4 * x / math.SqrtPi * math.Exp(-x*x)}}
+		})
 }
@@ -110,3 +110,57 @@ func TestMathBinary(t *testing.T) {
 		}
 	}
 }
+
+// Check that Hessians of functions from math are defined and
+// agree with finite differences of the gradients.
+func TestMathHessian(t *testing.T) {
+	unary := func(f func(float64) float64) func(...float64) float64 {
+		return func(x ...float64) float64 { return f(x[0]) }
+	}
+	for _, c := range []struct {
+		s  string
+		f  interface{}
+		fv func(...float64) float64
+		x  [][]float64
+	}{
+		{"sqrt", math.Sqrt, unary(math.Sqrt), [][]float64{{0.25}, {4}}},
+		{"abs", math.Abs, unary(math.Abs), [][]float64{{2}, {-3}}},
+		{"exp", math.Exp, unary(math.Exp), [][]float64{{-1}, {2}}},
+		{"log", math.Log, unary(math.Log), [][]float64{{0.5}, {3}}},
+		{"sin", math.Sin, unary(math.Sin), [][]float64{{0}, {1}}},
+		{"cos", math.Cos, unary(math.Cos), [][]float64{{0}, {1}}},
+		{"tan", math.Tan, unary(math.Tan), [][]float64{{0}, {1}}},
+		{"erf", math.Erf, unary(math.Erf), [][]float64{{0}, {0.5}}},
+		{"erfc", math.Erfc, unary(math.Erfc), [][]float64{{0}, {-1}}},
+		{"pow", math.Pow,
+			func(x ...float64) float64 { return math.Pow(x[0], x[1]) },
+			[][]float64{{2, 2}, {1.5, -0.5}}},
+	} {
+		grad, _ := ElementalGradient(c.f)
+		hess, ok := ElementalHessian(c.f)
+		if !ok {
+			t.Errorf("No Hessian for %v", c.s)
+			continue
+		}
+		const h = 1e-6
+		for _, x := range c.x {
+			got := hess(c.fv(x...), x...)
+			for j := range x {
+				xj := x[j]
+				x[j] = xj + h
+				gp := grad(c.fv(x...), x...)
+				x[j] = xj - h
+				gm := grad(c.fv(x...), x...)
+				x[j] = xj
+				for i := range x {
+					want := (gp[i] - gm[i]) / (2 * h)
+					if math.Abs(got[i][j]-want) > 1e-5 {
+						t.Errorf("Wrong Hessian of %v(%.4g): "+
+							"got %.4g, want %.4g at (%d, %d)",
+							c.s, x, got[i][j], want, i, j)
+					}
+				}
+			}
+		}
+	}
+}
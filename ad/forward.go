@@ -0,0 +1,114 @@
+package ad
+
+// Forward mode of automatic differentiation
+
+import (
+	"fmt"
+)
+
+// Forward mode computes the directional derivative of a
+// differentiated model along with the value. Each place is
+// paired with its tangent, and the tangents are propagated by
+// the same tape calls which compute the values, as if places
+// held dual numbers. The tape is still written, and Pop
+// discards it after the forward pass.
+
+// JVP returns the Jacobian-vector product, that is the
+// derivative of the log-likelihood of model m at x in
+// direction v. The model must be differentiated by deriv.
+func JVP(m observer, x, v []float64) float64 {
+	tape := tapes.get()
+	defer tape.track(x, v)()
+	tape.Observe(m, x)
+	c := &tape.cstack[len(tape.cstack)-1]
+	jvp := tape.tangents[tape.places[c.p]]
+	tape.Pop()
+	return jvp
+}
+
+// track starts tracking tangents, seeding the tangents of x
+// with v, and returns a function which restores the tangents
+// of the outer forward pass, if any, as forward passes may be
+// nested.
+func (tape *Tape) track(x, v []float64) func() {
+	if len(x) != len(v) {
+		panic(fmt.Sprintf("lengths of x and v are different: "+
+			"got len(x)=%v, len(v)=%v", len(x), len(v)))
+	}
+	tangents := tape.tangents
+	tape.tangents = make(map[*float64]float64, len(x))
+	for i := range x {
+		tape.tangents[&x[i]] = v[i]
+	}
+	return func() {
+		tape.tangents = tangents
+	}
+}
+
+// forward computes the tangents of the places written by
+// record r, which has just been run. forward is only called
+// when tangents are tracked.
+func (tape *Tape) forward(r *record) {
+	switch r.typ {
+	case typAssignment: // x; d/dx = 1
+		// Save the tangents of the left-hand side, to be
+		// restored on the backward pass along with the values.
+		for len(tape.tvalues) < r.v+r.op {
+			tape.tvalues = append(tape.tvalues, 0)
+		}
+		for i := 0; i != r.op; i++ {
+			tape.tvalues[r.v+i] = tape.tangents[tape.places[r.p+i]]
+		}
+		if r.op == 1 {
+			tape.tangents[tape.places[r.p]] =
+				tape.tangents[tape.places[r.p+1]]
+		} else {
+			// The right-hand side may overlap with the
+			// left-hand side, collect tangents first.
+			d := make([]float64, r.op)
+			for i := 0; i != r.op; i++ {
+				d[i] = tape.tangents[tape.places[r.p+r.op+i]]
+			}
+			for i := 0; i != r.op; i++ {
+				tape.tangents[tape.places[r.p+i]] = d[i]
+			}
+		}
+	case typArithmetic:
+		var d float64
+		switch r.op {
+		case OpNeg: // -x; d/dx = -1
+			d = -tape.tangents[tape.places[r.p+1]]
+		case OpAdd: // x + y; d/dx = 1; d/dy = 1
+			d = tape.tangents[tape.places[r.p+1]] +
+				tape.tangents[tape.places[r.p+2]]
+		case OpSub: // x - y; d/dx = 1; d/dy = -1
+			d = tape.tangents[tape.places[r.p+1]] -
+				tape.tangents[tape.places[r.p+2]]
+		case OpMul: // x * y; d/dx = y; d/dy = x
+			d = tape.tangents[tape.places[r.p+1]]**tape.places[r.p+2] +
+				*tape.places[r.p+1]*tape.tangents[tape.places[r.p+2]]
+		case OpDiv: // x / y; d/dx = 1 / y; d/dy = - d/dx * p
+			d = (tape.tangents[tape.places[r.p+1]] -
+				*tape.places[r.p]*tape.tangents[tape.places[r.p+2]]) /
+				*tape.places[r.p+2]
+		default:
+			panic(fmt.Sprintf("bad opcode %v", r.op))
+		}
+		tape.tangents[tape.places[r.p]] = d
+	case typElemental: // f(x, y, ...)
+		e := &tape.elementals[r.op]
+		g := e.g(*tape.places[r.p], tape.values[r.v:r.v+e.n]...)
+		if len(g) != e.n {
+			panic(fmt.Sprintf(
+				"wrong gradient size: got %d, want %d",
+				len(g), e.n))
+		}
+		d := 0.
+		for i := 0; i != e.n; i++ {
+			d += g[i] * tape.tangents[tape.places[r.p+1+i]]
+		}
+		tape.tangents[tape.places[r.p]] = d
+	default:
+		panic(fmt.Sprintf("bad type %v", r.typ))
+	}
+}
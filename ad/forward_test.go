@@ -0,0 +1,104 @@
+package ad
+
+// Testing the forward mode
+
+import (
+	"math"
+	"testing"
+)
+
+// fmodel turns a function writing the tape into a model.
+type fmodel func(x []float64) float64
+
+func (f fmodel) Observe(x []float64) float64 {
+	Setup(x)
+	return f(x)
+}
+
+func TestJVP(t *testing.T) {
+	for _, c := range []struct {
+		s string
+		f fmodel
+		x []float64
+	}{
+		{"x * y",
+			func(x []float64) float64 {
+				return Return(Arithmetic(OpMul, &x[0], &x[1]))
+			},
+			[]float64{2, 3}},
+		{"x / y - x",
+			func(x []float64) float64 {
+				return Return(Arithmetic(OpSub,
+					Arithmetic(OpDiv, &x[0], &x[1]),
+					&x[0]))
+			},
+			[]float64{2, 3}},
+		{"z = sin(x * y); z = -z * x; z",
+			func(x []float64) float64 {
+				var z float64
+				Assignment(&z,
+					Elemental(math.Sin,
+						Arithmetic(OpMul, &x[0], &x[1])))
+				Assignment(&z,
+					Arithmetic(OpMul, Arithmetic(OpNeg, &z), &x[0]))
+				return Return(&z)
+			},
+			[]float64{1, 2}},
+		{"x, y = y, x; 2*x + y*y",
+			func(x []float64) float64 {
+				ParallelAssignment(&x[0], &x[1], &x[1], &x[0])
+				return Return(Arithmetic(OpAdd,
+					Arithmetic(OpMul, Value(2), &x[0]),
+					Arithmetic(OpMul, &x[1], &x[1])))
+			},
+			[]float64{1, 3}},
+		{"twoArgElemental(exp(x), y) + vlemental(x, y)",
+			func(x []float64) float64 {
+				return Return(Arithmetic(OpAdd,
+					Elemental(twoArgElemental,
+						Elemental(math.Exp, &x[0]), &x[1]),
+					Vlemental(vlemental, x)))
+			},
+			[]float64{0.5, 2}},
+		{"(x, y -> x * y)(x, y + 1)",
+			func(x []float64) float64 {
+				return Return(
+					Call(func(_vararg []float64) {
+						func(a, b float64) float64 {
+							Enter(&a, &b)
+							return Return(Arithmetic(OpMul, &a, &b))
+						}(0, 0)
+					}, 2, &x[0], Arithmetic(OpAdd, &x[1], Value(1))))
+			},
+			[]float64{3, 4}},
+	} {
+		for _, v := range [][]float64{{1, 0}, {0, 1}, {0.5, -2}} {
+			x := make([]float64, len(c.x))
+			copy(x, c.x)
+			g := ddx(x, func(x []float64) { c.f(x) })
+			copy(x, c.x)
+			jvp := JVP(c.f, x, v)
+			gv := 0.
+			for i := range g {
+				gv += g[i] * v[i]
+			}
+			if math.Abs(jvp-gv) > 1e-9 {
+				t.Errorf("%s, x=%v, v=%v: jvp=%v, wanted %v",
+					c.s, c.x, v, jvp, gv)
+			}
+		}
+	}
+}
+
+// The tape must be restored after a forward pass.
+func TestJVPPop(t *testing.T) {
+	shouldPop(t, []float64{0, 1}, func(x []float64) {
+		JVP(fmodel(func(x []float64) float64 {
+			return Return(Arithmetic(OpMul, &x[0], &x[1]))
+		}), x, []float64{1, 1})
+		Return(Arithmetic(OpAdd, &x[0], &x[1]))
+	})
+	if tapes.get().tangents != nil {
+		t.Errorf("tangents must not be tracked after JVP")
+	}
+}
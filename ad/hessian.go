@@ -0,0 +1,172 @@
+package ad
+
+// Second-order differentiation
+
+import (
+	"fmt"
+)
+
+// The Hessian-vector product is computed in forward-over-reverse
+// mode: the forward pass tracks the tangents of places in the
+// direction of the vector, as in JVP, and the backward pass
+// propagates the tangents of the adjoints along with the
+// adjoints. The tangents of the adjoints of the parameters are
+// the Hessian-vector product. Elementals must have their
+// Hessians registered through RegisterElementalHessian.
+
+// HessianVector returns the product of the Hessian of the
+// log-likelihood of model m at x and vector v. The model must
+// be differentiated by deriv.
+func HessianVector(m observer, x, v []float64) []float64 {
+	tape := tapes.get()
+	defer tape.track(x, v)()
+	tape.Observe(m, x)
+	// The tape is popped even if an elemental has no
+	// Hessian, so that the caller may recover.
+	defer tape.Pop()
+	return tape.hessianVector()
+}
+
+// Hessian returns the Hessian of the log-likelihood of model m
+// at x, computed column by column as Hessian-vector products
+// with the unit vectors.
+func Hessian(m observer, x []float64) [][]float64 {
+	h := make([][]float64, len(x))
+	v := make([]float64, len(x))
+	for j := range x {
+		v[j] = 1
+		h[j] = HessianVector(m, x, v)
+		v[j] = 0
+	}
+	// The columns are symmetric up to rounding errors, make
+	// the Hessian exactly symmetric.
+	for i := range h {
+		for j := 0; j != i; j++ {
+			h[i][j] = 0.5 * (h[i][j] + h[j][i])
+			h[j][i] = h[i][j]
+		}
+	}
+	return h
+}
+
+// hessianVector runs the backward pass on the tape, tracking
+// the tangents of the adjoints, and returns the tangents of the
+// partial derivatives with respect to the parameters of
+// Observe. Just like the values, the tangents of places
+// overwritten by assignments are restored on the way back.
+func (tape *Tape) hessianVector() []float64 {
+	if len(tape.cstack) == 0 {
+		panic("HessianVector() called with empty tape")
+	}
+	c := &tape.cstack[len(tape.cstack)-1]
+	tape.adjoints = tape.zeroed(tape.adjoints)
+	tape.dadjoints = tape.zeroed(tape.dadjoints)
+	adjoints, dadjoints := tape.adjoints, tape.dadjoints
+	adjoints[tape.slots[c.p]] = 1
+	for ir := len(tape.records); ir != c.r; {
+		ir--
+		r := &tape.records[ir]
+		switch r.typ {
+		case typDummy:
+		case typAssignment: // x; d/dx = 1
+			// Restore the previous values and tangents.
+			for i := 0; i != r.op; i++ {
+				*tape.places[r.p+i] = tape.values[r.v+i]
+				tape.tangents[tape.places[r.p+i]] = tape.tvalues[r.v+i]
+			}
+			// The right-hand side may overlap with the
+			// left-hand side, save the adjoints first.
+			a := make([]float64, r.op)
+			da := make([]float64, r.op)
+			for i := 0; i != r.op; i++ {
+				a[i] = adjoints[tape.slots[r.p+i]]
+				da[i] = dadjoints[tape.slots[r.p+i]]
+			}
+			for i := 0; i != r.op; i++ {
+				adjoints[tape.slots[r.p+i]] = 0
+				dadjoints[tape.slots[r.p+i]] = 0
+			}
+			for i := 0; i != r.op; i++ {
+				adjoints[tape.slots[r.p+r.op+i]] += a[i]
+				dadjoints[tape.slots[r.p+r.op+i]] += da[i]
+			}
+		case typArithmetic:
+			p, px := tape.places[r.p], tape.places[r.p+1]
+			sp, sx := tape.slots[r.p], tape.slots[r.p+1]
+			var py *float64
+			var sy int
+			if r.op != OpNeg {
+				py, sy = tape.places[r.p+2], tape.slots[r.p+2]
+			}
+			a, da := adjoints[sp], dadjoints[sp]
+			switch r.op {
+			case OpNeg: // -x; d/dx = -1
+				adjoints[sx] -= a
+				dadjoints[sx] -= da
+			case OpAdd: // x + y; d/dx = 1; d/dy = 1
+				adjoints[sx] += a
+				dadjoints[sx] += da
+				adjoints[sy] += a
+				dadjoints[sy] += da
+			case OpSub: // x - y; d/dx = 1; d/dy = -1
+				adjoints[sx] += a
+				dadjoints[sx] += da
+				adjoints[sy] -= a
+				dadjoints[sy] -= da
+			case OpMul: // x * y; d/dx = y; d/dy = x
+				ax, dax := a**py, da**py+a*tape.tangents[py]
+				ay, day := a**px, da**px+a*tape.tangents[px]
+				adjoints[sx] += ax
+				dadjoints[sx] += dax
+				adjoints[sy] += ay
+				dadjoints[sy] += day
+			case OpDiv: // x / y; d/dx = 1 / y; d/dy = - d/dx * p
+				ax := a / *py
+				dax := (da - ax*tape.tangents[py]) / *py
+				ay := -ax * *p
+				day := -dax**p - ax*tape.tangents[p]
+				adjoints[sx] += ax
+				dadjoints[sx] += dax
+				adjoints[sy] += ay
+				dadjoints[sy] += day
+			default:
+				panic(fmt.Sprintf("bad opcode %v", r.op))
+			}
+		case typElemental: // f(x, y, ...)
+			p := tape.places[r.p]
+			a, da := adjoints[tape.slots[r.p]], dadjoints[tape.slots[r.p]]
+			e := &tape.elementals[r.op]
+			if e.h == nil {
+				panic("no Hessian for elemental")
+			}
+			params := tape.values[r.v : r.v+e.n]
+			g := e.g(*p, params...)
+			h := e.h(*p, params...)
+			if len(g) != e.n || len(h) != e.n {
+				panic(fmt.Sprintf(
+					"wrong gradient or Hessian size: "+
+						"got %d and %d, want %d",
+					len(g), len(h), e.n))
+			}
+			for i := 0; i != e.n; i++ {
+				// d(a*g_i) = da*g_i + a*sum_j h_ij*dx_j
+				dg := 0.
+				for j := 0; j != e.n; j++ {
+					dg += h[i][j] * tape.tangents[tape.places[r.p+1+j]]
+				}
+				adjoints[tape.slots[r.p+1+i]] += a * g[i]
+				dadjoints[tape.slots[r.p+1+i]] += da*g[i] + a*dg
+			}
+		default:
+			panic(fmt.Sprintf("bad type %v", r.typ))
+		}
+	}
+
+	// Collect the tangents of the partials; places 1 to c.n
+	// are parameters.
+	hv := make([]float64, c.n)
+	for i := 0; i != c.n; i++ {
+		hv[i] = dadjoints[tape.slots[c.p+i+1]]
+	}
+	return hv
+}
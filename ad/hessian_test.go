@@ -0,0 +1,190 @@
+package ad
+
+// Testing second-order differentiation
+
+import (
+	"math"
+	"testing"
+)
+
+func init() {
+	RegisterElemental(noHessianElemental,
+		func(v float64, a ...float64) []float64 {
+			return []float64{2 * a[0]}
+		})
+	RegisterElementalHessian(twoArgElemental,
+		func(v float64, a ...float64) [][]float64 {
+			return [][]float64{{0, 1}, {1, 0}}
+		})
+	RegisterElementalHessian(threeArgElemental,
+		func(v float64, a ...float64) [][]float64 {
+			return [][]float64{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}}
+		})
+	RegisterElementalHessian(vlemental,
+		func(v float64, a ...float64) [][]float64 {
+			return [][]float64{{0, 1}, {1, 0}}
+		})
+}
+
+// fdHessian approximates the Hessian by central finite
+// differences of the gradient.
+func fdHessian(f fmodel, x []float64) [][]float64 {
+	const h = 1e-6
+	hess := make([][]float64, len(x))
+	for j := range x {
+		xj := x[j]
+		x[j] = xj + h
+		gp := ddx(x, func(x []float64) { f(x) })
+		x[j] = xj - h
+		gm := ddx(x, func(x []float64) { f(x) })
+		x[j] = xj
+		hess[j] = make([]float64, len(x))
+		for i := range x {
+			hess[j][i] = (gp[i] - gm[i]) / (2 * h)
+		}
+	}
+	return hess
+}
+
+func TestHessian(t *testing.T) {
+	for _, c := range []struct {
+		s string
+		f fmodel
+		x []float64
+	}{
+		{"x * y * y",
+			func(x []float64) float64 {
+				return Return(Arithmetic(OpMul,
+					Arithmetic(OpMul, &x[0], &x[1]), &x[1]))
+			},
+			[]float64{2, 3}},
+		{"x / y - x * x",
+			func(x []float64) float64 {
+				return Return(Arithmetic(OpSub,
+					Arithmetic(OpDiv, &x[0], &x[1]),
+					Arithmetic(OpMul, &x[0], &x[0])))
+			},
+			[]float64{2, 3}},
+		{"z = sin(x * y); z = -z * x; z",
+			func(x []float64) float64 {
+				var z float64
+				Assignment(&z,
+					Elemental(math.Sin,
+						Arithmetic(OpMul, &x[0], &x[1])))
+				Assignment(&z,
+					Arithmetic(OpMul, Arithmetic(OpNeg, &z), &x[0]))
+				return Return(&z)
+			},
+			[]float64{1, 2}},
+		{"x, y = y, x*y; x*x + log(y)",
+			func(x []float64) float64 {
+				ParallelAssignment(&x[0], &x[1],
+					&x[1], Arithmetic(OpMul, &x[0], &x[1]))
+				return Return(Arithmetic(OpAdd,
+					Arithmetic(OpMul, &x[0], &x[0]),
+					Elemental(math.Log, &x[1])))
+			},
+			[]float64{1, 3}},
+		{"pow(x, y) + sqrt(exp(x) + y)",
+			func(x []float64) float64 {
+				return Return(Arithmetic(OpAdd,
+					Elemental(math.Pow, &x[0], &x[1]),
+					Elemental(math.Sqrt,
+						Arithmetic(OpAdd,
+							Elemental(math.Exp, &x[0]), &x[1]))))
+			},
+			[]float64{1.5, 0.5}},
+		{"twoArgElemental(exp(x), y) + vlemental(x, y)",
+			func(x []float64) float64 {
+				return Return(Arithmetic(OpAdd,
+					Elemental(twoArgElemental,
+						Elemental(math.Exp, &x[0]), &x[1]),
+					Vlemental(vlemental, x)))
+			},
+			[]float64{0.5, 2}},
+		{"(x, y -> x * y * y)(x, y + x)",
+			func(x []float64) float64 {
+				return Return(
+					Call(func(_vararg []float64) {
+						func(a, b float64) float64 {
+							Enter(&a, &b)
+							return Return(Arithmetic(OpMul,
+								Arithmetic(OpMul, &a, &b), &b))
+						}(0, 0)
+					}, 2, &x[0], Arithmetic(OpAdd, &x[1], &x[0])))
+			},
+			[]float64{3, 4}},
+	} {
+		x := make([]float64, len(c.x))
+		copy(x, c.x)
+		want := fdHessian(c.f, x)
+		got := Hessian(c.f, x)
+		for i := range x {
+			if x[i] != c.x[i] {
+				t.Errorf("%s: x changed: got %v, want %v",
+					c.s, x, c.x)
+				break
+			}
+		}
+		for i := range want {
+			for j := range want[i] {
+				if math.Abs(got[i][j]-want[i][j]) > 1e-5 {
+					t.Errorf("%s, x=%v: got %v, want %v",
+						c.s, c.x, got, want)
+				}
+			}
+		}
+		v := []float64{0.5, -2}
+		hv := HessianVector(c.f, x, v)
+		for i := range hv {
+			hvi := want[i][0]*v[0] + want[i][1]*v[1]
+			if math.Abs(hv[i]-hvi) > 1e-5 {
+				t.Errorf("%s, x=%v, v=%v: got %v, want %v at %d",
+					c.s, c.x, v, hv[i], hvi, i)
+			}
+		}
+	}
+}
+
+// The tape must be restored after second-order
+// differentiation.
+func TestHessianPop(t *testing.T) {
+	shouldPop(t, []float64{0, 1}, func(x []float64) {
+		Hessian(fmodel(func(x []float64) float64 {
+			return Return(Arithmetic(OpMul, &x[0], &x[1]))
+		}), x)
+		Return(Arithmetic(OpAdd, &x[0], &x[1]))
+	})
+	if tapes.get().tangents != nil {
+		t.Errorf("tangents must not be tracked after Hessian")
+	}
+}
+
+// An elemental with the gradient but without the Hessian.
+func noHessianElemental(a float64) float64 {
+	return a * a
+}
+
+// The tape is restored when the Hessian of an elemental is
+// missing.
+func TestHessianMissing(t *testing.T) {
+	tape := tapes.get()
+	lc := len(tape.cstack)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("no panic for a missing Hessian")
+			}
+		}()
+		Hessian(fmodel(func(x []float64) float64 {
+			return Return(Elemental(noHessianElemental, &x[0]))
+		}), []float64{1})
+	}()
+	if len(tape.cstack) != lc {
+		t.Errorf("wrong number of counters: got %d, want %d",
+			len(tape.cstack), lc)
+	}
+	if tape.tangents != nil {
+		t.Errorf("tangents must not be tracked after Hessian")
+	}
+}
@@ -5,30 +5,59 @@ package ad
 import (
 	"fmt"
 	"reflect"
+	"unsafe"
 )
 
-// adTape specifies the tape as a list of records and the
-// memory.
-type adTape struct {
+// Tape specifies the tape as a list of records and the
+// memory. Differentiated code runs against a tape passed to the
+// differentiated methods (see Deriv), and a tape created by
+// NewTape and owned by the caller can be used in any goroutine,
+// one at a time, without locking.
+type Tape struct {
 	records    []record    // recorded instructions
 	places     []*float64  // variable places
 	values     []float64   // stored values
 	elementals []elemental // gradients of elementals
 	cstack     []counters  // counter stack (see below)
+	// Places are resolved to dense adjoint slots at record
+	// time (see slot below), so that the backward pass works
+	// over a slice rather than a map.
+	slots    []int              // slots of places
+	nslots   int                // number of allocated slots
+	vslots   []int              // slots of values, if any
+	vold     []float64          // memory the slots refer to
+	smaps    []map[*float64]int // slots of frames, reused
+	adjoints []float64          // adjoints, reused
+	// tangents of places, tracked in forward mode only
+	// (see forward.go)
+	tangents map[*float64]float64
+	// tangents of stored values, parallel to values, saved
+	// by assignments for second-order differentiation
+	// (see hessian.go)
+	tvalues []float64
+	// tangents of adjoints, reused (see hessian.go)
+	dadjoints []float64
 }
 
-func newTape() *adTape {
-	tape := adTape{
+// NewTape creates a new tape.
+func NewTape() *Tape {
+	tape := Tape{
 		records:    make([]record, 0),
 		places:     make([]*float64, 0),
 		values:     make([]float64, 0),
 		elementals: make([]elemental, 0),
 		cstack:     make([]counters, 0),
+		slots:      make([]int, 0),
+		smaps:      make([]map[*float64]int, 0),
 	}
 	// The returned value is in the first place;
-	// see Call and Return below.
+	// see Call and Return below. The place is outside
+	// of any frame and has no slot.
 	tape.values = append(tape.values, 0)
 	tape.places = append(tape.places, &tape.values[0])
+	tape.slots = append(tape.slots, -1)
+	tape.vslots = append(tape.vslots, -1)
+	tape.vold = tape.values[:cap(tape.values)]
 	tape.records = append(tape.records, record{typ: typDummy})
 	return &tape
 }
@@ -38,7 +67,7 @@ func newTape() *adTape {
 // drop discards the goroutine's tape. clear discards all
 // tapes.
 type tapeStore interface {
-	get() *adTape
+	get() *Tape
 	drop()
 	clear()
 }
@@ -46,6 +75,13 @@ type tapeStore interface {
 // Tapes are maintained in a global store.
 var tapes tapeStore
 
+// CurrentTape returns the goroutine's tape, from the global
+// store. Differentiated methods called without a tape run
+// against the current tape.
+func CurrentTape() *Tape {
+	return tapes.get()
+}
+
 // DropTape discards the goroutine's tape.
 func DropTape() {
 	tapes.drop()
@@ -62,19 +98,19 @@ func DropAllTapes() {
 // multiple goroutines with a single tape requires a mutex on
 // the forward-backward pass.
 
-func (tape *adTape) get() *adTape {
+func (tape *Tape) get() *Tape {
 	return tape
 }
 
-func (tape *adTape) drop()  {}
-func (tape *adTape) clear() {}
+func (tape *Tape) drop()  {}
+func (tape *Tape) clear() {}
 
 // The default tape store is a single tape, and thus
 // not thread-safe. A thread-safe tape store is provided
 // in mtstore.go.
 
 func init() {
-	tapes = newTape()
+	tapes = NewTape()
 }
 
 // record specifies the record type and indexes the tape memory
@@ -93,6 +129,7 @@ type record struct {
 type elemental struct {
 	n int                   // number of arguments
 	g ElementalGradientFunc // gradient function
+	h ElementalHessianFunc  // Hessian, in forward mode only
 }
 
 // counters holds counters for the tape components. Counters are
@@ -103,7 +140,8 @@ type counters struct {
 	r, // records
 	p, // places
 	v, // values
-	e int // elementals
+	e, // elementals
+	s int // slots
 }
 
 // Record types.
@@ -127,68 +165,146 @@ const (
 // Forward pass
 
 // Setup set ups the tape for the forward pass.
-func Setup(x []float64) {
-	push(len(x))
-	register(x)
+func (tape *Tape) Setup(x []float64) {
+	tape.push(len(x))
+	tape.register(x)
 }
 
 // push pushes a counter frame to the counter stack. n is the
 // number of function parameters.
-func push(n int) {
-	tape := tapes.get()
+func (tape *Tape) push(n int) {
 	c := counters{
 		n: n,
 		r: len(tape.records),
 		p: len(tape.places),
 		v: len(tape.values),
 		e: len(tape.elementals),
+		s: tape.nslots,
 	}
 	tape.cstack = append(tape.cstack, c)
+	// Slot maps are reused by later frames at the same depth.
+	if len(tape.smaps) < len(tape.cstack) {
+		tape.smaps = append(tape.smaps, make(map[*float64]int))
+	}
 	// The returned value is in the first place;
 	// see Call and Return below.
-	tape.places = append(tape.places, Value(0))
+	tape.place(tape.Value(0))
 }
 
 // register stores locations of function parameters at the
 // beginning of the current frame's places.  The places are then
 // used to collect the partial derivatives of the gradient.
-func register(x []float64) {
-	tape := tapes.get()
+func (tape *Tape) register(x []float64) {
 	for i := range x {
-		tape.places = append(tape.places, &x[i])
+		tape.place(&x[i])
+	}
+}
+
+// place appends places px to the tape, along with their slots.
+func (tape *Tape) place(px ...*float64) {
+	for _, p := range px {
+		tape.places = append(tape.places, p)
+		tape.slots = append(tape.slots, tape.slot(p))
+	}
+}
+
+// slot returns the adjoint slot of place p. A slot is allocated
+// when the place is first seen in the current frame. Slot maps
+// are per frame: a place of an outer frame gets a new slot in an
+// inner frame, and the backward pass of the inner frame only
+// touches the slots of the inner frame.
+func (tape *Tape) slot(p *float64) int {
+	c := &tape.cstack[len(tape.cstack)-1]
+	// Most places are values allocated by the current frame,
+	// their slots are found without a map lookup.
+	if i := tape.ivalue(p); i >= c.v {
+		if tape.vslots[i] == -1 {
+			tape.vslots[i] = tape.nslots
+			tape.nslots++
+		}
+		return tape.vslots[i]
 	}
+	smap := tape.smaps[len(tape.cstack)-1]
+	s, ok := smap[p]
+	if !ok {
+		s = tape.nslots
+		tape.nslots++
+		smap[p] = s
+	}
+	return s
+}
+
+// ivalue returns the index of p in the tape memory, or -1 if p
+// points elsewhere.
+func (tape *Tape) ivalue(p *float64) int {
+	if &tape.values[0] != &tape.vold[0] {
+		tape.rebase()
+	}
+	offset := uintptr(unsafe.Pointer(p)) -
+		uintptr(unsafe.Pointer(&tape.values[0]))
+	i := offset / unsafe.Sizeof(*p)
+	if i >= uintptr(len(tape.values)) {
+		return -1
+	}
+	return int(i)
+}
+
+// rebase is called when the tape memory has been reallocated.
+// Places in the old memory are still referenced by variables,
+// hence their slots are moved to the slot maps.
+func (tape *Tape) rebase() {
+	for f := range tape.cstack {
+		end := min(len(tape.values), len(tape.vslots), len(tape.vold))
+		if f+1 != len(tape.cstack) {
+			end = tape.cstack[f+1].v
+		}
+		for i := tape.cstack[f].v; i < end; i++ {
+			if tape.vslots[i] != -1 {
+				tape.smaps[f][&tape.vold[i]] = tape.vslots[i]
+			}
+		}
+	}
+	tape.vold = tape.values[:cap(tape.values)]
 }
 
 // Value adds value v to the memory and returns the location of
 // the value.
-func Value(v float64) *float64 {
-	tape := tapes.get()
+func (tape *Tape) Value(v float64) *float64 {
 	tape.values = append(tape.values, v)
-	return &tape.values[len(tape.values)-1]
+	p := &tape.values[len(tape.values)-1]
+	// The slot is allocated on first use.
+	for len(tape.vslots) < len(tape.values) {
+		tape.vslots = append(tape.vslots, -1)
+	}
+	tape.vslots[len(tape.values)-1] = -1
+	if tape.tangents != nil {
+		// The location may be reused, forget the tangent.
+		delete(tape.tangents, p)
+	}
+	return p
 }
 
 // Return returns the result of the differentiated function.
-func Return(px *float64) float64 {
-	tape := tapes.get()
+func (tape *Tape) Return(px *float64) float64 {
 	// The returned value goes into the first place.
 	c := &tape.cstack[len(tape.cstack)-1]
 	tape.places[c.p] = px
+	tape.slots[c.p] = tape.slot(px)
 	return *px
 }
 
 // Arithmetic encodes an arithmetic operation and returns the
 // location of the result.
-func Arithmetic(op int, px ...*float64) *float64 {
-	tape := tapes.get()
+func (tape *Tape) Arithmetic(op int, px ...*float64) *float64 {
 	// Register
-	p := Value(0)
+	p := tape.Value(0)
 	r := record{
 		typ: typArithmetic,
 		op:  op,
 		p:   len(tape.places),
 	}
-	tape.places = append(tape.places, p)
-	tape.places = append(tape.places, px...)
+	tape.place(p)
+	tape.place(px...)
 	tape.records = append(tape.records, r)
 	// Run
 	switch op {
@@ -205,12 +321,14 @@ func Arithmetic(op int, px ...*float64) *float64 {
 	default:
 		panic(fmt.Sprintf("bad opcode %v", r.op))
 	}
+	if tape.tangents != nil {
+		tape.forward(&tape.records[len(tape.records)-1])
+	}
 	return p
 }
 
 // ParallelAssigment encodes a parallel assignment.
-func ParallelAssignment(ppx ...*float64) {
-	tape := tapes.get()
+func (tape *Tape) ParallelAssignment(ppx ...*float64) {
 	// Register
 	p, px := ppx[:len(ppx)/2], ppx[len(ppx)/2:]
 	r := record{
@@ -220,11 +338,11 @@ func ParallelAssignment(ppx ...*float64) {
 		v:   len(tape.values),
 	}
 	for i := range p {
-		tape.places = append(tape.places, p[i])
+		tape.place(p[i])
 		tape.values = append(tape.values, *p[i])
 	}
 	for i := range px {
-		tape.places = append(tape.places, px[i])
+		tape.place(px[i])
 		tape.values = append(tape.values, *px[i])
 	}
 	tape.records = append(tape.records, r)
@@ -232,14 +350,16 @@ func ParallelAssignment(ppx ...*float64) {
 	for i := range p {
 		*p[i] = tape.values[len(tape.values)-len(p)+i]
 	}
+	if tape.tangents != nil {
+		tape.forward(&tape.records[len(tape.records)-1])
+	}
 }
 
 // Assignment encodes a single-value assingment.
-func Assignment(p *float64, px *float64) {
+func (tape *Tape) Assignment(p *float64, px *float64) {
 	// Can be just a call to ParallelAssignment.
 	// However most assignments are single-valued and
 	// we can avoid loops and extra allocation.
-	tape := tapes.get()
 	// Register
 	r := record{
 		typ: typAssignment,
@@ -247,26 +367,28 @@ func Assignment(p *float64, px *float64) {
 		p:   len(tape.places),
 		v:   len(tape.values),
 	}
-	tape.places = append(tape.places, p, px)
+	tape.place(p, px)
 	tape.values = append(tape.values, *p)
 	tape.records = append(tape.records, r)
 	// Run
 	*p = *px
+	if tape.tangents != nil {
+		tape.forward(&tape.records[len(tape.records)-1])
+	}
 }
 
 // Elemental encodes a call to the elemental f.
 // To call gradient without allocation on backward pass,
 // argument values are copied to the tape memory.
 // Elemental returns the location of the result.
-func Elemental(f interface{}, px ...*float64) *float64 {
-	tape := tapes.get()
+func (tape *Tape) Elemental(f interface{}, px ...*float64) *float64 {
 	g, ok := ElementalGradient(f)
 	if !ok {
 		// No gradient attached, thus not an elemental.
 		panic("not an elemental")
 	}
 	// Register
-	p := Value(0)
+	p := tape.Value(0)
 	r := record{
 		typ: typElemental,
 		op:  len(tape.elementals),
@@ -277,8 +399,11 @@ func Elemental(f interface{}, px ...*float64) *float64 {
 		n: len(px),
 		g: g,
 	}
-	tape.places = append(tape.places, p)
-	tape.places = append(tape.places, px...)
+	if tape.tangents != nil {
+		e.h, _ = ElementalHessian(f)
+	}
+	tape.place(p)
+	tape.place(px...)
 	for _, py := range px {
 		tape.values = append(tape.values, *py)
 	}
@@ -300,6 +425,9 @@ func Elemental(f interface{}, px ...*float64) *float64 {
 		}
 		*p = reflect.ValueOf(f).Call(args)[0].Float()
 	}
+	if tape.tangents != nil {
+		tape.forward(&tape.records[len(tape.records)-1])
+	}
 
 	return p
 }
@@ -308,15 +436,14 @@ func Elemental(f interface{}, px ...*float64) *float64 {
 // To call gradient without allocation on backward pass,
 // argument values are copied to the tape memory.
 // Vlemental returns the location of the result.
-func Vlemental(f func([]float64) float64, x []float64) *float64 {
-	tape := tapes.get()
+func (tape *Tape) Vlemental(f func([]float64) float64, x []float64) *float64 {
 	g, ok := ElementalGradient(f)
 	if !ok {
 		// No gradient attached, thus not an elemental.
 		panic("not an elemental")
 	}
 	// Register
-	p := Value(0)
+	p := tape.Value(0)
 	r := record{
 		typ: typElemental,
 		op:  len(tape.elementals),
@@ -327,15 +454,21 @@ func Vlemental(f func([]float64) float64, x []float64) *float64 {
 		n: len(x),
 		g: g,
 	}
+	if tape.tangents != nil {
+		e.h, _ = ElementalHessian(f)
+	}
 	tape.values = append(tape.values, x...)
-	tape.places = append(tape.places, p)
+	tape.place(p)
 	for i := range x {
-		tape.places = append(tape.places, &x[i])
+		tape.place(&x[i])
 	}
 	tape.elementals = append(tape.elementals, e)
 	tape.records = append(tape.records, r)
 	// Run
 	*p = f(x)
+	if tape.tangents != nil {
+		tape.forward(&tape.records[len(tape.records)-1])
+	}
 
 	return p
 }
@@ -345,27 +478,25 @@ func Vlemental(f func([]float64) float64, x []float64) *float64 {
 // True iff the last record on the tape is a Call record.
 // A call record is added before a call to a differentiated
 // method from another differentiated method.
-func Called() bool {
-	tape := tapes.get()
+func (tape *Tape) Called() bool {
 	return tape.records[len(tape.records)-1].typ == typCall
 }
 
 // Call wraps a call to a differentiated subfunction. narg is
 // the number of non-variadic arguments.
-func Call(
+func (tape *Tape) Call(
 	f func(_vararg []float64),
 	narg int,
 	px ...*float64,
 ) *float64 {
-	tape := tapes.get()
 	// Register function parameters. The function will assign
 	// the actual parameters to the formal parameters on entry.
 	var vararg []float64
 	if narg < len(px) {
-		vararg = variadic(px[narg:])
+		vararg = tape.variadic(px[narg:])
 	}
 	for _, py := range px[:narg] {
-		tape.places = append(tape.places, py)
+		tape.place(py)
 	}
 	// Let the method know that it was called from
 	// another method.
@@ -385,8 +516,7 @@ func Call(
 
 // variadic wraps variadic arguments into a slice for passing to
 // the underlying call.
-func variadic(px []*float64) []float64 {
-	tape := tapes.get()
+func (tape *Tape) variadic(px []*float64) []float64 {
 	// In order to pass variadic float64 arguments to a
 	// differentiated method, we build a slice on the caller
 	// side and assign the arguments to the slice. We put the
@@ -394,21 +524,42 @@ func variadic(px []*float64) []float64 {
 	var sides []*float64
 	v0 := len(tape.values)
 	for range px { // left-hand side
-		sides = append(sides, Value(0))
+		sides = append(sides, tape.Value(0))
 	}
 	vararg := tape.values[v0:]   // the slice
 	sides = append(sides, px...) // right-hand side
-	ParallelAssignment(sides...)
+	tape.ParallelAssignment(sides...)
 	// Now, the result of variadic is a slice, to be passed
 	// to the variadic argument.
 	return vararg
 }
 
+// observer is the interface of a model. It mirrors
+// model.Model, which cannot be imported here.
+type observer interface {
+	Observe(x []float64) float64
+}
+
+// tapeObserver is the interface of a model differentiated by
+// deriv, which runs against the tape passed to ObserveTape.
+type tapeObserver interface {
+	ObserveTape(tape *Tape, x []float64) float64
+}
+
+// Observe calls Observe of model m on the tape. If the model is
+// not differentiated with a tape parameter, Observe of the
+// model is called and runs on the current tape.
+func (tape *Tape) Observe(m observer, x []float64) float64 {
+	if m, ok := m.(tapeObserver); ok {
+		return m.ObserveTape(tape, x)
+	}
+	return m.Observe(x)
+}
+
 // Enter copies the actual parameters to the formal parameters.
-func Enter(px ...*float64) {
-	tape := tapes.get()
+func (tape *Tape) Enter(px ...*float64) {
 	p0 := len(tape.places) - len(px)
-	ParallelAssignment(append(px, tape.places[p0:p0+len(px)]...)...)
+	tape.ParallelAssignment(append(px, tape.places[p0:p0+len(px)]...)...)
 }
 
 // Backward pass
@@ -418,20 +569,22 @@ func Enter(px ...*float64) {
 // to an automatically differentiated function, and can be
 // called only once per call to an automatically differentiated
 // function.
-func Gradient() []float64 {
-	partials := backward()
-	Pop()
+func (tape *Tape) Gradient() []float64 {
+	partials := tape.backward()
+	tape.Pop()
 	return partials
 }
 
 // Pop deallocates current tape fragment from the tape.
 // Gradient calls Pop; when the gradient is not needed, Pop can
 // be called directly to skip gradient computation.
-func Pop() {
-	tape := tapes.get()
+func (tape *Tape) Pop() {
 	c := &tape.cstack[len(tape.cstack)-1]
 	tape.records = tape.records[:c.r]
 	tape.places = tape.places[:c.p]
+	tape.slots = tape.slots[:c.p]
+	tape.nslots = c.s
+	clear(tape.smaps[len(tape.cstack)-1])
 	tape.values = tape.values[:c.v]
 	tape.elementals = tape.elementals[:c.e]
 	tape.cstack = tape.cstack[:len(tape.cstack)-1]
@@ -440,17 +593,17 @@ func Pop() {
 // backward runs the backward pass on the tape and returns the
 // partial derivatives of the log-likelihood with respect to
 // the parameters of Observe.
-func backward() []float64 {
-	tape := tapes.get()
+func (tape *Tape) backward() []float64 {
 	if len(tape.cstack) == 0 {
 		panic("Gradient() called with empty tape")
 	}
 	c := &tape.cstack[len(tape.cstack)-1]
-	// allocate enough place for all adjoints at once, avoids
-	// reallocation of map storage, which is slow.
-	adjoints := make(map[*float64]float64, len(tape.places)-c.p)
+	// The adjoints are indexed by slots; the slots of the
+	// current frame are zeroed.
+	tape.adjoints = tape.zeroed(tape.adjoints)
+	adjoints := tape.adjoints
 	// Set the adjoint of the result to 1
-	adjoints[tape.places[c.p]] = 1
+	adjoints[tape.slots[c.p]] = 1
 	// Bottom is the first record in the current frame.
 	bottom := tape.cstack[len(tape.cstack)-1].r
 	for ir := len(tape.records); ir != bottom; {
@@ -465,13 +618,13 @@ func backward() []float64 {
 				// Restore the previous value.
 				*tape.places[r.p] = tape.values[r.v]
 				// Save the adjoint.
-				a := adjoints[tape.places[r.p]]
+				a := adjoints[tape.slots[r.p]]
 				// Update the adjoint: the adjoint of the
 				// left-hand side is zero (because the place is
 				// overwritten) except if the right-hand side is
 				// the same place.
-				adjoints[tape.places[r.p]] = 0
-				adjoints[tape.places[r.p+1]] += a
+				adjoints[tape.slots[r.p]] = 0
+				adjoints[tape.slots[r.p+1]] += a
 			} else {
 				// Restore the previous values.
 				for i := 0; i != r.op; i++ {
@@ -481,45 +634,45 @@ func backward() []float64 {
 				// a is a vector, re-use values.
 				a := tape.values[r.v : r.v+r.op]
 				for i := 0; i != r.op; i++ {
-					a[i] = adjoints[tape.places[r.p+i]]
+					a[i] = adjoints[tape.slots[r.p+i]]
 				}
 				// Update the adjoints: the adjoints of the
 				// left-hand side are zero (because the places
 				// are overwritten) except if the right-hand
 				// side is the same place.
 				for i := 0; i != r.op; i++ {
-					adjoints[tape.places[r.p+i]] = 0
+					adjoints[tape.slots[r.p+i]] = 0
 				}
 				for i := 0; i != r.op; i++ {
-					adjoints[tape.places[r.p+r.op+i]] += a[i]
+					adjoints[tape.slots[r.p+r.op+i]] += a[i]
 				}
 			}
 		case typArithmetic:
-			a := adjoints[tape.places[r.p]]
+			a := adjoints[tape.slots[r.p]]
 			switch r.op {
 			case OpNeg: // -x; d/dx = -1
-				adjoints[tape.places[r.p+1]] -= a
+				adjoints[tape.slots[r.p+1]] -= a
 			case OpAdd: // x + y; d/dx = 1; d/dy = 1
-				adjoints[tape.places[r.p+1]] += a
-				adjoints[tape.places[r.p+2]] += a
+				adjoints[tape.slots[r.p+1]] += a
+				adjoints[tape.slots[r.p+2]] += a
 			case OpSub: // x - y; d/dx = 1; d/dy = -1
-				adjoints[tape.places[r.p+1]] += a
-				adjoints[tape.places[r.p+2]] -= a
+				adjoints[tape.slots[r.p+1]] += a
+				adjoints[tape.slots[r.p+2]] -= a
 			case OpMul: // x * y; d/dx = y; d/dy = x
 				ax := a * *tape.places[r.p+2]
 				ay := a * *tape.places[r.p+1]
-				adjoints[tape.places[r.p+1]] += ax
-				adjoints[tape.places[r.p+2]] += ay
+				adjoints[tape.slots[r.p+1]] += ax
+				adjoints[tape.slots[r.p+2]] += ay
 			case OpDiv: // x / y; d/dx = 1 / y; d/dy = - d/dx * p
 				ax := a / *tape.places[r.p+2]
 				ay := -ax * *tape.places[r.p]
-				adjoints[tape.places[r.p+1]] += ax
-				adjoints[tape.places[r.p+2]] += ay
+				adjoints[tape.slots[r.p+1]] += ax
+				adjoints[tape.slots[r.p+2]] += ay
 			default:
 				panic(fmt.Sprintf("bad opcode %v", r.op))
 			}
 		case typElemental: // f(x, y, ...)
-			a := adjoints[tape.places[r.p]]
+			a := adjoints[tape.slots[r.p]]
 			e := &tape.elementals[r.op]
 			d := e.g(*tape.places[r.p],
 				// Parameters must be copied to tape.values
@@ -531,7 +684,7 @@ func backward() []float64 {
 					len(d), e.n))
 			}
 			for i := 0; i != e.n; i++ {
-				adjoints[tape.places[r.p+1+i]] += a * d[i]
+				adjoints[tape.slots[r.p+1+i]] += a * d[i]
 			}
 		default:
 			panic(fmt.Sprintf("bad type %v", r.typ))
@@ -541,8 +694,97 @@ func backward() []float64 {
 	// Collect the partials; places 1 to c.n are parameters.
 	partials := make([]float64, c.n)
 	for i := 0; i != c.n; i++ {
-		partials[i] = adjoints[tape.places[c.p+i+1]]
+		partials[i] = adjoints[tape.slots[c.p+i+1]]
 	}
 
 	return partials
 }
+
+// zeroed grows buf to hold all slots, zeroes the slots of the
+// current frame, and returns the buffer. Adjoint buffers are
+// kept on the tape and reused across calls.
+func (tape *Tape) zeroed(buf []float64) []float64 {
+	if len(buf) < tape.nslots {
+		buf = append(buf, make([]float64, tape.nslots-len(buf))...)
+	}
+	c := &tape.cstack[len(tape.cstack)-1]
+	clear(buf[c.s:tape.nslots])
+	return buf
+}
+
+// Operations on the current tape
+
+// The functions below call the corresponding methods on the
+// goroutine's tape (see CurrentTape). They are used by
+// hand-written differentiated code and by models differentiated
+// without a tape parameter.
+
+// Setup calls Setup on the current tape.
+func Setup(x []float64) {
+	tapes.get().Setup(x)
+}
+
+// Value calls Value on the current tape.
+func Value(v float64) *float64 {
+	return tapes.get().Value(v)
+}
+
+// Return calls Return on the current tape.
+func Return(px *float64) float64 {
+	return tapes.get().Return(px)
+}
+
+// Arithmetic calls Arithmetic on the current tape.
+func Arithmetic(op int, px ...*float64) *float64 {
+	return tapes.get().Arithmetic(op, px...)
+}
+
+// ParallelAssignment calls ParallelAssignment on the current
+// tape.
+func ParallelAssignment(ppx ...*float64) {
+	tapes.get().ParallelAssignment(ppx...)
+}
+
+// Assignment calls Assignment on the current tape.
+func Assignment(p *float64, px *float64) {
+	tapes.get().Assignment(p, px)
+}
+
+// Elemental calls Elemental on the current tape.
+func Elemental(f interface{}, px ...*float64) *float64 {
+	return tapes.get().Elemental(f, px...)
+}
+
+// Vlemental calls Vlemental on the current tape.
+func Vlemental(f func([]float64) float64, x []float64) *float64 {
+	return tapes.get().Vlemental(f, x)
+}
+
+// Called calls Called on the current tape.
+func Called() bool {
+	return tapes.get().Called()
+}
+
+// Call calls Call on the current tape.
+func Call(
+	f func(_vararg []float64),
+	narg int,
+	px ...*float64,
+) *float64 {
+	return tapes.get().Call(f, narg, px...)
+}
+
+// Enter calls Enter on the current tape.
+func Enter(px ...*float64) {
+	tapes.get().Enter(px...)
+}
+
+// Gradient calls Gradient on the current tape.
+func Gradient() []float64 {
+	return tapes.get().Gradient()
+}
+
+// Pop calls Pop on the current tape.
+func Pop() {
+	tapes.get().Pop()
+}
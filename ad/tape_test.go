@@ -466,3 +466,83 @@ func TestCall(t *testing.T) {
 				{{1, 2}, {2, 1}}}},
 	})
 }
+
+// Places must keep their slots when the tape memory is
+// reallocated, including by nested differentiation.
+func TestRealloc(t *testing.T) {
+	saved := tapes
+	defer func() { tapes = saved }()
+	tapes = NewTape()
+	data := make([]float64, 1000)
+	for i := range data {
+		data[i] = math.Sin(float64(i))
+	}
+	x := []float64{0.5, -0.5}
+	g := ddx(x, func(x []float64) {
+		var ll, sigma float64
+		Assignment(&sigma, Elemental(math.Exp, &x[1]))
+		for j := range data {
+			d := Arithmetic(OpDiv,
+				Arithmetic(OpSub, &data[j], &x[0]), &sigma)
+			if j%100 == 0 {
+				ddx([]float64{data[j]}, func(y []float64) {
+					Return(Arithmetic(OpMul, &y[0], d))
+				})
+			}
+			Assignment(&ll,
+				Arithmetic(OpSub,
+					Arithmetic(OpSub, &ll,
+						Arithmetic(OpMul, Value(0.5),
+							Arithmetic(OpMul, d, d))),
+					&x[1]))
+		}
+		Return(&ll)
+	})
+	want := make([]float64, 2)
+	sigma := math.Exp(x[1])
+	for _, y := range data {
+		d := (y - x[0]) / sigma
+		want[0] += d / sigma
+		want[1] += d*d - 1
+	}
+	for i := range want {
+		if math.Abs(g[i]-want[i]) > 1e-9*math.Abs(want[i]) {
+			t.Errorf("wrong gradient: got %v, want %v", g, want)
+			break
+		}
+	}
+}
+
+// Benchmarks
+
+// benchGradient computes the gradient of the log-likelihood of
+// a normal model with unknown mean and log standard deviation
+// over n data points, as the differentiated code would, and
+// thus benchmarks a tape of about 10n records.
+func benchGradient(b *testing.B, n int) {
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = math.Sin(float64(i))
+	}
+	x := []float64{0.5, -0.5}
+	for i := 0; i != b.N; i++ {
+		ddx(x, func(x []float64) {
+			var ll, sigma float64
+			Assignment(&sigma, Elemental(math.Exp, &x[1]))
+			for j := range data {
+				d := Arithmetic(OpDiv,
+					Arithmetic(OpSub, &data[j], &x[0]), &sigma)
+				Assignment(&ll,
+					Arithmetic(OpSub,
+						Arithmetic(OpSub, &ll,
+							Arithmetic(OpMul, Value(0.5),
+								Arithmetic(OpMul, d, d))),
+						&x[1]))
+			}
+			Return(&ll)
+		})
+	}
+}
+
+func BenchmarkGradient100(b *testing.B)   { benchGradient(b, 100) }
+func BenchmarkGradient10000(b *testing.B) { benchGradient(b, 10000) }
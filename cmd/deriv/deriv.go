@@ -9,7 +9,7 @@ import (
 
 const (
 	command = "deriv"
-	version = "1.2.4"
+	version = "1.3.0"
 )
 
 var (
@@ -0,0 +1,366 @@
+// Package diag implements convergence diagnostics for MCMC:
+// split and rank-normalized R-hat, bulk and tail effective
+// sample size, Monte Carlo standard error, autocorrelation,
+// and quantiles (https://arxiv.org/abs/1903.08008).
+//
+// Diagnostics of a single parameter are computed on draws
+// from one or more chains, chains[i][j] being draw j from
+// chain i. All chains must be of the same length.
+package diag
+
+import (
+	"math"
+	"sort"
+)
+
+// Collect collects n draws from the samples channel of an
+// MCMC sampler. Collect returns fewer draws if the channel is
+// closed.
+func Collect(samples <-chan []float64, n int) [][]float64 {
+	draws := make([][]float64, 0, n)
+	for i := 0; i != n; i++ {
+		x := <-samples
+		if len(x) == 0 {
+			break
+		}
+		draw := make([]float64, len(x))
+		copy(draw, x)
+		draws = append(draws, draw)
+	}
+	return draws
+}
+
+// Parameter returns the draws of parameter i from collected
+// draws of each chain, draws[chain][draw][parameter].
+func Parameter(draws [][][]float64, i int) [][]float64 {
+	chains := make([][]float64, len(draws))
+	for ichain, chain := range draws {
+		chains[ichain] = make([]float64, len(chain))
+		for j, x := range chain {
+			chains[ichain][j] = x[i]
+		}
+	}
+	return chains
+}
+
+// Summary summarizes the draws of a parameter.
+type Summary struct {
+	Mean, Stddev float64
+	MCSE         float64 // Monte Carlo standard error of the mean
+	Q5, Median   float64
+	Q95          float64
+	RHat         float64 // rank-normalized split R-hat
+	BulkESS      float64
+	TailESS      float64
+}
+
+// Summarize summarizes the draws of a parameter.
+func Summarize(chains [][]float64) Summary {
+	x := pool(chains)
+	mean, vari := meanVar(x)
+	return Summary{
+		Mean:    mean,
+		Stddev:  math.Sqrt(vari),
+		MCSE:    MCSE(chains),
+		Q5:      Quantile(x, 0.05),
+		Median:  Quantile(x, 0.5),
+		Q95:     Quantile(x, 0.95),
+		RHat:    RHat(chains),
+		BulkESS: BulkESS(chains),
+		TailESS: TailESS(chains),
+	}
+}
+
+// SummarizeAll summarizes the draws of all parameters,
+// draws[chain][draw][parameter].
+func SummarizeAll(draws [][][]float64) []Summary {
+	if len(draws) == 0 || len(draws[0]) == 0 {
+		return nil
+	}
+	summaries := make([]Summary, len(draws[0][0]))
+	for i := range summaries {
+		summaries[i] = Summarize(Parameter(draws, i))
+	}
+	return summaries
+}
+
+// R-hat
+
+// RHat returns the rank-normalized split R-hat, the maximum of
+// the split R-hat of the rank-normalized draws and of the
+// rank-normalized draws folded around the median.
+func RHat(chains [][]float64) float64 {
+	bulk := SplitRHat(rankNormalize(chains))
+	median := Quantile(pool(chains), 0.5)
+	folded := make([][]float64, len(chains))
+	for i, chain := range chains {
+		folded[i] = make([]float64, len(chain))
+		for j, x := range chain {
+			folded[i][j] = math.Abs(x - median)
+		}
+	}
+	tail := SplitRHat(rankNormalize(folded))
+	return math.Max(bulk, tail)
+}
+
+// SplitRHat returns the potential scale reduction factor of
+// the chains split in halves.
+func SplitRHat(chains [][]float64) float64 {
+	return rhat(split(chains))
+}
+
+// rhat returns the potential scale reduction factor.
+func rhat(chains [][]float64) float64 {
+	m := float64(len(chains))
+	n := float64(len(chains[0]))
+	means := make([]float64, len(chains))
+	w := 0.
+	for i, chain := range chains {
+		mean, vari := meanVar(chain)
+		means[i] = mean
+		w += vari / m
+	}
+	_, b := meanVar(means)
+	// B/n is the variance of the means.
+	varPlus := (n-1)/n*w + b
+	return math.Sqrt(varPlus / w)
+}
+
+// Effective sample size
+
+// BulkESS returns the effective sample size of the
+// rank-normalized split chains.
+func BulkESS(chains [][]float64) float64 {
+	return ess(split(rankNormalize(chains)))
+}
+
+// TailESS returns the minimum of the effective sample sizes of
+// the 5% and 95% quantiles.
+func TailESS(chains [][]float64) float64 {
+	x := pool(chains)
+	q5, q95 := Quantile(x, 0.05), Quantile(x, 0.95)
+	return math.Min(
+		ess(split(indicator(chains, q5))),
+		ess(split(indicator(chains, q95))))
+}
+
+// ESS returns the effective sample size of the split chains,
+// for estimating the mean.
+func ESS(chains [][]float64) float64 {
+	return ess(split(chains))
+}
+
+// MCSE returns the Monte Carlo standard error of the mean.
+func MCSE(chains [][]float64) float64 {
+	_, vari := meanVar(pool(chains))
+	return math.Sqrt(vari / ESS(chains))
+}
+
+// ess estimates the effective sample size, truncating the
+// autocorrelations by Geyer's initial monotone sequence.
+func ess(chains [][]float64) float64 {
+	m := float64(len(chains))
+	n := len(chains[0])
+	if n < 4 {
+		return math.NaN()
+	}
+	acov := make([][]float64, len(chains))
+	means := make([]float64, len(chains))
+	meanVari := 0.
+	for i, chain := range chains {
+		acov[i] = autocovariance(chain)
+		means[i], _ = meanVar(chain)
+		meanVari += acov[i][0] * float64(n) / float64(n-1) / m
+	}
+	varPlus := meanVari * float64(n-1) / float64(n)
+	if len(chains) > 1 {
+		_, b := meanVar(means)
+		varPlus += b
+	}
+	// rho returns the combined autocorrelation at lag t.
+	rho := func(t int) float64 {
+		meanAcov := 0.
+		for i := range acov {
+			meanAcov += acov[i][t] / m
+		}
+		return 1 - (meanVari-meanAcov)/varPlus
+	}
+
+	// Geyer's initial positive sequence
+	rhos := make([]float64, n)
+	rhoEven, rhoOdd := 1., rho(1)
+	rhos[0], rhos[1] = rhoEven, rhoOdd
+	t := 1
+	for t < n-3 && rhoEven+rhoOdd > 0 {
+		rhoEven, rhoOdd = rho(t+1), rho(t+2)
+		if rhoEven+rhoOdd >= 0 {
+			rhos[t+1], rhos[t+2] = rhoEven, rhoOdd
+		}
+		t += 2
+	}
+	maxt := t - 2
+	if rhoEven > 0 {
+		rhos[maxt+1] = rhoEven
+	}
+
+	// Geyer's initial monotone sequence
+	for t := 1; t <= maxt-2; t += 2 {
+		if rhos[t+1]+rhos[t+2] > rhos[t-1]+rhos[t] {
+			rhos[t+1] = (rhos[t-1] + rhos[t]) / 2
+			rhos[t+2] = rhos[t+1]
+		}
+	}
+
+	size := m * float64(n)
+	tau := -1.
+	for t := 0; t <= maxt; t++ {
+		tau += 2 * rhos[t]
+	}
+	tau += rhos[maxt+1]
+	tau = math.Max(tau, 1/math.Log10(size))
+	return size / tau
+}
+
+// Autocorrelation
+
+// Autocorrelation returns the autocorrelation of x at lags 0
+// to maxLag.
+func Autocorrelation(x []float64, maxLag int) []float64 {
+	acov := autocovariance(x)
+	if maxLag >= len(x) {
+		maxLag = len(x) - 1
+	}
+	acor := make([]float64, maxLag+1)
+	for t := range acor {
+		acor[t] = acov[t] / acov[0]
+	}
+	return acor
+}
+
+// autocovariance returns the biased estimate of the
+// autocovariance of x at all lags, computed through the fast
+// Fourier transform.
+func autocovariance(x []float64) []float64 {
+	n := len(x)
+	size := 1
+	for size < 2*n {
+		size *= 2
+	}
+	mean, _ := meanVar(x)
+	z := make([]complex128, size)
+	for i := range x {
+		z[i] = complex(x[i]-mean, 0)
+	}
+	fft(z, false)
+	for i := range z {
+		z[i] = complex(real(z[i])*real(z[i])+imag(z[i])*imag(z[i]), 0)
+	}
+	fft(z, true)
+	acov := make([]float64, n)
+	for t := range acov {
+		acov[t] = real(z[t]) / float64(size) / float64(n)
+	}
+	return acov
+}
+
+// Quantiles
+
+// Quantile returns the p-quantile of x, interpolating between
+// the order statistics.
+func Quantile(x []float64, p float64) float64 {
+	sorted := make([]float64, len(x))
+	copy(sorted, x)
+	sort.Float64s(sorted)
+	h := p * float64(len(sorted)-1)
+	i := int(math.Floor(h))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// Transformations of draws
+
+// split splits each chain in halves, dropping the middle draw
+// of odd-length chains.
+func split(chains [][]float64) [][]float64 {
+	halves := make([][]float64, 0, 2*len(chains))
+	for _, chain := range chains {
+		n := len(chain) / 2
+		halves = append(halves, chain[:n], chain[len(chain)-n:])
+	}
+	return halves
+}
+
+// rankNormalize replaces draws by the normal scores of their
+// ranks in the pooled draws; ties get the average rank.
+func rankNormalize(chains [][]float64) [][]float64 {
+	type draw struct {
+		x    float64
+		i, j int
+	}
+	var draws []draw
+	for i, chain := range chains {
+		for j, x := range chain {
+			draws = append(draws, draw{x, i, j})
+		}
+	}
+	sort.Slice(draws, func(a, b int) bool {
+		return draws[a].x < draws[b].x
+	})
+	z := make([][]float64, len(chains))
+	for i, chain := range chains {
+		z[i] = make([]float64, len(chain))
+	}
+	s := float64(len(draws))
+	for a := 0; a != len(draws); {
+		b := a + 1
+		for b != len(draws) && draws[b].x == draws[a].x {
+			b++
+		}
+		// Ranks start at 1, ties get the average rank.
+		rank := float64(a+b+1) / 2
+		score := math.Sqrt2 * math.Erfinv(2*(rank-0.375)/(s+0.25)-1)
+		for ; a != b; a++ {
+			z[draws[a].i][draws[a].j] = score
+		}
+	}
+	return z
+}
+
+// indicator returns the indicators of draws not greater than q.
+func indicator(chains [][]float64, q float64) [][]float64 {
+	ind := make([][]float64, len(chains))
+	for i, chain := range chains {
+		ind[i] = make([]float64, len(chain))
+		for j, x := range chain {
+			if x <= q {
+				ind[i][j] = 1
+			}
+		}
+	}
+	return ind
+}
+
+// pool concatenates the chains.
+func pool(chains [][]float64) []float64 {
+	var x []float64
+	for _, chain := range chains {
+		x = append(x, chain...)
+	}
+	return x
+}
+
+// meanVar returns the mean and the unbiased variance of x.
+func meanVar(x []float64) (mean, vari float64) {
+	n := float64(len(x))
+	for _, xi := range x {
+		mean += xi
+	}
+	mean /= n
+	for _, xi := range x {
+		vari += (xi - mean) * (xi - mean)
+	}
+	vari /= n - 1
+	return mean, vari
+}
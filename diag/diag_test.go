@@ -0,0 +1,226 @@
+package diag
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// normalChains returns m chains of n independent standard
+// normal draws, shifted by shift[i].
+func normalChains(rng *rand.Rand, m, n int, shift []float64) [][]float64 {
+	chains := make([][]float64, m)
+	for i := range chains {
+		chains[i] = make([]float64, n)
+		for j := range chains[i] {
+			chains[i][j] = rng.NormFloat64()
+			if shift != nil {
+				chains[i][j] += shift[i]
+			}
+		}
+	}
+	return chains
+}
+
+// ar1Chains returns m chains of n draws of the AR(1) process
+// with coefficient phi and unit marginal variance.
+func ar1Chains(rng *rand.Rand, m, n int, phi float64) [][]float64 {
+	chains := make([][]float64, m)
+	sigma := math.Sqrt(1 - phi*phi)
+	for i := range chains {
+		chains[i] = make([]float64, n)
+		x := rng.NormFloat64()
+		for j := range chains[i] {
+			x = phi*x + sigma*rng.NormFloat64()
+			chains[i][j] = x
+		}
+	}
+	return chains
+}
+
+func TestFFT(t *testing.T) {
+	x := []float64{1, 2, 0, -1, 3, 0.5, -2, 1}
+	z := make([]complex128, len(x))
+	for i := range x {
+		z[i] = complex(x[i], 0)
+	}
+	fft(z, false)
+	for k := range z {
+		var want complex128
+		for j := range x {
+			a := -2 * math.Pi * float64(j*k) / float64(len(x))
+			want += complex(x[j]*math.Cos(a), x[j]*math.Sin(a))
+		}
+		if math.Abs(real(z[k]-want)) > 1e-9 ||
+			math.Abs(imag(z[k]-want)) > 1e-9 {
+			t.Errorf("wrong transform at %d: got %v, want %v",
+				k, z[k], want)
+		}
+	}
+	fft(z, true)
+	for i := range x {
+		if math.Abs(real(z[i])/float64(len(x))-x[i]) > 1e-9 {
+			t.Errorf("wrong inverse transform at %d: got %v, want %v",
+				i, z[i], x[i])
+		}
+	}
+}
+
+func TestAutocovariance(t *testing.T) {
+	x := []float64{1, 3, 2, 5, 4, 4, 0, 1, 2}
+	acov := autocovariance(x)
+	mean, _ := meanVar(x)
+	for lag := range x {
+		want := 0.
+		for i := 0; i+lag < len(x); i++ {
+			want += (x[i] - mean) * (x[i+lag] - mean)
+		}
+		want /= float64(len(x))
+		if math.Abs(acov[lag]-want) > 1e-9 {
+			t.Errorf("wrong autocovariance at lag %d: got %.6g, want %.6g",
+				lag, acov[lag], want)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	acor := Autocorrelation(ar1Chains(rng, 1, 10000, 0.9)[0], 2)
+	for lag, want := range []float64{1, 0.9, 0.81} {
+		if math.Abs(acor[lag]-want) > 0.05 {
+			t.Errorf("wrong AR(1) autocorrelation at lag %d: "+
+				"got %.4g, want %.4g", lag, acor[lag], want)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	x := []float64{5, 1, 4, 2, 3}
+	for _, c := range []struct {
+		p, q float64
+	}{
+		{0, 1},
+		{0.25, 2},
+		{0.5, 3},
+		{0.6, 3.4},
+		{1, 5},
+	} {
+		if q := Quantile(x, c.p); math.Abs(q-c.q) > 1e-12 {
+			t.Errorf("wrong %.2g-quantile: got %.4g, want %.4g",
+				c.p, q, c.q)
+		}
+	}
+}
+
+func TestRHat(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	mixed := normalChains(rng, 4, 1000, nil)
+	if rhat := RHat(mixed); rhat > 1.01 {
+		t.Errorf("R-hat of mixed chains: got %.4g, want < 1.01", rhat)
+	}
+	stuck := normalChains(rng, 4, 1000, []float64{0, 0, 0, 1})
+	if rhat := RHat(stuck); rhat < 1.05 {
+		t.Errorf("R-hat of stuck chains: got %.4g, want > 1.05", rhat)
+	}
+	// The second half of each chain drifts, only split R-hat
+	// can tell.
+	drifting := normalChains(rng, 4, 1000, nil)
+	for _, chain := range drifting {
+		for j := len(chain) / 2; j != len(chain); j++ {
+			chain[j] += 2
+		}
+	}
+	if rhat := SplitRHat(drifting); rhat < 1.2 {
+		t.Errorf("split R-hat of drifting chains: got %.4g, want > 1.2",
+			rhat)
+	}
+	// Chains differing in scale only are detected on the
+	// folded draws.
+	scaled := normalChains(rng, 4, 1000, nil)
+	for j := range scaled[3] {
+		scaled[3][j] *= 3
+	}
+	if rhat := RHat(scaled); rhat < 1.05 {
+		t.Errorf("R-hat of scaled chains: got %.4g, want > 1.05", rhat)
+	}
+}
+
+func TestESS(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, c := range []struct {
+		name   string
+		chains [][]float64
+		ess    float64
+		// Indicators of tail quantiles are less autocorrelated
+		// than the draws, and tail ESS is higher.
+		maxTailESS float64
+	}{
+		{"independent", normalChains(rng, 4, 1000, nil), 4000, 5000},
+		// ESS of AR(1) is n(1 - phi)/(1 + phi).
+		{"AR(1)", ar1Chains(rng, 4, 5000, 0.9), 20000 * 0.1 / 1.9, 4000},
+	} {
+		for _, e := range []struct {
+			name string
+			ess  func([][]float64) float64
+		}{
+			{"ESS", ESS},
+			{"BulkESS", BulkESS},
+		} {
+			if ess := e.ess(c.chains); math.Abs(math.Log(ess/c.ess)) > 0.25 {
+				t.Errorf("wrong %s of %s chains: got %.4g, want %.4g",
+					e.name, c.name, ess, c.ess)
+			}
+		}
+		if ess := TailESS(c.chains); ess < 0.75*c.ess || ess > c.maxTailESS {
+			t.Errorf("wrong TailESS of %s chains: got %.4g, want %.4g to %.4g",
+				c.name, ess, c.ess, c.maxTailESS)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	chains := normalChains(rng, 4, 1000, []float64{1, 1, 1, 1})
+	s := Summarize(chains)
+	for _, c := range []struct {
+		name      string
+		got, want float64
+		prec      float64
+	}{
+		{"Mean", s.Mean, 1, 0.05},
+		{"Stddev", s.Stddev, 1, 0.05},
+		{"MCSE", s.MCSE, 1 / math.Sqrt(4000), 0.005},
+		{"Q5", s.Q5, 1 - 1.645, 0.1},
+		{"Median", s.Median, 1, 0.1},
+		{"Q95", s.Q95, 1 + 1.645, 0.1},
+		{"RHat", s.RHat, 1, 0.01},
+	} {
+		if math.Abs(c.got-c.want) > c.prec {
+			t.Errorf("wrong %s: got %.4g, want %.4g", c.name, c.got, c.want)
+		}
+	}
+
+	// Draws of multiple parameters are summarized per
+	// parameter.
+	draws := make([][][]float64, len(chains))
+	for i, chain := range chains {
+		samples := make(chan []float64)
+		go func() {
+			for _, x := range chain {
+				samples <- []float64{x, -x}
+			}
+			close(samples)
+		}()
+		draws[i] = Collect(samples, 2*len(chain))
+		if len(draws[i]) != len(chain) {
+			t.Fatalf("wrong number of draws: got %d, want %d",
+				len(draws[i]), len(chain))
+		}
+	}
+	summaries := SummarizeAll(draws)
+	if len(summaries) != 2 {
+		t.Fatalf("wrong number of summaries: got %d, want 2",
+			len(summaries))
+	}
+	if summaries[0] != s || summaries[1].Mean != -s.Mean {
+		t.Errorf("wrong summaries: got %+v, want %+v and its negation",
+			summaries, s)
+	}
+}
@@ -0,0 +1,44 @@
+package diag
+
+// Fast Fourier transform, for computing autocovariance.
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft computes in place the discrete Fourier transform of z,
+// or the unnormalized inverse transform if inverse is true.
+// The length of z must be a power of 2.
+func fft(z []complex128, inverse bool) {
+	n := len(z)
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			z[i], z[j] = z[j], z[i]
+		}
+	}
+	// Butterflies
+	sign := -1.
+	if inverse {
+		sign = 1
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, sign*2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k != size/2; k++ {
+				u := z[start+k]
+				v := z[start+k+size/2] * wk
+				z[start+k] = u + v
+				z[start+k+size/2] = u - v
+				wk *= w
+			}
+		}
+	}
+}
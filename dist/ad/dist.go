@@ -8,11 +8,11 @@ import (
 )
 
 var (
-	logpi, log2pi float64
+	log2, logpi, log2pi float64
 )
 
 func init() {
-	log2 := math.Log(2)
+	log2 = math.Log(2)
 	logpi = math.Log(math.Pi)
 	log2pi = log2 + logpi
 }
@@ -21,137 +21,1877 @@ type normal struct{}
 
 var Normal normal
 
+func (dist normal) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		mu float64
+
+		sigma float64
+
+		y []float64
+	)
+
+	mu, sigma, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &sigma, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &mu, &sigma))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
 func (dist normal) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (normal) LogpTape(_tape *ad.Tape, mu, sigma float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &sigma, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var vari float64
+	_tape.Assignment(&vari, _tape.Arithmetic(ad.OpMul, &sigma, &sigma))
+	var logv float64
+	_tape.Assignment(&logv, _tape.Elemental(math.Log, &vari))
+	var d float64
+	_tape.Assignment(&d, _tape.Arithmetic(ad.OpSub, &y, &mu))
+	return _tape.Return(_tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, &d, &d), &vari), &logv), &log2pi))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv normal) Logp(mu, sigma float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), mu, sigma, y)
+}
+
+func (normal) LogpsTape(_tape *ad.Tape, mu, sigma float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &sigma)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var vari float64
+	_tape.Assignment(&vari, _tape.Arithmetic(ad.OpMul, &sigma, &sigma))
+	var logv float64
+	_tape.Assignment(&logv, _tape.Elemental(math.Log, &vari))
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, &logv, &log2pi))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var d float64
+		_tape.Assignment(&d, _tape.Arithmetic(ad.OpSub, &y[i], &mu))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &d), &d), &vari)))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv normal) Logps(mu, sigma float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), mu, sigma, y...)
+}
+
+type cauchy struct{}
+
+var Cauchy cauchy
+
+func (dist cauchy) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		mu float64
+
+		sigma float64
+
+		y []float64
+	)
+
+	mu, sigma, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &sigma, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &mu, &sigma))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist cauchy) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (cauchy) LogpTape(_tape *ad.Tape, x0, gamma float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&x0, &gamma, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var logGamma float64
+	_tape.Assignment(&logGamma, _tape.Elemental(math.Log, &gamma))
+	var d float64
+	_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, (_tape.Arithmetic(ad.OpSub, &y, &x0)), &gamma))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpNeg, &logGamma), &logpi), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpMul, &d, &d)))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv cauchy) Logp(x0, gamma float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), x0, gamma, y)
+}
+
+func (cauchy) LogpsTape(_tape *ad.Tape, x0, gamma float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&x0, &gamma)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var logGamma float64
+	_tape.Assignment(&logGamma, _tape.Elemental(math.Log, &gamma))
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpNeg, &logGamma), &logpi)), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var d float64
+		_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, (_tape.Arithmetic(ad.OpSub, &y[i], &x0)), &gamma))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpMul, &d, &d)))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv cauchy) Logps(x0, gamma float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), x0, gamma, y...)
+}
+
+type studentT struct{}
+
+var StudentT studentT
+
+func (dist studentT) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		nu float64
+
+		mu float64
+
+		sigma float64
+
+		y []float64
+	)
+
+	nu, mu, sigma, y = x[0], x[1], x[2], x[3:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0, 0)
+		}, 4, &nu, &mu, &sigma, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, 0, y...)
+		}, 3, &nu, &mu, &sigma))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist studentT) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (studentT) LogpTape(_tape *ad.Tape, nu, mu, sigma float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu, &mu, &sigma, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var d float64
+	_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, (_tape.Arithmetic(ad.OpSub, &y, &mu)), &sigma))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1))))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu))), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Elemental(math.Log, &nu), &logpi)))), _tape.Elemental(math.Log, &sigma)), _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1)))), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, &d, &d), &nu))))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv studentT) Logp(nu, mu, sigma float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), nu, mu, sigma, y)
+}
+
+func (studentT) LogpsTape(_tape *ad.Tape, nu, mu, sigma float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu, &mu, &sigma)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1))))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu))), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Elemental(math.Log, &nu), &logpi)))), _tape.Elemental(math.Log, &sigma))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var d float64
+		_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, (_tape.Arithmetic(ad.OpSub, &y[i], &mu)), &sigma))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1)))), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, &d, &d), &nu))))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv studentT) Logps(nu, mu, sigma float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), nu, mu, sigma, y...)
+}
+
+type laplace struct{}
+
+var Laplace laplace
+
+func (dist laplace) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		mu float64
+
+		b float64
+
+		y []float64
+	)
+
+	mu, b, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &b, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &mu, &b))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist laplace) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (laplace) LogpTape(_tape *ad.Tape, mu, b float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &b, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpMul, _tape.Value(2), &b))), _tape.Arithmetic(ad.OpDiv, _tape.Elemental(math.Abs, _tape.Arithmetic(ad.OpSub, &y, &mu)), &b)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv laplace) Logp(mu, b float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), mu, b, y)
+}
+
+func (laplace) LogpsTape(_tape *ad.Tape, mu, b float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &b)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpMul, _tape.Value(2), &b))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpDiv, _tape.Elemental(math.Abs, _tape.Arithmetic(ad.OpSub, &y[i], &mu)), &b)))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv laplace) Logps(mu, b float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), mu, b, y...)
+}
+
+type logistic struct{}
+
+var Logistic logistic
+
+func (dist logistic) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		mu float64
+
+		s float64
+
+		y []float64
+	)
+
+	mu, s, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &s, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &mu, &s))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist logistic) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (logistic) LogpTape(_tape *ad.Tape, mu, s float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &s, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var z float64
+	_tape.Assignment(&z, _tape.Arithmetic(ad.OpDiv, _tape.Elemental(math.Abs, _tape.Arithmetic(ad.OpSub, &y, &mu)), &s))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Elemental(mathx.LogDSigm, _tape.Arithmetic(ad.OpNeg, &z)), _tape.Elemental(math.Log, &s)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv logistic) Logp(mu, s float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), mu, s, y)
+}
+
+func (logistic) LogpsTape(_tape *ad.Tape, mu, s float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &s)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(math.Log, &s)), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var z float64
+		_tape.Assignment(&z, _tape.Arithmetic(ad.OpDiv, _tape.Elemental(math.Abs, _tape.Arithmetic(ad.OpSub, &y[i], &mu)), &s))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Elemental(mathx.LogDSigm, _tape.Arithmetic(ad.OpNeg, &z))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv logistic) Logps(mu, s float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), mu, s, y...)
+}
+
+type gumbel struct{}
+
+var Gumbel gumbel
+
+func (dist gumbel) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		mu float64
+
+		beta float64
+
+		y []float64
+	)
+
+	mu, beta, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &beta, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &mu, &beta))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist gumbel) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (gumbel) LogpTape(_tape *ad.Tape, mu, beta float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &beta, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var z float64
+	_tape.Assignment(&z, _tape.Arithmetic(ad.OpDiv, (_tape.Arithmetic(ad.OpSub, &y, &mu)), &beta))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(math.Log, &beta)), &z), _tape.Elemental(math.Exp, _tape.Arithmetic(ad.OpNeg, &z))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv gumbel) Logp(mu, beta float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), mu, beta, y)
+}
+
+func (gumbel) LogpsTape(_tape *ad.Tape, mu, beta float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &beta)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(math.Log, &beta)), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var z float64
+		_tape.Assignment(&z, _tape.Arithmetic(ad.OpDiv, (_tape.Arithmetic(ad.OpSub, &y[i], &mu)), &beta))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpAdd, &z, _tape.Elemental(math.Exp, _tape.Arithmetic(ad.OpNeg, &z)))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv gumbel) Logps(mu, beta float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), mu, beta, y...)
+}
+
+type exponential struct{}
+
+var Exponential, Expon exponential
+
+func (dist exponential) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		lambda float64
+
+		y []float64
+	)
+
+	lambda, y = x[0], x[1:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0)
+		}, 2, &lambda, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, y...)
+		}, 1, &lambda))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist exponential) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (exponential) LogpTape(_tape *ad.Tape, lambda float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&lambda, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var logl float64
+	_tape.Assignment(&logl, _tape.Elemental(math.Log, &lambda))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, &logl, _tape.Arithmetic(ad.OpMul, &lambda, &y)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv exponential) Logp(lambda float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), lambda, y)
+}
+
+func (exponential) LogpsTape(_tape *ad.Tape, lambda float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&lambda)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var logl float64
+	_tape.Assignment(&logl, _tape.Elemental(math.Log, &lambda))
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, &logl, _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpMul, &lambda, &y[i])))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv exponential) Logps(lambda float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), lambda, y...)
+}
+
+type gamma struct{}
+
+var Gamma gamma
+
+func (dist gamma) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		alpha float64
+
+		beta float64
+
+		y []float64
+	)
+
+	alpha, beta, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &alpha, &beta, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &alpha, &beta))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist gamma) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (gamma) LogpTape(_tape *ad.Tape, alpha, beta float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&alpha, &beta, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &alpha, _tape.Value(1))), _tape.Elemental(math.Log, &y)), _tape.Arithmetic(ad.OpMul, &beta, &y)), _tape.Elemental(mathx.LogGamma, &alpha)), _tape.Arithmetic(ad.OpMul, &alpha, _tape.Elemental(math.Log, &beta))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv gamma) Logp(alpha, beta float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), alpha, beta, y)
+}
+
+func (gamma) LogpsTape(_tape *ad.Tape, alpha, beta float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&alpha, &beta)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(mathx.LogGamma, &alpha)), _tape.Arithmetic(ad.OpMul, &alpha, _tape.Elemental(math.Log, &beta)))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &alpha, _tape.Value(1))), _tape.Elemental(math.Log, &y[i])), _tape.Arithmetic(ad.OpMul, &beta, &y[i]))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv gamma) Logps(alpha, beta float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), alpha, beta, y...)
+}
+
+type logNormal struct{}
+
+var LogNormal logNormal
+
+func (dist logNormal) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		mu float64
+
+		sigma float64
+
+		y []float64
+	)
+
+	mu, sigma, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &sigma, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &mu, &sigma))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist logNormal) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (logNormal) LogpTape(_tape *ad.Tape, mu, sigma float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &sigma, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var vari float64
+	_tape.Assignment(&vari, _tape.Arithmetic(ad.OpMul, &sigma, &sigma))
+	var logv float64
+	_tape.Assignment(&logv, _tape.Elemental(math.Log, &vari))
+	var logy float64
+	_tape.Assignment(&logy, _tape.Elemental(math.Log, &y))
+	var d float64
+	_tape.Assignment(&d, _tape.Arithmetic(ad.OpSub, &logy, &mu))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, &d, &d), &vari), &logv), &log2pi))), &logy))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv logNormal) Logp(mu, sigma float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), mu, sigma, y)
+}
+
+func (logNormal) LogpsTape(_tape *ad.Tape, mu, sigma float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &sigma)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var vari float64
+	_tape.Assignment(&vari, _tape.Arithmetic(ad.OpMul, &sigma, &sigma))
+	var logv float64
+	_tape.Assignment(&logv, _tape.Elemental(math.Log, &vari))
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, &logv, &log2pi))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var logy float64
+		_tape.Assignment(&logy, _tape.Elemental(math.Log, &y[i]))
+		var d float64
+		_tape.Assignment(&d, _tape.Arithmetic(ad.OpSub, &logy, &mu))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &d), &d), &vari), &logy)))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv logNormal) Logps(mu, sigma float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), mu, sigma, y...)
+}
+
+type inverseGamma struct{}
+
+var InverseGamma inverseGamma
+
+func (dist inverseGamma) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		alpha float64
+
+		beta float64
+
+		y []float64
+	)
+
+	alpha, beta, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &alpha, &beta, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &alpha, &beta))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist inverseGamma) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (inverseGamma) LogpTape(_tape *ad.Tape, alpha, beta float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&alpha, &beta, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, (_tape.Arithmetic(ad.OpAdd, &alpha, _tape.Value(1)))), _tape.Elemental(math.Log, &y)), _tape.Arithmetic(ad.OpDiv, &beta, &y)), _tape.Elemental(mathx.LogGamma, &alpha)), _tape.Arithmetic(ad.OpMul, &alpha, _tape.Elemental(math.Log, &beta))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv inverseGamma) Logp(alpha, beta float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), alpha, beta, y)
+}
+
+func (inverseGamma) LogpsTape(_tape *ad.Tape, alpha, beta float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&alpha, &beta)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(mathx.LogGamma, &alpha)), _tape.Arithmetic(ad.OpMul, &alpha, _tape.Elemental(math.Log, &beta)))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, &alpha, _tape.Value(1))), _tape.Elemental(math.Log, &y[i])), _tape.Arithmetic(ad.OpDiv, &beta, &y[i]))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv inverseGamma) Logps(alpha, beta float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), alpha, beta, y...)
+}
+
+type weibull struct{}
+
+var Weibull weibull
+
+func (dist weibull) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		k float64
+
+		lambda float64
+
+		y []float64
+	)
+
+	k, lambda, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &k, &lambda, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &k, &lambda))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist weibull) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (weibull) LogpTape(_tape *ad.Tape, k, lambda float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&k, &lambda, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var z float64
+	_tape.Assignment(&z, _tape.Arithmetic(ad.OpDiv, &y, &lambda))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &k), _tape.Elemental(math.Log, &lambda)), _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &k, _tape.Value(1))), _tape.Elemental(math.Log, &z))), _tape.Elemental(math.Pow, &z, &k)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv weibull) Logp(k, lambda float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), k, lambda, y)
+}
+
+func (weibull) LogpsTape(_tape *ad.Tape, k, lambda float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&k, &lambda)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &k), _tape.Elemental(math.Log, &lambda))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var z float64
+		_tape.Assignment(&z, _tape.Arithmetic(ad.OpDiv, &y[i], &lambda))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &k, _tape.Value(1))), _tape.Elemental(math.Log, &z)), _tape.Elemental(math.Pow, &z, &k))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv weibull) Logps(k, lambda float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), k, lambda, y...)
+}
+
+type halfNormal struct{}
+
+var HalfNormal halfNormal
+
+func (dist halfNormal) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		sigma float64
+
+		y []float64
+	)
+
+	sigma, y = x[0], x[1:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0)
+		}, 2, &sigma, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, y...)
+		}, 1, &sigma))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist halfNormal) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (halfNormal) LogpTape(_tape *ad.Tape, sigma float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&sigma, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var vari float64
+	_tape.Assignment(&vari, _tape.Arithmetic(ad.OpMul, &sigma, &sigma))
+	var logv float64
+	_tape.Assignment(&logv, _tape.Elemental(math.Log, &vari))
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, &y, &y), &vari), &logv), &log2pi))), &log2))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv halfNormal) Logp(sigma float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), sigma, y)
+}
+
+func (halfNormal) LogpsTape(_tape *ad.Tape, sigma float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&sigma)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var vari float64
+	_tape.Assignment(&vari, _tape.Arithmetic(ad.OpMul, &sigma, &sigma))
+	var logv float64
+	_tape.Assignment(&logv, _tape.Elemental(math.Log, &vari))
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, &logv, &log2pi))), &log2)), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &y[i]), &y[i]), &vari)))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv halfNormal) Logps(sigma float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), sigma, y...)
+}
+
+type halfCauchy struct{}
+
+var HalfCauchy halfCauchy
+
+func (dist halfCauchy) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		gamma float64
+
+		y []float64
+	)
+
+	gamma, y = x[0], x[1:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0)
+		}, 2, &gamma, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, y...)
+		}, 1, &gamma))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist halfCauchy) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (halfCauchy) LogpTape(_tape *ad.Tape, gamma float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&gamma, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var logGamma float64
+	_tape.Assignment(&logGamma, _tape.Elemental(math.Log, &gamma))
+	var d float64
+	_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, &y, &gamma))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpNeg, &logGamma), &logpi), &log2), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpMul, &d, &d)))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv halfCauchy) Logp(gamma float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), gamma, y)
+}
+
+func (halfCauchy) LogpsTape(_tape *ad.Tape, gamma float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&gamma)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var logGamma float64
+	_tape.Assignment(&logGamma, _tape.Elemental(math.Log, &gamma))
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpNeg, &logGamma), &logpi), &log2)), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var d float64
+		_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, &y[i], &gamma))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpMul, &d, &d)))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv halfCauchy) Logps(gamma float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), gamma, y...)
+}
+
+type halfStudentT struct{}
+
+var HalfStudentT halfStudentT
+
+func (dist halfStudentT) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		nu float64
+
+		sigma float64
+
+		y []float64
+	)
+
+	nu, sigma, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &nu, &sigma, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &nu, &sigma))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist halfStudentT) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (halfStudentT) LogpTape(_tape *ad.Tape, nu, sigma float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu, &sigma, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var d float64
+	_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, &y, &sigma))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1))))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu))), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Elemental(math.Log, &nu), &logpi)))), _tape.Elemental(math.Log, &sigma)), &log2), _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1)))), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, &d, &d), &nu))))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv halfStudentT) Logp(nu, sigma float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), nu, sigma, y)
+}
+
+func (halfStudentT) LogpsTape(_tape *ad.Tape, nu, sigma float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu, &sigma)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1))))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu))), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Elemental(math.Log, &nu), &logpi)))), _tape.Elemental(math.Log, &sigma)), &log2)), _tape.Value(float64(len(y)))))
+	for i := range y {
+		var d float64
+		_tape.Assignment(&d, _tape.Arithmetic(ad.OpDiv, &y[i], &sigma))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpAdd, &nu, _tape.Value(1)))), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, _tape.Value(1), _tape.Arithmetic(ad.OpDiv, _tape.Arithmetic(ad.OpMul, &d, &d), &nu))))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv halfStudentT) Logps(nu, sigma float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), nu, sigma, y...)
+}
+
+type beta struct{}
+
+var Beta beta
+
+func (dist beta) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		alpha float64
+
+		beta float64
+
+		y []float64
+	)
+
+	alpha, beta, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &alpha, &beta, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &alpha, &beta))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist beta) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (beta) LogpTape(_tape *ad.Tape, alpha, beta float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&alpha, &beta, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &alpha, _tape.Value(1))), _tape.Elemental(math.Log, &y)), _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &beta, _tape.Value(1))), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &y)))), _tape.Elemental(mathx.LogGamma, &alpha)), _tape.Elemental(mathx.LogGamma, &beta)), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &alpha, &beta))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv beta) Logp(alpha, beta float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), alpha, beta, y)
+}
+
+func (beta) LogpsTape(_tape *ad.Tape, alpha, beta float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&alpha, &beta)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(mathx.LogGamma, &alpha)), _tape.Elemental(mathx.LogGamma, &beta)), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &alpha, &beta)))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &alpha, _tape.Value(1))), _tape.Elemental(math.Log, &y[i])), _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &beta, _tape.Value(1))), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &y[i]))))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv beta) Logps(alpha, beta float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), alpha, beta, y...)
+}
+
+type binomial struct{}
+
+var Binomial binomial
+
+func (dist binomial) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var (
+		n float64
+
+		p float64
+
+		y []float64
+	)
+
+	n, p, y = x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &n, &p, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &n, &p))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist binomial) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (binomial) LogpTape(_tape *ad.Tape, n, p float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&n, &p, &y)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, &y, _tape.Elemental(math.Log, &p)), _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &n, &y)), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &p)))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y, _tape.Value(1)))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, &n, &y), _tape.Value(1)))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &n, _tape.Value(1)))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv binomial) Logp(n, p float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), n, p, y)
+}
+
+func (binomial) LogpsTape(_tape *ad.Tape, n, p float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&n, &p)
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &n, _tape.Value(1))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, &y[i], _tape.Elemental(math.Log, &p)), _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &n, &y[i])), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &p)))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y[i], _tape.Value(1)))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, &n, &y[i]), _tape.Value(1))))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv binomial) Logps(n, p float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), n, p, y...)
+}
+
+type Dirichlet struct {
+	N int
+}
+
+var Dir Dirichlet
+
+func (dist Dirichlet) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var alpha []float64
+
+	alpha = x[:dist.N]
+	if len(x[dist.N:]) == dist.N {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, alpha, x[dist.N:])
+		}, 0))
+	} else {
+		var ys [][]float64
+
+		ys = make([][]float64, len(x[dist.N:])/dist.N)
+		for i := range ys {
+			ys[i] = x[dist.N*(i+1) : dist.N*(i+2)]
+		}
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, alpha, ys...)
+		}, 0))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist Dirichlet) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (dist Dirichlet) LogpTape(_tape *ad.Tape, alpha []float64, y []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var sum float64
+	_tape.Assignment(&sum, _tape.Value(0.))
+	for j := range y {
+		_tape.Assignment(&sum, _tape.Arithmetic(ad.OpAdd, &sum, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &alpha[j], _tape.Value(1))), _tape.Elemental(math.Log, &y[j]))))
+	}
+
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, &sum, _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, alpha)
+	}, 0)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (dist Dirichlet) Logp(alpha []float64, y []float64) float64 {
+	return dist.LogpTape(ad.CurrentTape(), alpha, y)
+}
+
+func (dist Dirichlet) LogpsTape(_tape *ad.Tape, alpha []float64, y ...[]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var LogZ float64
+	_tape.Assignment(&LogZ, _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, alpha)
+	}, 0))
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, &LogZ), _tape.Value(float64(len(y)))))
+	for i := range y {
+		for j := range alpha {
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, &alpha[j], _tape.Value(1))), _tape.Elemental(math.Log, &y[i][j]))))
+		}
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (dist Dirichlet) Logps(alpha []float64, y ...[]float64) float64 {
+	return dist.LogpsTape(ad.CurrentTape(), alpha, y...)
+}
+
+func (dist Dirichlet) LogZTape(_tape *ad.Tape, alpha []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("LogZ called outside Observe")
+	}
+	var sumAlpha float64
+	_tape.Assignment(&sumAlpha, _tape.Value(0.))
+	var sumLogGammaAlpha float64
+	_tape.Assignment(&sumLogGammaAlpha, _tape.Value(0.))
+	for i := range alpha {
+		_tape.Assignment(&sumAlpha, _tape.Arithmetic(ad.OpAdd, &sumAlpha, &alpha[i]))
+		_tape.Assignment(&sumLogGammaAlpha, _tape.Arithmetic(ad.OpAdd, &sumLogGammaAlpha, _tape.Elemental(mathx.LogGamma, &alpha[i])))
+	}
+
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, &sumLogGammaAlpha, _tape.Elemental(mathx.LogGamma, &sumAlpha)))
+}
+
+// LogZ calls LogZTape on the current tape.
+func (dist Dirichlet) LogZ(alpha []float64) float64 {
+	return dist.LogZTape(ad.CurrentTape(), alpha)
+}
+
+type MultivariateNormal struct {
+	N int
+}
+
+var MvNormal MultivariateNormal
+
+func (dist MultivariateNormal) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var mu []float64
+
+	mu = x[:dist.N]
+	var l [][]float64
+
+	l = make([][]float64, dist.N)
+	for i := range l {
+		l[i] = x[dist.N*(i+1) : dist.N*(i+2)]
+	}
+	x = x[dist.N*(dist.N+1):]
+	if len(x) == dist.N {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, mu, l, x)
+		}, 0))
+	} else {
+		var ys [][]float64
+
+		ys = make([][]float64, len(x)/dist.N)
+		for i := range ys {
+			ys[i] = x[dist.N*i : dist.N*(i+1)]
+		}
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, mu, l, ys...)
+		}, 0))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist MultivariateNormal) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (dist MultivariateNormal) LogpTape(_tape *ad.Tape,
+	mu []float64, l [][]float64, y []float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Logp called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Value(float64(len(y))), &log2pi), _tape.Call(func(_ []float64) {
+		dist.MahalanobisTape(_tape, mu, l, y)
+	}, 0)))), _tape.Call(func(_ []float64) {
+		dist.LogDetTape(_tape, l)
+	}, 0)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (dist MultivariateNormal) Logp(mu []float64, l [][]float64, y []float64) float64 {
+	return dist.LogpTape(ad.CurrentTape(), mu, l, y)
+}
+
+func (dist MultivariateNormal) LogpsTape(_tape *ad.Tape,
+	mu []float64, l [][]float64, y ...[]float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Value(float64(len(mu)))), &log2pi), _tape.Call(func(_ []float64) {
+		dist.LogDetTape(_tape, l)
+	}, 0)))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Call(func(_ []float64) {
+			dist.MahalanobisTape(_tape, mu, l, y[i])
+		}, 0))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (dist MultivariateNormal) Logps(mu []float64, l [][]float64, y ...[]float64) float64 {
+	return dist.LogpsTape(ad.CurrentTape(), mu, l, y...)
+}
+
+func (dist MultivariateNormal) MahalanobisTape(_tape *ad.Tape,
+	mu []float64, l [][]float64, y []float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Mahalanobis called outside Observe")
+	}
+	var z []float64
+
+	z = make([]float64, len(y))
+	var d2 float64
+	_tape.Assignment(&d2, _tape.Value(0.))
+	for i := range y {
+		_tape.Assignment(&z[i], _tape.Arithmetic(ad.OpSub, &y[i], &mu[i]))
+		for j := 0; j != i; j = j + 1 {
+			_tape.Assignment(&z[i], _tape.Arithmetic(ad.OpSub, &z[i], _tape.Arithmetic(ad.OpMul, &l[i][j], &z[j])))
+		}
+		_tape.Assignment(&z[i], _tape.Arithmetic(ad.OpDiv, &z[i], &l[i][i]))
+		_tape.Assignment(&d2, _tape.Arithmetic(ad.OpAdd, &d2, _tape.Arithmetic(ad.OpMul, &z[i], &z[i])))
+	}
+	return _tape.Return(&d2)
+}
+
+// Mahalanobis calls MahalanobisTape on the current tape.
+func (dist MultivariateNormal) Mahalanobis(mu []float64, l [][]float64, y []float64) float64 {
+	return dist.MahalanobisTape(ad.CurrentTape(), mu, l, y)
+}
+
+func (dist MultivariateNormal) LogDetTape(_tape *ad.Tape, l [][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("LogDet called outside Observe")
+	}
+	var diag []float64
+
+	diag = make([]float64, len(l))
+	for i := range l {
+		_tape.Assignment(&diag[i], &l[i][i])
+	}
+	return _tape.Return(_tape.Vlemental(mathx.LogDet, diag))
+}
+
+// LogDet calls LogDetTape on the current tape.
+func (dist MultivariateNormal) LogDet(l [][]float64) float64 {
+	return dist.LogDetTape(ad.CurrentTape(), l)
+}
+
+type MultivariateNormalPrec struct {
+	N int
+}
+
+var MvNormalPrec MultivariateNormalPrec
+
+func (dist MultivariateNormalPrec) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var mu []float64
+
+	mu = x[:dist.N]
+	var u [][]float64
+
+	u = make([][]float64, dist.N)
+	for i := range u {
+		u[i] = x[dist.N*(i+1) : dist.N*(i+2)]
+	}
+	x = x[dist.N*(dist.N+1):]
+	if len(x) == dist.N {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, mu, u, x)
+		}, 0))
+	} else {
+		var ys [][]float64
+
+		ys = make([][]float64, len(x)/dist.N)
+		for i := range ys {
+			ys[i] = x[dist.N*i : dist.N*(i+1)]
+		}
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, mu, u, ys...)
+		}, 0))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist MultivariateNormalPrec) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (dist MultivariateNormalPrec) LogpTape(_tape *ad.Tape,
+	mu []float64, u [][]float64, y []float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Logp called outside Observe")
+	}
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Value(float64(len(y))), &log2pi), _tape.Call(func(_ []float64) {
+		dist.MahalanobisTape(_tape, mu, u, y)
+	}, 0)))), _tape.Call(func(_ []float64) {
+		MvNormal.LogDetTape(_tape, u)
+	}, 0)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (dist MultivariateNormalPrec) Logp(mu []float64, u [][]float64, y []float64) float64 {
+	return dist.LogpTape(ad.CurrentTape(), mu, u, y)
+}
+
+func (dist MultivariateNormalPrec) LogpsTape(_tape *ad.Tape,
+	mu []float64, u [][]float64, y ...[]float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Logps called outside Observe")
+	}
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(-0.5), _tape.Value(float64(len(mu)))), &log2pi), _tape.Call(func(_ []float64) {
+		MvNormal.LogDetTape(_tape, u)
+	}, 0))), _tape.Value(float64(len(y)))))
+	for i := range y {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Call(func(_ []float64) {
+			dist.MahalanobisTape(_tape, mu, u, y[i])
+		}, 0))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (dist MultivariateNormalPrec) Logps(mu []float64, u [][]float64, y ...[]float64) float64 {
+	return dist.LogpsTape(ad.CurrentTape(), mu, u, y...)
+}
+
+func (dist MultivariateNormalPrec) MahalanobisTape(_tape *ad.Tape,
+	mu []float64, u [][]float64, y []float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("Mahalanobis called outside Observe")
+	}
+	var d2 float64
+	_tape.Assignment(&d2, _tape.Value(0.))
+	for j := range y {
+		var w float64
+		_tape.Assignment(&w, _tape.Value(0.))
+		for i := j; i != len(y); i = i + 1 {
+			_tape.Assignment(&w, _tape.Arithmetic(ad.OpAdd, &w, _tape.Arithmetic(ad.OpMul, &u[i][j], (_tape.Arithmetic(ad.OpSub, &y[i], &mu[i])))))
+		}
+		_tape.Assignment(&d2, _tape.Arithmetic(ad.OpAdd, &d2, _tape.Arithmetic(ad.OpMul, &w, &w)))
+	}
+	return _tape.Return(&d2)
+}
+
+// Mahalanobis calls MahalanobisTape on the current tape.
+func (dist MultivariateNormalPrec) Mahalanobis(mu []float64, u [][]float64, y []float64) float64 {
+	return dist.MahalanobisTape(ad.CurrentTape(), mu, u, y)
+}
+
+type LKJCholesky struct {
+	N int
+}
+
+var LKJ LKJCholesky
+
+func (dist LKJCholesky) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var eta float64
+	_tape.Assignment(&eta, &x[0])
+	var ls [][][]float64
+
+	ls = make([][][]float64, (len(x)-1)/(dist.N*dist.N))
+	for i := range ls {
+		ls[i] = make([][]float64, dist.N)
+		for j := range ls[i] {
+			var k int
+
+			k = 1 + dist.N*(dist.N*i+j)
+			ls[i][j] = x[k : k+dist.N]
+		}
+	}
+	if len(ls) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, ls[0])
+		}, 1, &eta))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, ls...)
+		}, 1, &eta))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist LKJCholesky) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (dist LKJCholesky) LogpTape(_tape *ad.Tape, eta float64, l [][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&eta)
+	} else {
+		panic("Logp called outside Observe")
+	}
+	var n int
+
+	n = len(l)
+	var lp float64
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpNeg, _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, 0, n)
+	}, 1, &eta)))
+	for i := 1; i != n; i = i + 1 {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Value(float64(n-i-3)), _tape.Arithmetic(ad.OpMul, _tape.Value(2), &eta))), _tape.Elemental(math.Log, &l[i][i]))))
+	}
+	return _tape.Return(&lp)
+}
+
+// Logp calls LogpTape on the current tape.
+func (dist LKJCholesky) Logp(eta float64, l [][]float64) float64 {
+	return dist.LogpTape(ad.CurrentTape(), eta, l)
+}
+
+func (dist LKJCholesky) LogpsTape(_tape *ad.Tape, eta float64, l ...[][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&eta)
 	} else {
-		ad.Setup(x)
+		panic("Logps called outside Observe")
 	}
-	var (
-		mu float64
+	var lp float64
+	_tape.Assignment(&lp, _tape.Value(0.))
+	if len(l) > 0 {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, _tape.Call(func(_ []float64) {
+			dist.LogZTape(_tape, 0, len(l[0]))
+		}, 1, &eta)), _tape.Value(float64(len(l)))))
+	}
+	for k := range l {
+		var n int
 
-		sigma float64
+		n = len(l[k])
+		for i := 1; i != n; i = i + 1 {
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Value(float64(n-i-3)), _tape.Arithmetic(ad.OpMul, _tape.Value(2), &eta))), _tape.Elemental(math.Log, &l[k][i][i]))))
+		}
+	}
+	return _tape.Return(&lp)
+}
 
-		y []float64
-	)
+// Logps calls LogpsTape on the current tape.
+func (dist LKJCholesky) Logps(eta float64, l ...[][]float64) float64 {
+	return dist.LogpsTape(ad.CurrentTape(), eta, l...)
+}
 
-	mu, sigma, y = x[0], x[1], x[2:]
-	if len(y) == 1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(0, 0, 0)
-		}, 3, &mu, &sigma, &y[0]))
+func (dist LKJCholesky) LogZTape(_tape *ad.Tape, eta float64, n int) float64 {
+	if _tape.Called() {
+		_tape.Enter(&eta)
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(0, 0, y...)
-		}, 2, &mu, &sigma))
+		panic("LogZ called outside Observe")
+	}
+	var logz float64
+	_tape.Assignment(&logz, _tape.Value(0.))
+	for k := 1; k != n; k = k + 1 {
+		var m float64
+		_tape.Assignment(&m, _tape.Value(float64(n-k)))
+		var b float64
+		_tape.Assignment(&b, _tape.Arithmetic(ad.OpAdd, &eta, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), (_tape.Arithmetic(ad.OpSub, &m, _tape.Value(1))))))
+		_tape.Assignment(&logz, _tape.Arithmetic(ad.OpAdd, &logz, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, _tape.Value(2), &eta), _tape.Value(2)), &m)), &m), &log2), _tape.Arithmetic(ad.OpMul, &m, (_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, _tape.Value(2), _tape.Elemental(mathx.LogGamma, &b)), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpMul, _tape.Value(2), &b))))))))
+	}
+	return _tape.Return(&logz)
+}
+
+// LogZ calls LogZTape on the current tape.
+func (dist LKJCholesky) LogZ(eta float64, n int) float64 {
+	return dist.LogZTape(ad.CurrentTape(), eta, n)
+}
+
+type Wishart struct {
+	N int
+}
+
+var Wish Wishart
+
+func (dist Wishart) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		_tape.Setup(x)
+	}
+	var nu float64
+	_tape.Assignment(&nu, &x[0])
+	var ms [][][]float64
+
+	ms = make([][][]float64, (len(x)-1)/(dist.N*dist.N))
+	for i := range ms {
+		ms[i] = make([][]float64, dist.N)
+		for j := range ms[i] {
+			var k int
+
+			k = 1 + dist.N*(dist.N*i+j)
+			ms[i][j] = x[k : k+dist.N]
+		}
+	}
+	if len(ms) == 2 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, ms[0], ms[1])
+		}, 1, &nu))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, ms[0], ms[1:]...)
+		}, 1, &nu))
 	}
 }
 
-func (normal) Logp(mu, sigma float64, y float64) float64 {
-	if ad.Called() {
-		ad.Enter(&mu, &sigma, &y)
+// Observe calls ObserveTape on the current tape.
+func (dist Wishart) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (dist Wishart) LogpTape(_tape *ad.Tape, nu float64, l [][]float64, c [][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu)
 	} else {
 		panic("Logp called outside Observe")
 	}
-	var vari float64
-	ad.Assignment(&vari, ad.Arithmetic(ad.OpMul, &sigma, &sigma))
-	var logv float64
-	ad.Assignment(&logv, ad.Elemental(math.Log, &vari))
-	var d float64
-	ad.Assignment(&d, ad.Arithmetic(ad.OpSub, &y, &mu))
-	return ad.Return(ad.Arithmetic(ad.OpMul, ad.Value(-0.5), (ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpDiv, ad.Arithmetic(ad.OpMul, &d, &d), &vari), &logv), &log2pi))))
+	var n float64
+	_tape.Assignment(&n, _tape.Value(float64(len(l))))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, &nu, &n), _tape.Value(1))), _tape.Call(func(_ []float64) {
+		MvNormal.LogDetTape(_tape, c)
+	}, 0)), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Call(func(_ []float64) {
+		D.SqNormTriSolveTape(_tape, l, c)
+	}, 0))), _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, 0, l)
+	}, 1, &nu)))
 }
 
-func (normal) Logps(mu, sigma float64, y ...float64) float64 {
-	if ad.Called() {
-		ad.Enter(&mu, &sigma)
+// Logp calls LogpTape on the current tape.
+func (dist Wishart) Logp(nu float64, l [][]float64, c [][]float64) float64 {
+	return dist.LogpTape(ad.CurrentTape(), nu, l, c)
+}
+
+func (dist Wishart) LogpsTape(_tape *ad.Tape, nu float64, l [][]float64, c ...[][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu)
 	} else {
 		panic("Logps called outside Observe")
 	}
-	var vari float64
-	ad.Assignment(&vari, ad.Arithmetic(ad.OpMul, &sigma, &sigma))
-	var logv float64
-	ad.Assignment(&logv, ad.Elemental(math.Log, &vari))
+	var n float64
+	_tape.Assignment(&n, _tape.Value(float64(len(l))))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, ad.Arithmetic(ad.OpMul, ad.Value(-0.5), (ad.Arithmetic(ad.OpAdd, &logv, &log2pi))), ad.Value(float64(len(y)))))
-	for i := range y {
-		var d float64
-		ad.Assignment(&d, ad.Arithmetic(ad.OpSub, &y[i], &mu))
-		ad.Assignment(&lp, ad.Arithmetic(ad.OpSub, &lp, ad.Arithmetic(ad.OpDiv, ad.Arithmetic(ad.OpMul, ad.Arithmetic(ad.OpMul, ad.Value(0.5), &d), &d), &vari)))
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, 0, l)
+	}, 1, &nu)), _tape.Value(float64(len(c)))))
+	for i := range c {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, &nu, &n), _tape.Value(1))), _tape.Call(func(_ []float64) {
+			MvNormal.LogDetTape(_tape, c[i])
+		}, 0)), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Call(func(_ []float64) {
+			D.SqNormTriSolveTape(_tape, l, c[i])
+		}, 0)))))
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-type cauchy struct{}
-
-var Cauchy cauchy
+// Logps calls LogpsTape on the current tape.
+func (dist Wishart) Logps(nu float64, l [][]float64, c ...[][]float64) float64 {
+	return dist.LogpsTape(ad.CurrentTape(), nu, l, c...)
+}
 
-func (dist cauchy) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+func (dist Wishart) LogZTape(_tape *ad.Tape, nu float64, l [][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu)
 	} else {
-		ad.Setup(x)
+		panic("LogZ called outside Observe")
 	}
-	var (
-		mu float64
+	var n int
+
+	n = len(l)
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, &nu, _tape.Call(func(_ []float64) {
+		MvNormal.LogDetTape(_tape, l)
+	}, 0)), _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu), _tape.Value(float64(n))), &log2)), _tape.Call(func(_ []float64) {
+		D.LogMvGammaTape(_tape, 0, n)
+	}, 1, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu))))
+}
 
-		sigma float64
+// LogZ calls LogZTape on the current tape.
+func (dist Wishart) LogZ(nu float64, l [][]float64) float64 {
+	return dist.LogZTape(ad.CurrentTape(), nu, l)
+}
 
-		y []float64
-	)
+type InverseWishart struct {
+	N int
+}
 
-	mu, sigma, y = x[0], x[1], x[2:]
-	if len(y) == 1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(0, 0, 0)
-		}, 3, &mu, &sigma, &y[0]))
+var InvWish InverseWishart
+
+func (dist InverseWishart) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(0, 0, y...)
-		}, 2, &mu, &sigma))
+		_tape.Setup(x)
+	}
+	var nu float64
+	_tape.Assignment(&nu, &x[0])
+	var ms [][][]float64
+
+	ms = make([][][]float64, (len(x)-1)/(dist.N*dist.N))
+	for i := range ms {
+		ms[i] = make([][]float64, dist.N)
+		for j := range ms[i] {
+			var k int
+
+			k = 1 + dist.N*(dist.N*i+j)
+			ms[i][j] = x[k : k+dist.N]
+		}
 	}
+	if len(ms) == 2 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, ms[0], ms[1])
+		}, 1, &nu))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, ms[0], ms[1:]...)
+		}, 1, &nu))
+	}
+}
+
+// Observe calls ObserveTape on the current tape.
+func (dist InverseWishart) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
 }
 
-func (cauchy) Logp(x0, gamma float64, y float64) float64 {
-	if ad.Called() {
-		ad.Enter(&x0, &gamma, &y)
+func (dist InverseWishart) LogpTape(_tape *ad.Tape,
+	nu float64, l [][]float64, c [][]float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu)
 	} else {
 		panic("Logp called outside Observe")
 	}
-	var logGamma float64
-	ad.Assignment(&logGamma, ad.Elemental(math.Log, &gamma))
-	var d float64
-	ad.Assignment(&d, ad.Arithmetic(ad.OpDiv, (ad.Arithmetic(ad.OpSub, &y, &x0)), &gamma))
-	return ad.Return(ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpNeg, &logGamma), &logpi), ad.Elemental(math.Log, ad.Arithmetic(ad.OpAdd, ad.Value(1), ad.Arithmetic(ad.OpMul, &d, &d)))))
+	var n float64
+	_tape.Assignment(&n, _tape.Value(float64(len(l))))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, &nu, &n), _tape.Value(1)))), _tape.Call(func(_ []float64) {
+		MvNormal.LogDetTape(_tape, c)
+	}, 0)), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Call(func(_ []float64) {
+		D.SqNormTriSolveTape(_tape, c, l)
+	}, 0))), _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, 0, l)
+	}, 1, &nu)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (dist InverseWishart) Logp(nu float64, l [][]float64, c [][]float64) float64 {
+	return dist.LogpTape(ad.CurrentTape(), nu, l, c)
 }
 
-func (cauchy) Logps(x0, gamma float64, y ...float64) float64 {
-	if ad.Called() {
-		ad.Enter(&x0, &gamma)
+func (dist InverseWishart) LogpsTape(_tape *ad.Tape,
+	nu float64, l [][]float64, c ...[][]float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu)
 	} else {
 		panic("Logps called outside Observe")
 	}
-	var logGamma float64
-	ad.Assignment(&logGamma, ad.Elemental(math.Log, &gamma))
+	var n float64
+	_tape.Assignment(&n, _tape.Value(float64(len(l))))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpNeg, &logGamma), &logpi)), ad.Value(float64(len(y)))))
-	for i := range y {
-		var d float64
-		ad.Assignment(&d, ad.Arithmetic(ad.OpDiv, (ad.Arithmetic(ad.OpSub, &y[i], &x0)), &gamma))
-		ad.Assignment(&lp, ad.Arithmetic(ad.OpSub, &lp, ad.Elemental(math.Log, ad.Arithmetic(ad.OpAdd, ad.Value(1), ad.Arithmetic(ad.OpMul, &d, &d)))))
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, 0, l)
+	}, 1, &nu)), _tape.Value(float64(len(c)))))
+	for i := range c {
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpSub, &lp, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, &nu, &n), _tape.Value(1))), _tape.Call(func(_ []float64) {
+			MvNormal.LogDetTape(_tape, c[i])
+		}, 0)), _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Call(func(_ []float64) {
+			D.SqNormTriSolveTape(_tape, c[i], l)
+		}, 0)))))
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-type exponential struct{}
+// Logps calls LogpsTape on the current tape.
+func (dist InverseWishart) Logps(nu float64, l [][]float64, c ...[][]float64) float64 {
+	return dist.LogpsTape(ad.CurrentTape(), nu, l, c...)
+}
 
-var Exponential, Expon exponential
+func (dist InverseWishart) LogZTape(_tape *ad.Tape, nu float64, l [][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&nu)
+	} else {
+		panic("LogZ called outside Observe")
+	}
+	var n int
+
+	n = len(l)
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, &nu), _tape.Call(func(_ []float64) {
+		MvNormal.LogDetTape(_tape, l)
+	}, 0)), _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu), _tape.Value(float64(n))), &log2)), _tape.Call(func(_ []float64) {
+		D.LogMvGammaTape(_tape, 0, n)
+	}, 1, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), &nu))))
+}
 
-func (dist exponential) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+// LogZ calls LogZTape on the current tape.
+func (dist InverseWishart) LogZ(nu float64, l [][]float64) float64 {
+	return dist.LogZTape(ad.CurrentTape(), nu, l)
+}
+
+type poisson struct{}
+
+var Poisson poisson
+
+func (dist poisson) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup(x)
+		_tape.Setup(x)
 	}
 	var (
 		lambda float64
@@ -161,298 +1901,390 @@ func (dist exponential) Observe(x []float64) float64 {
 
 	lambda, y = x[0], x[1:]
 	if len(y) == 1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(0, 0)
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0)
 		}, 2, &lambda, &y[0]))
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(0, y...)
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, y...)
 		}, 1, &lambda))
 	}
 }
 
-func (exponential) Logp(lambda float64, y float64) float64 {
-	if ad.Called() {
-		ad.Enter(&lambda, &y)
+// Observe calls ObserveTape on the current tape.
+func (dist poisson) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (poisson) LogpTape(_tape *ad.Tape, lambda float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&lambda, &y)
 	} else {
 		panic("Logp called outside Observe")
 	}
-	var logl float64
-	ad.Assignment(&logl, ad.Elemental(math.Log, &lambda))
-	return ad.Return(ad.Arithmetic(ad.OpSub, &logl, ad.Arithmetic(ad.OpMul, &lambda, &y)))
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, &y, _tape.Elemental(math.Log, &lambda)), &lambda), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y, _tape.Value(1)))))
 }
 
-func (exponential) Logps(lambda float64, y ...float64) float64 {
-	if ad.Called() {
-		ad.Enter(&lambda)
+// Logp calls LogpTape on the current tape.
+func (_recv poisson) Logp(lambda float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), lambda, y)
+}
+
+func (poisson) LogpsTape(_tape *ad.Tape, lambda float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&lambda)
 	} else {
 		panic("Logps called outside Observe")
 	}
 	var logl float64
-	ad.Assignment(&logl, ad.Elemental(math.Log, &lambda))
+	_tape.Assignment(&logl, _tape.Elemental(math.Log, &lambda))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, &logl, ad.Value(float64(len(y)))))
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, &lambda), _tape.Value(float64(len(y)))))
 	for i := range y {
-		ad.Assignment(&lp, ad.Arithmetic(ad.OpSub, &lp, ad.Arithmetic(ad.OpMul, &lambda, &y[i])))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, &y[i], &logl), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y[i], _tape.Value(1))))))
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-type gamma struct{}
+// Logps calls LogpsTape on the current tape.
+func (_recv poisson) Logps(lambda float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), lambda, y...)
+}
 
-var Gamma gamma
+type negativeBinomial struct{}
 
-func (dist gamma) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+var NegativeBinomial negativeBinomial
+
+func (dist negativeBinomial) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup(x)
+		_tape.Setup(x)
 	}
 	var (
-		alpha float64
+		mu float64
 
-		beta float64
+		phi float64
 
 		y []float64
 	)
 
-	alpha, beta, y = x[0], x[1], x[2:]
+	mu, phi, y = x[0], x[1], x[2:]
 	if len(y) == 1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(0, 0, 0)
-		}, 3, &alpha, &beta, &y[0]))
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &phi, &y[0]))
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(0, 0, y...)
-		}, 2, &alpha, &beta))
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &mu, &phi))
 	}
 }
 
-func (gamma) Logp(alpha, beta float64, y float64) float64 {
-	if ad.Called() {
-		ad.Enter(&alpha, &beta, &y)
+// Observe calls ObserveTape on the current tape.
+func (dist negativeBinomial) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (negativeBinomial) LogpTape(_tape *ad.Tape, mu, phi float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &phi, &y)
 	} else {
 		panic("Logp called outside Observe")
 	}
-	return ad.Return(ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &alpha, ad.Value(1))), ad.Elemental(math.Log, &y)), ad.Arithmetic(ad.OpMul, &beta, &y)), ad.Elemental(mathx.LogGamma, &alpha)), ad.Arithmetic(ad.OpMul, &alpha, ad.Elemental(math.Log, &beta))))
+	var logmuphi float64
+	_tape.Assignment(&logmuphi, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, &mu, &phi)))
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y, &phi)), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y, _tape.Value(1)))), _tape.Elemental(mathx.LogGamma, &phi)), _tape.Arithmetic(ad.OpMul, &phi, (_tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &phi), &logmuphi)))), _tape.Arithmetic(ad.OpMul, &y, (_tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &mu), &logmuphi)))))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv negativeBinomial) Logp(mu, phi float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), mu, phi, y)
 }
 
-func (gamma) Logps(alpha, beta float64, y ...float64) float64 {
-	if ad.Called() {
-		ad.Enter(&alpha, &beta)
+func (negativeBinomial) LogpsTape(_tape *ad.Tape, mu, phi float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&mu, &phi)
 	} else {
 		panic("Logps called outside Observe")
 	}
+	var logmuphi float64
+	_tape.Assignment(&logmuphi, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, &mu, &phi)))
+	var logmu float64
+	_tape.Assignment(&logmu, _tape.Elemental(math.Log, &mu))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpNeg, ad.Elemental(mathx.LogGamma, &alpha)), ad.Arithmetic(ad.OpMul, &alpha, ad.Elemental(math.Log, &beta)))), ad.Value(float64(len(y)))))
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, (_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpNeg, _tape.Elemental(mathx.LogGamma, &phi)), _tape.Arithmetic(ad.OpMul, &phi, (_tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &phi), &logmuphi))))), _tape.Value(float64(len(y)))))
 	for i := range y {
-		ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &alpha, ad.Value(1))), ad.Elemental(math.Log, &y[i])), ad.Arithmetic(ad.OpMul, &beta, &y[i]))))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y[i], &phi)), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y[i], _tape.Value(1)))), _tape.Arithmetic(ad.OpMul, &y[i], (_tape.Arithmetic(ad.OpSub, &logmu, &logmuphi))))))
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-type beta struct{}
+// Logps calls LogpsTape on the current tape.
+func (_recv negativeBinomial) Logps(mu, phi float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), mu, phi, y...)
+}
 
-var Beta beta
+type geometric struct{}
 
-func (dist beta) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+var Geometric geometric
+
+func (dist geometric) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup(x)
+		_tape.Setup(x)
 	}
 	var (
-		alpha float64
-
-		beta float64
+		p float64
 
 		y []float64
 	)
 
-	alpha, beta, y = x[0], x[1], x[2:]
+	p, y = x[0], x[1:]
 	if len(y) == 1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(0, 0, 0)
-		}, 3, &alpha, &beta, &y[0]))
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0)
+		}, 2, &p, &y[0]))
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(0, 0, y...)
-		}, 2, &alpha, &beta))
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, y...)
+		}, 1, &p))
 	}
 }
 
-func (beta) Logp(alpha, beta float64, y float64) float64 {
-	if ad.Called() {
-		ad.Enter(&alpha, &beta, &y)
+// Observe calls ObserveTape on the current tape.
+func (dist geometric) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (geometric) LogpTape(_tape *ad.Tape, p float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&p, &y)
 	} else {
 		panic("Logp called outside Observe")
 	}
-	return ad.Return(ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &alpha, ad.Value(1))), ad.Elemental(math.Log, &y)), ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &beta, ad.Value(1))), ad.Elemental(math.Log, ad.Arithmetic(ad.OpSub, ad.Value(1), &y)))), ad.Elemental(mathx.LogGamma, &alpha)), ad.Elemental(mathx.LogGamma, &beta)), ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, &alpha, &beta))))
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, &y, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &p))), _tape.Elemental(math.Log, &p)))
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv geometric) Logp(p float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), p, y)
 }
 
-func (beta) Logps(alpha, beta float64, y ...float64) float64 {
-	if ad.Called() {
-		ad.Enter(&alpha, &beta)
+func (geometric) LogpsTape(_tape *ad.Tape, p float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&p)
 	} else {
 		panic("Logps called outside Observe")
 	}
+	var log1p float64
+	_tape.Assignment(&log1p, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &p)))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpNeg, ad.Elemental(mathx.LogGamma, &alpha)), ad.Elemental(mathx.LogGamma, &beta)), ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, &alpha, &beta)))), ad.Value(float64(len(y)))))
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Elemental(math.Log, &p), _tape.Value(float64(len(y)))))
 	for i := range y {
-		ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp, ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &alpha, ad.Value(1))), ad.Elemental(math.Log, &y[i])), ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &beta, ad.Value(1))), ad.Elemental(math.Log, ad.Arithmetic(ad.OpSub, ad.Value(1), &y[i]))))))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpMul, &y[i], &log1p)))
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-type binomial struct{}
+// Logps calls LogpsTape on the current tape.
+func (_recv geometric) Logps(p float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), p, y...)
+}
 
-var Binomial binomial
+type zeroInflatedPoisson struct{}
 
-func (dist binomial) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+var ZeroInflatedPoisson zeroInflatedPoisson
+
+func (dist zeroInflatedPoisson) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup(x)
+		_tape.Setup(x)
 	}
 	var (
-		n float64
+		pi float64
 
-		p float64
+		lambda float64
 
 		y []float64
 	)
 
-	n, p, y = x[0], x[1], x[2:]
+	pi, lambda, y = x[0], x[1], x[2:]
 	if len(y) == 1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(0, 0, 0)
-		}, 3, &n, &p, &y[0]))
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0)
+		}, 3, &pi, &lambda, &y[0]))
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(0, 0, y...)
-		}, 2, &n, &p))
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, y...)
+		}, 2, &pi, &lambda))
 	}
 }
 
-func (binomial) Logp(n, p float64, y float64) float64 {
-	if ad.Called() {
-		ad.Enter(&n, &p, &y)
+// Observe calls ObserveTape on the current tape.
+func (dist zeroInflatedPoisson) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (zeroInflatedPoisson) LogpTape(_tape *ad.Tape, pi, lambda float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&pi, &lambda, &y)
 	} else {
 		panic("Logp called outside Observe")
 	}
-	return ad.Return(ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpMul, &y, ad.Elemental(math.Log, &p)), ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &n, &y)), ad.Elemental(math.Log, ad.Arithmetic(ad.OpSub, ad.Value(1), &p)))), ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, &y, ad.Value(1)))), ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpSub, &n, &y), ad.Value(1)))), ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, &n, ad.Value(1)))))
+	if y < 0.5 {
+		return _tape.Return(_tape.Elemental(mathx.LogSumExp, _tape.Elemental(math.Log, &pi), _tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &pi)), &lambda)))
+	} else {
+		return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &pi)), _tape.Call(func(_ []float64) {
+			Poisson.LogpTape(_tape, 0, 0)
+		}, 2, &lambda, &y)))
+	}
+}
+
+// Logp calls LogpTape on the current tape.
+func (_recv zeroInflatedPoisson) Logp(pi, lambda float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), pi, lambda, y)
 }
 
-func (binomial) Logps(n, p float64, y ...float64) float64 {
-	if ad.Called() {
-		ad.Enter(&n, &p)
+func (zeroInflatedPoisson) LogpsTape(_tape *ad.Tape, pi, lambda float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&pi, &lambda)
 	} else {
 		panic("Logps called outside Observe")
 	}
+	var logz float64
+	_tape.Assignment(&logz, _tape.Elemental(math.Log, &pi))
+	var log1z float64
+	_tape.Assignment(&log1z, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &pi)))
+	var logl float64
+	_tape.Assignment(&logl, _tape.Elemental(math.Log, &lambda))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, &n, ad.Value(1))), ad.Value(float64(len(y)))))
+	_tape.Assignment(&lp, _tape.Value(0.))
 	for i := range y {
-		ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpSub, ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpMul, &y[i], ad.Elemental(math.Log, &p)), ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &n, &y[i])), ad.Elemental(math.Log, ad.Arithmetic(ad.OpSub, ad.Value(1), &p)))), ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, &y[i], ad.Value(1)))), ad.Elemental(mathx.LogGamma, ad.Arithmetic(ad.OpAdd, ad.Arithmetic(ad.OpSub, &n, &y[i]), ad.Value(1))))))
+		if y[i] < 0.5 {
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Elemental(mathx.LogSumExp, &logz, _tape.Arithmetic(ad.OpSub, &log1z, &lambda))))
+		} else {
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, &log1z, _tape.Arithmetic(ad.OpMul, &y[i], &logl)), &lambda), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y[i], _tape.Value(1))))))
+		}
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-type Dirichlet struct {
-	N int
+// Logps calls LogpsTape on the current tape.
+func (_recv zeroInflatedPoisson) Logps(pi, lambda float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), pi, lambda, y...)
 }
 
-var Dir Dirichlet
+type zeroInflatedNegativeBinomial struct{}
 
-func (dist Dirichlet) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+var ZeroInflatedNegativeBinomial zeroInflatedNegativeBinomial
+
+func (dist zeroInflatedNegativeBinomial) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup(x)
+		_tape.Setup(x)
 	}
-	var alpha []float64
+	var (
+		pi float64
 
-	alpha = x[:dist.N]
-	if len(x[dist.N:]) == dist.N {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(alpha, x[dist.N:])
-		}, 0))
-	} else {
-		var ys [][]float64
+		mu float64
 
-		ys = make([][]float64, len(x[dist.N:])/dist.N)
-		for i := range ys {
-			ys[i] = x[dist.N*(i+1) : dist.N*(i+2)]
-		}
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(alpha, ys...)
-		}, 0))
+		phi float64
+
+		y []float64
+	)
+
+	pi, mu, phi, y = x[0], x[1], x[2], x[3:]
+	if len(y) == 1 {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0, 0, 0)
+		}, 4, &pi, &mu, &phi, &y[0]))
+	} else {
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, 0, 0, y...)
+		}, 3, &pi, &mu, &phi))
 	}
 }
 
-func (dist Dirichlet) Logp(alpha []float64, y []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+// Observe calls ObserveTape on the current tape.
+func (dist zeroInflatedNegativeBinomial) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (zeroInflatedNegativeBinomial) LogpTape(_tape *ad.Tape,
+	pi, mu, phi float64, y float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter(&pi, &mu, &phi, &y)
 	} else {
 		panic("Logp called outside Observe")
 	}
-	var sum float64
-	ad.Assignment(&sum, ad.Value(0.))
-	for j := range y {
-		ad.Assignment(&sum, ad.Arithmetic(ad.OpAdd, &sum, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &alpha[j], ad.Value(1))), ad.Elemental(math.Log, &y[j]))))
+
+	if y < 0.5 {
+		var lp0 float64
+		_tape.Assignment(&lp0, _tape.Arithmetic(ad.OpMul, &phi, (_tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &phi), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, &mu, &phi))))))
+		return _tape.Return(_tape.Elemental(mathx.LogSumExp, _tape.Elemental(math.Log, &pi), _tape.Arithmetic(ad.OpAdd, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &pi)), &lp0)))
+	} else {
+		return _tape.Return(_tape.Arithmetic(ad.OpAdd, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &pi)), _tape.Call(func(_ []float64) {
+			NegativeBinomial.LogpTape(_tape, 0, 0, 0)
+		}, 3, &mu, &phi, &y)))
 	}
+}
 
-	return ad.Return(ad.Arithmetic(ad.OpSub, &sum, ad.Call(func(_ []float64) {
-		dist.LogZ(alpha)
-	}, 0)))
+// Logp calls LogpTape on the current tape.
+func (_recv zeroInflatedNegativeBinomial) Logp(pi, mu, phi float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), pi, mu, phi, y)
 }
 
-func (dist Dirichlet) Logps(alpha []float64, y ...[]float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+func (zeroInflatedNegativeBinomial) LogpsTape(_tape *ad.Tape,
+	pi, mu, phi float64, y ...float64,
+) float64 {
+	if _tape.Called() {
+		_tape.Enter(&pi, &mu, &phi)
 	} else {
 		panic("Logps called outside Observe")
 	}
-	var LogZ float64
-	ad.Assignment(&LogZ, ad.Call(func(_ []float64) {
-		dist.LogZ(alpha)
-	}, 0))
+	var logz float64
+	_tape.Assignment(&logz, _tape.Elemental(math.Log, &pi))
+	var log1z float64
+	_tape.Assignment(&log1z, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &pi)))
+	var logmuphi float64
+	_tape.Assignment(&logmuphi, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpAdd, &mu, &phi)))
+	var logmu float64
+	_tape.Assignment(&logmu, _tape.Elemental(math.Log, &mu))
+	var lp0 float64
+	_tape.Assignment(&lp0, _tape.Arithmetic(ad.OpMul, &phi, (_tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &phi), &logmuphi))))
+	var lgphi float64
+	_tape.Assignment(&lgphi, _tape.Elemental(mathx.LogGamma, &phi))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, ad.Arithmetic(ad.OpNeg, &LogZ), ad.Value(float64(len(y)))))
+	_tape.Assignment(&lp, _tape.Value(0.))
 	for i := range y {
-		for j := range alpha {
-			ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp, ad.Arithmetic(ad.OpMul, (ad.Arithmetic(ad.OpSub, &alpha[j], ad.Value(1))), ad.Elemental(math.Log, &y[i][j]))))
+		if y[i] < 0.5 {
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Elemental(mathx.LogSumExp, &logz, _tape.Arithmetic(ad.OpAdd, &log1z, &lp0))))
+		} else {
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpAdd, &log1z, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y[i], &phi))), _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpAdd, &y[i], _tape.Value(1)))), &lgphi), &lp0), _tape.Arithmetic(ad.OpMul, &y[i], (_tape.Arithmetic(ad.OpSub, &logmu, &logmuphi))))))
 		}
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-func (dist Dirichlet) LogZ(alpha []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
-	} else {
-		panic("LogZ called outside Observe")
-	}
-	var sumAlpha float64
-	ad.Assignment(&sumAlpha, ad.Value(0.))
-	var sumLogGammaAlpha float64
-	ad.Assignment(&sumLogGammaAlpha, ad.Value(0.))
-	for i := range alpha {
-		ad.Assignment(&sumAlpha, ad.Arithmetic(ad.OpAdd, &sumAlpha, &alpha[i]))
-		ad.Assignment(&sumLogGammaAlpha, ad.Arithmetic(ad.OpAdd, &sumLogGammaAlpha, ad.Elemental(mathx.LogGamma, &alpha[i])))
-	}
-
-	return ad.Return(ad.Arithmetic(ad.OpSub, &sumLogGammaAlpha, ad.Elemental(mathx.LogGamma, &sumAlpha)))
+// Logps calls LogpsTape on the current tape.
+func (_recv zeroInflatedNegativeBinomial) Logps(pi, mu, phi float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), pi, mu, phi, y...)
 }
 
 type bernoulli struct{}
 
 var Bernoulli bernoulli
 
-func (dist bernoulli) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+func (dist bernoulli) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup(x)
+		_tape.Setup(x)
 	}
 	var (
 		p float64
@@ -462,45 +2294,60 @@ func (dist bernoulli) Observe(x []float64) float64 {
 
 	p, y = x[0], x[1:]
 	if len(y) == 1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(0, 0)
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, 0, 0)
 		}, 2, &p, &y[0]))
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(0, y...)
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, 0, y...)
 		}, 1, &p))
 	}
 }
 
-func (bernoulli) Logp(p float64, y float64) float64 {
-	if ad.Called() {
-		ad.Enter(&p, &y)
+// Observe calls ObserveTape on the current tape.
+func (dist bernoulli) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (bernoulli) LogpTape(_tape *ad.Tape, p float64, y float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&p, &y)
 	} else {
 		panic("Logp called outside Observe")
 	}
 	if y >= 0.5 {
-		return ad.Return(ad.Elemental(math.Log, &p))
+		return _tape.Return(_tape.Elemental(math.Log, &p))
 	} else {
-		return ad.Return(ad.Elemental(math.Log, ad.Arithmetic(ad.OpSub, ad.Value(1), &p)))
+		return _tape.Return(_tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &p)))
 	}
 }
 
-func (bernoulli) Logps(p float64, y ...float64) float64 {
-	if ad.Called() {
-		ad.Enter(&p)
+// Logp calls LogpTape on the current tape.
+func (_recv bernoulli) Logp(p float64, y float64) float64 {
+	return _recv.LogpTape(ad.CurrentTape(), p, y)
+}
+
+func (bernoulli) LogpsTape(_tape *ad.Tape, p float64, y ...float64) float64 {
+	if _tape.Called() {
+		_tape.Enter(&p)
 	} else {
 		panic("Logps called outside Observe")
 	}
 	var lp float64
-	ad.Assignment(&lp, ad.Value(0.))
+	_tape.Assignment(&lp, _tape.Value(0.))
 	for i := range y {
 		if y[i] >= 0.5 {
-			ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp, ad.Elemental(math.Log, &p)))
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Elemental(math.Log, &p)))
 		} else {
-			ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp, ad.Elemental(math.Log, ad.Arithmetic(ad.OpSub, ad.Value(1), &p))))
+			_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &p))))
 		}
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
+}
+
+// Logps calls LogpsTape on the current tape.
+func (_recv bernoulli) Logps(p float64, y ...float64) float64 {
+	return _recv.LogpsTape(ad.CurrentTape(), p, y...)
 }
 
 type Categorical struct {
@@ -509,89 +2356,114 @@ type Categorical struct {
 
 var Cat Categorical
 
-func (dist Categorical) Observe(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+func (dist Categorical) ObserveTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup(x)
+		_tape.Setup(x)
 	}
 	if len(x) == dist.N+1 {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logp(x[:dist.N], 0)
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpTape(_tape, x[:dist.N], 0)
 		}, 1, &x[dist.N]))
 	} else {
-		return ad.Return(ad.Call(func(_ []float64) {
-			dist.Logps(x[:dist.N], x[dist.N:]...)
+		return _tape.Return(_tape.Call(func(_ []float64) {
+			dist.LogpsTape(_tape, x[:dist.N], x[dist.N:]...)
 		}, 0))
 	}
 }
 
-func (dist Categorical) Logp(
+// Observe calls ObserveTape on the current tape.
+func (dist Categorical) Observe(x []float64) float64 {
+	return dist.ObserveTape(ad.CurrentTape(), x)
+}
+
+func (dist Categorical) LogpTape(_tape *ad.Tape,
 	alpha []float64, y float64,
 ) float64 {
-	if ad.Called() {
-		ad.Enter(&y)
+	if _tape.Called() {
+		_tape.Enter(&y)
 	} else {
 		panic("Logp called outside Observe")
 	}
 	var i int
 
 	i = int(y)
-	return ad.Return(ad.Arithmetic(ad.OpSub, ad.Elemental(math.Log, &alpha[i]), ad.Call(func(_ []float64) {
-		dist.LogZ(alpha)
+	return _tape.Return(_tape.Arithmetic(ad.OpSub, _tape.Elemental(math.Log, &alpha[i]), _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, alpha)
 	}, 0)))
 }
 
-func (dist Categorical) Logps(
+// Logp calls LogpTape on the current tape.
+func (dist Categorical) Logp(alpha []float64, y float64) float64 {
+	return dist.LogpTape(ad.CurrentTape(), alpha, y)
+}
+
+func (dist Categorical) LogpsTape(_tape *ad.Tape,
 	alpha []float64, y ...float64,
 ) float64 {
-	if ad.Called() {
-		ad.Enter()
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
 		panic("Logps called outside Observe")
 	}
 	var LogZ float64
-	ad.Assignment(&LogZ, ad.Call(func(_ []float64) {
-		dist.LogZ(alpha)
+	_tape.Assignment(&LogZ, _tape.Call(func(_ []float64) {
+		dist.LogZTape(_tape, alpha)
 	}, 0))
 	var lp float64
-	ad.Assignment(&lp, ad.Arithmetic(ad.OpMul, ad.Arithmetic(ad.OpNeg, &LogZ), ad.Value(float64(len(y)))))
+	_tape.Assignment(&lp, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpNeg, &LogZ), _tape.Value(float64(len(y)))))
 	for i := range y {
-		ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp, ad.Elemental(math.Log, &alpha[int(y[i])])))
+		_tape.Assignment(&lp, _tape.Arithmetic(ad.OpAdd, &lp, _tape.Elemental(math.Log, &alpha[int(y[i])])))
 	}
-	return ad.Return(&lp)
+	return _tape.Return(&lp)
 }
 
-func (dist Categorical) LogZ(alpha []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+// Logps calls LogpsTape on the current tape.
+func (dist Categorical) Logps(alpha []float64, y ...float64) float64 {
+	return dist.LogpsTape(ad.CurrentTape(), alpha, y...)
+}
+
+func (dist Categorical) LogZTape(_tape *ad.Tape, alpha []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
 		panic("LogZ called outside Observe")
 	}
 	var z float64
-	ad.Assignment(&z, ad.Value(0.))
+	_tape.Assignment(&z, _tape.Value(0.))
 	for _, a := range alpha {
-		ad.Assignment(&z, ad.Arithmetic(ad.OpAdd, &z, &a))
+		_tape.Assignment(&z, _tape.Arithmetic(ad.OpAdd, &z, &a))
 	}
-	return ad.Return(ad.Elemental(math.Log, &z))
+	return _tape.Return(_tape.Elemental(math.Log, &z))
+}
+
+// LogZ calls LogZTape on the current tape.
+func (dist Categorical) LogZ(alpha []float64) float64 {
+	return dist.LogZTape(ad.CurrentTape(), alpha)
 }
 
 type d struct{}
 
-func (d) Observe(_ []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+func (d) ObserveTape(_tape *ad.Tape, _ []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
-		ad.Setup([]float64{})
+		_tape.Setup([]float64{})
 	}
 	panic("should never be called")
 }
 
+// Observe calls ObserveTape on the current tape.
+func (_recv d) Observe(_param0 []float64) float64 {
+	return _recv.ObserveTape(ad.CurrentTape(), _param0)
+}
+
 var D d
 
-func (d) SoftMax(x, p []float64) {
-	if ad.Called() {
-		ad.Enter()
+func (d) SoftMaxTape(_tape *ad.Tape, x, p []float64) {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
 		panic("SoftMax called outside Observe")
 	}
@@ -600,28 +2472,33 @@ func (d) SoftMax(x, p []float64) {
 			"got len(x)=%v, len(p)=%v", len(x), len(p)))
 	}
 	var max float64
-	ad.Assignment(&max, ad.Value(math.Inf(-1)))
+	_tape.Assignment(&max, _tape.Value(math.Inf(-1)))
 	for i := range x {
 		if x[i] > max {
-			ad.Assignment(&max, &x[i])
+			_tape.Assignment(&max, &x[i])
 		}
 	}
 	var z float64
-	ad.Assignment(&z, ad.Value(0.))
+	_tape.Assignment(&z, _tape.Value(0.))
 	for i := range x {
 		var q float64
-		ad.Assignment(&q, ad.Elemental(math.Exp, ad.Arithmetic(ad.OpSub, &x[i], &max)))
-		ad.Assignment(&z, ad.Arithmetic(ad.OpAdd, &z, &q))
-		ad.Assignment(&p[i], &q)
+		_tape.Assignment(&q, _tape.Elemental(math.Exp, _tape.Arithmetic(ad.OpSub, &x[i], &max)))
+		_tape.Assignment(&z, _tape.Arithmetic(ad.OpAdd, &z, &q))
+		_tape.Assignment(&p[i], &q)
 	}
 	for i := range p {
-		ad.Assignment(&p[i], ad.Arithmetic(ad.OpDiv, &p[i], &z))
+		_tape.Assignment(&p[i], _tape.Arithmetic(ad.OpDiv, &p[i], &z))
 	}
 }
 
-func (d) LogSoftMax(x, p []float64) {
-	if ad.Called() {
-		ad.Enter()
+// SoftMax calls SoftMaxTape on the current tape.
+func (_recv d) SoftMax(x, p []float64) {
+	_recv.SoftMaxTape(ad.CurrentTape(), x, p)
+}
+
+func (d) LogSoftMaxTape(_tape *ad.Tape, x, p []float64) {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
 		panic("LogSoftMax called outside Observe")
 	}
@@ -630,32 +2507,140 @@ func (d) LogSoftMax(x, p []float64) {
 			"got len(x)=%v, len(p)=%v", len(x), len(p)))
 	}
 	var logZ float64
-	ad.Assignment(&logZ, ad.Call(func(_ []float64) {
-		D.LogSumExp(x)
+	_tape.Assignment(&logZ, _tape.Call(func(_ []float64) {
+		D.LogSumExpTape(_tape, x)
 	}, 0))
 	for i := range p {
-		ad.Assignment(&p[i], ad.Arithmetic(ad.OpSub, &x[i], &logZ))
+		_tape.Assignment(&p[i], _tape.Arithmetic(ad.OpSub, &x[i], &logZ))
 	}
 }
 
-func (d) LogSumExp(x []float64) float64 {
-	if ad.Called() {
-		ad.Enter()
+// LogSoftMax calls LogSoftMaxTape on the current tape.
+func (_recv d) LogSoftMax(x, p []float64) {
+	_recv.LogSoftMaxTape(ad.CurrentTape(), x, p)
+}
+
+func (d) LogSumExpTape(_tape *ad.Tape, x []float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
 	} else {
 		panic("LogSumExp called outside Observe")
 	}
 	var max float64
-	ad.Assignment(&max, ad.Value(math.Inf(-1)))
+	_tape.Assignment(&max, _tape.Value(math.Inf(-1)))
 	for i := range x {
 		if x[i] > max {
-			ad.Assignment(&max, &x[i])
+			_tape.Assignment(&max, &x[i])
 		}
 	}
 	var sumExp float64
-	ad.Assignment(&sumExp, ad.Value(0.))
+	_tape.Assignment(&sumExp, _tape.Value(0.))
 	for i := range x {
-		ad.Assignment(&sumExp, ad.Arithmetic(ad.OpAdd, &sumExp, ad.Elemental(math.Exp, ad.Arithmetic(ad.OpSub, &x[i], &max))))
+		_tape.Assignment(&sumExp, _tape.Arithmetic(ad.OpAdd, &sumExp, _tape.Elemental(math.Exp, _tape.Arithmetic(ad.OpSub, &x[i], &max))))
+	}
+
+	return _tape.Return(_tape.Arithmetic(ad.OpAdd, &max, _tape.Elemental(math.Log, &sumExp)))
+}
+
+// LogSumExp calls LogSumExpTape on the current tape.
+func (_recv d) LogSumExp(x []float64) float64 {
+	return _recv.LogSumExpTape(ad.CurrentTape(), x)
+}
+
+func (d) CholeskyCorrTape(_tape *ad.Tape, x []float64, l [][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("CholeskyCorr called outside Observe")
+	}
+	var n int
+
+	n = len(l)
+	if len(x) != n*(n-1)/2 {
+		panic(fmt.Sprintf("wrong number of parameters: "+
+			"got len(x)=%v, want %v", len(x), n*(n-1)/2))
+	}
+	var logj float64
+	_tape.Assignment(&logj, _tape.Value(0.))
+	_tape.Assignment(&l[0][0], _tape.Value(1))
+	for j := 1; j != n; j = j + 1 {
+		_tape.Assignment(&l[0][j], _tape.Value(0))
+	}
+	var k int
+
+	k = 0
+	for i := 1; i != n; i = i + 1 {
+		var sumsq float64
+		_tape.Assignment(&sumsq, _tape.Value(0.))
+		for j := 0; j != i; j = j + 1 {
+			var z float64
+			_tape.Assignment(&z, _tape.Arithmetic(ad.OpSub, _tape.Arithmetic(ad.OpMul, _tape.Value(2), _tape.Elemental(mathx.Sigm, _tape.Arithmetic(ad.OpMul, _tape.Value(2), &x[k]))), _tape.Value(1)))
+			_tape.Assignment(&logj, _tape.Arithmetic(ad.OpAdd, &logj, _tape.Arithmetic(ad.OpAdd, _tape.Arithmetic(ad.OpMul, _tape.Value(2), &log2), _tape.Elemental(mathx.LogDSigm, _tape.Arithmetic(ad.OpMul, _tape.Value(-2), _tape.Elemental(math.Abs, &x[k]))))))
+			_tape.Assignment(&logj, _tape.Arithmetic(ad.OpAdd, &logj, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Elemental(math.Log, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &sumsq)))))
+			_tape.Assignment(&l[i][j], _tape.Arithmetic(ad.OpMul, &z, _tape.Elemental(math.Sqrt, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &sumsq))))
+			_tape.Assignment(&sumsq, _tape.Arithmetic(ad.OpAdd, &sumsq, _tape.Arithmetic(ad.OpMul, &l[i][j], &l[i][j])))
+			k = k + 1
+		}
+		_tape.Assignment(&l[i][i], _tape.Elemental(math.Sqrt, _tape.Arithmetic(ad.OpSub, _tape.Value(1), &sumsq)))
+		for j := i + 1; j != n; j = j + 1 {
+			_tape.Assignment(&l[i][j], _tape.Value(0))
+		}
 	}
+	return _tape.Return(&logj)
+}
+
+// CholeskyCorr calls CholeskyCorrTape on the current tape.
+func (_recv d) CholeskyCorr(x []float64, l [][]float64) float64 {
+	return _recv.CholeskyCorrTape(ad.CurrentTape(), x, l)
+}
+
+func (d) LogMvGammaTape(_tape *ad.Tape, a float64, n int) float64 {
+	if _tape.Called() {
+		_tape.Enter(&a)
+	} else {
+		panic("LogMvGamma called outside Observe")
+	}
+	var lg float64
+	_tape.Assignment(&lg, _tape.Arithmetic(ad.OpMul, _tape.Arithmetic(ad.OpMul, _tape.Value(0.25), _tape.Value(float64(n*(n-1)))), &logpi))
+	for j := 0; j != n; j = j + 1 {
+		_tape.Assignment(&lg, _tape.Arithmetic(ad.OpAdd, &lg, _tape.Elemental(mathx.LogGamma, _tape.Arithmetic(ad.OpSub, &a, _tape.Arithmetic(ad.OpMul, _tape.Value(0.5), _tape.Value(float64(j)))))))
+	}
+	return _tape.Return(&lg)
+}
+
+// LogMvGamma calls LogMvGammaTape on the current tape.
+func (_recv d) LogMvGamma(a float64, n int) float64 {
+	return _recv.LogMvGammaTape(ad.CurrentTape(), a, n)
+}
+
+func (d) SqNormTriSolveTape(_tape *ad.Tape, a, b [][]float64) float64 {
+	if _tape.Called() {
+		_tape.Enter()
+	} else {
+		panic("SqNormTriSolve called outside Observe")
+	}
+	var n int
+
+	n = len(a)
+	var x []float64
+
+	x = make([]float64, n)
+	var sqnorm float64
+	_tape.Assignment(&sqnorm, _tape.Value(0.))
+	for j := 0; j != n; j = j + 1 {
+		for i := j; i != n; i = i + 1 {
+			_tape.Assignment(&x[i], &b[i][j])
+			for k := j; k != i; k = k + 1 {
+				_tape.Assignment(&x[i], _tape.Arithmetic(ad.OpSub, &x[i], _tape.Arithmetic(ad.OpMul, &a[i][k], &x[k])))
+			}
+			_tape.Assignment(&x[i], _tape.Arithmetic(ad.OpDiv, &x[i], &a[i][i]))
+			_tape.Assignment(&sqnorm, _tape.Arithmetic(ad.OpAdd, &sqnorm, _tape.Arithmetic(ad.OpMul, &x[i], &x[i])))
+		}
+	}
+	return _tape.Return(&sqnorm)
+}
 
-	return ad.Return(ad.Arithmetic(ad.OpAdd, &max, ad.Elemental(math.Log, &sumExp)))
+// SqNormTriSolve calls SqNormTriSolveTape on the current tape.
+func (_recv d) SqNormTriSolve(a, b [][]float64) float64 {
+	return _recv.SqNormTriSolveTape(ad.CurrentTape(), a, b)
 }
@@ -0,0 +1,285 @@
+package dist
+
+// Gradient checks of differentiated distributions, against
+// central finite differences.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"math"
+	"testing"
+)
+
+// A model calling a distribution on its parameters, the way
+// differentiated models do. narg is the number of
+// non-variadic arguments of the call.
+type callModel struct {
+	narg int
+	f    func(_vararg []float64)
+}
+
+func (m *callModel) Observe(x []float64) float64 {
+	ad.Setup(x)
+	px := make([]*float64, len(x))
+	for i := range x {
+		px[i] = &x[i]
+	}
+	return ad.Return(ad.Call(m.f, m.narg, px...))
+}
+
+// A model calling a function of matrices with rows of n
+// consecutive parameters.
+type matrixModel struct {
+	n int
+	f func(rows [][]float64)
+}
+
+func (m *matrixModel) Observe(x []float64) float64 {
+	ad.Setup(x)
+	rows := make([][]float64, len(x)/m.n)
+	for i := range rows {
+		rows[i] = x[i*m.n : (i+1)*m.n]
+	}
+	return ad.Return(ad.Call(func(_ []float64) {
+		m.f(rows)
+	}, 0))
+}
+
+// A model of a Cholesky factor of a correlation matrix with
+// the LKJ prior, on unconstrained parameters; x[0] is eta.
+type corrModel struct {
+	n int
+}
+
+func (m *corrModel) Observe(x []float64) float64 {
+	ad.Setup(x)
+	l := make([][]float64, m.n)
+	for i := range l {
+		l[i] = make([]float64, m.n)
+	}
+	var lp float64
+	ad.Assignment(&lp, ad.Call(func(_ []float64) {
+		D.CholeskyCorr(x[1:], l)
+	}, 0))
+	ad.Assignment(&lp, ad.Arithmetic(ad.OpAdd, &lp,
+		ad.Call(func(_ []float64) {
+			LKJ.Logp(0, l)
+		}, 1, &x[0])))
+	return ad.Return(&lp)
+}
+
+// numGradient computes the gradient of m at x by central
+// finite differences.
+func numGradient(m model.Model, x []float64) []float64 {
+	grad := make([]float64, len(x))
+	for i := range x {
+		h := 1e-6 * math.Max(1, math.Abs(x[i]))
+		xi := x[i]
+		x[i] = xi + h
+		lplus := m.Observe(x)
+		model.DropGradient(m)
+		x[i] = xi - h
+		lminus := m.Observe(x)
+		model.DropGradient(m)
+		x[i] = xi
+		grad[i] = (lplus - lminus) / (2 * h)
+	}
+	return grad
+}
+
+// A gradient check: model m is differentiated at x.
+type gradientCase struct {
+	name string
+	m    model.Model
+	x    []float64
+}
+
+// checkGradients compares the gradients of differentiated
+// models with finite differences.
+func checkGradients(t *testing.T, cases []gradientCase) {
+	for _, c := range cases {
+		c.m.Observe(c.x)
+		grad := model.Gradient(c.m)
+		ngrad := numGradient(c.m, c.x)
+		for i := range grad {
+			if math.Abs(grad[i]-ngrad[i]) >
+				1e-5*math.Max(1, math.Abs(ngrad[i])) {
+				t.Errorf("Wrong gradient of %s at %v: "+
+					"got %v, want %v",
+					c.name, c.x, grad, ngrad)
+				break
+			}
+		}
+	}
+}
+
+// The test functions are run by 'make test' with
+// -run TestGradients, the rest of the tests in this package
+// are copies of the tests of dist, which call the
+// differentiated distributions outside Observe.
+
+func TestGradientsLocationScale(t *testing.T) {
+	checkGradients(t, []gradientCase{
+		{"StudentT.Logp", &callModel{4, func(_ []float64) {
+			StudentT.Logp(0, 0, 0, 0)
+		}}, []float64{1., 0., 1., 0.5}},
+		{"StudentT.Logps", &callModel{3, func(y []float64) {
+			StudentT.Logps(0, 0, 0, y...)
+		}}, []float64{10., 0., 0.5, -1., 0.}},
+		{"Laplace.Logp", &callModel{3, func(_ []float64) {
+			Laplace.Logp(0, 0, 0)
+		}}, []float64{1., 2., 2.5}},
+		{"Laplace.Logps", &callModel{2, func(y []float64) {
+			Laplace.Logps(0, 0, y...)
+		}}, []float64{0., 0.5, -1., 0.3}},
+		{"Logistic.Logp", &callModel{3, func(_ []float64) {
+			Logistic.Logp(0, 0, 0)
+		}}, []float64{1., 2., -2.}},
+		{"Logistic.Logps", &callModel{2, func(y []float64) {
+			Logistic.Logps(0, 0, y...)
+		}}, []float64{0., 0.5, -1., 0.3}},
+		{"Gumbel.Logp", &callModel{3, func(_ []float64) {
+			Gumbel.Logp(0, 0, 0)
+		}}, []float64{1., 2., 2.}},
+		{"Gumbel.Logps", &callModel{2, func(y []float64) {
+			Gumbel.Logps(0, 0, y...)
+		}}, []float64{0., 0.5, -1., 0.3}},
+	})
+}
+
+func TestGradientsPositive(t *testing.T) {
+	checkGradients(t, []gradientCase{
+		{"LogNormal.Logp", &callModel{3, func(_ []float64) {
+			LogNormal.Logp(0, 0, 0)
+		}}, []float64{0., 1., 2.}},
+		{"LogNormal.Logps", &callModel{2, func(y []float64) {
+			LogNormal.Logps(0, 0, y...)
+		}}, []float64{1., 0.5, 0.5, 3.}},
+		{"InverseGamma.Logp", &callModel{3, func(_ []float64) {
+			InverseGamma.Logp(0, 0, 0)
+		}}, []float64{2., 3., 2.}},
+		{"InverseGamma.Logps", &callModel{2, func(y []float64) {
+			InverseGamma.Logps(0, 0, y...)
+		}}, []float64{3., 1., 0.5, 3.}},
+		{"Weibull.Logp", &callModel{3, func(_ []float64) {
+			Weibull.Logp(0, 0, 0)
+		}}, []float64{2., 3., 2.}},
+		{"Weibull.Logps", &callModel{2, func(y []float64) {
+			Weibull.Logps(0, 0, y...)
+		}}, []float64{0.5, 1., 0.5, 3.}},
+		{"HalfNormal.Logp", &callModel{2, func(_ []float64) {
+			HalfNormal.Logp(0, 0)
+		}}, []float64{2., 1.}},
+		{"HalfNormal.Logps", &callModel{1, func(y []float64) {
+			HalfNormal.Logps(0, y...)
+		}}, []float64{0.5, 0.5, 2.}},
+		{"HalfCauchy.Logp", &callModel{2, func(_ []float64) {
+			HalfCauchy.Logp(0, 0)
+		}}, []float64{2.5, 2.}},
+		{"HalfCauchy.Logps", &callModel{1, func(y []float64) {
+			HalfCauchy.Logps(0, y...)
+		}}, []float64{5., 1., 0.}},
+		{"HalfStudentT.Logp", &callModel{3, func(_ []float64) {
+			HalfStudentT.Logp(0, 0, 0)
+		}}, []float64{3., 2., 2.}},
+		{"HalfStudentT.Logps", &callModel{2, func(y []float64) {
+			HalfStudentT.Logps(0, 0, y...)
+		}}, []float64{10., 0.5, 1., 0.}},
+	})
+}
+
+func TestGradientsCount(t *testing.T) {
+	checkGradients(t, []gradientCase{
+		{"Poisson.Logp", &callModel{2, func(_ []float64) {
+			Poisson.Logp(0, 0)
+		}}, []float64{2.5, 3.}},
+		{"Poisson.Logps", &callModel{1, func(y []float64) {
+			Poisson.Logps(0, y...)
+		}}, []float64{4., 1., 5.}},
+		{"NegativeBinomial.Logp", &callModel{3, func(_ []float64) {
+			NegativeBinomial.Logp(0, 0, 0)
+		}}, []float64{2.5, 3., 3.}},
+		{"NegativeBinomial.Logps", &callModel{2, func(y []float64) {
+			NegativeBinomial.Logps(0, 0, y...)
+		}}, []float64{4., 0.5, 0., 1., 5.}},
+		{"Geometric.Logp", &callModel{2, func(_ []float64) {
+			Geometric.Logp(0, 0)
+		}}, []float64{0.2, 3.}},
+		{"Geometric.Logps", &callModel{1, func(y []float64) {
+			Geometric.Logps(0, y...)
+		}}, []float64{0.7, 1., 5.}},
+		{"ZeroInflatedPoisson.Logp", &callModel{3, func(_ []float64) {
+			ZeroInflatedPoisson.Logp(0, 0, 0)
+		}}, []float64{0.3, 2.5, 0.}},
+		{"ZeroInflatedPoisson.Logps", &callModel{2, func(y []float64) {
+			ZeroInflatedPoisson.Logps(0, 0, y...)
+		}}, []float64{0.1, 4., 0., 1., 5.}},
+		{"ZeroInflatedPoisson.Logp", &callModel{3, func(_ []float64) {
+			ZeroInflatedPoisson.Logp(0, 0, 0)
+		}}, []float64{0.3, 2.5, 3.}},
+		{"ZeroInflatedNegativeBinomial.Logp", &callModel{4, func(_ []float64) {
+			ZeroInflatedNegativeBinomial.Logp(0, 0, 0, 0)
+		}}, []float64{0.3, 2.5, 3., 0.}},
+		{"ZeroInflatedNegativeBinomial.Logps", &callModel{3, func(y []float64) {
+			ZeroInflatedNegativeBinomial.Logps(0, 0, 0, y...)
+		}}, []float64{0.1, 4., 0.5, 0., 1., 5.}},
+		{"ZeroInflatedNegativeBinomial.Logp", &callModel{4, func(_ []float64) {
+			ZeroInflatedNegativeBinomial.Logp(0, 0, 0, 0)
+		}}, []float64{0.3, 2.5, 3., 3.}},
+	})
+}
+
+func TestGradientsMultivariateNormal(t *testing.T) {
+	checkGradients(t, []gradientCase{
+		// mathx.LogDet through the log-determinant of a
+		// Cholesky factor.
+		{"MultivariateNormal.LogDet", &matrixModel{3, func(l [][]float64) {
+			MvNormal.LogDet(l)
+		}}, []float64{1.5, 0., 0., 0.3, 0.8, 0., -0.2, 0.4, 1.2}},
+		// The multivariate distributions are differentiated
+		// through Observe, the parameters are slices of x.
+		{"MultivariateNormal.Logp", MultivariateNormal{2},
+			[]float64{1., -1., 2., 0., 0.5, 1.5, 0.3, 0.2}},
+		{"MultivariateNormal.Logps", MultivariateNormal{3},
+			[]float64{0., 1., 2.,
+				1., 0., 0., 0.3, 0.8, 0., -0.2, 0.4, 1.2,
+				0.5, 0.5, 0.5, -1., 2., 3.}},
+		{"MultivariateNormalPrec.Logp", MultivariateNormalPrec{2},
+			[]float64{1., -1., 2., 0., 0.5, 1.5, 0.3, 0.2}},
+		{"MultivariateNormalPrec.Logps", MultivariateNormalPrec{3},
+			[]float64{0., 1., 2.,
+				1., 0., 0., 0.3, 0.8, 0., -0.2, 0.4, 1.2,
+				0.5, 0.5, 0.5, -1., 2., 3.}},
+	})
+}
+
+func TestGradientsMatrix(t *testing.T) {
+	checkGradients(t, []gradientCase{
+		{"D.LogMvGamma", &callModel{1, func(_ []float64) {
+			D.LogMvGamma(0, 3)
+		}}, []float64{2.5}},
+		{"D.SqNormTriSolve", &matrixModel{2, func(m [][]float64) {
+			D.SqNormTriSolve(m[:2], m[2:])
+		}}, []float64{1.5, 0., 0.3, 0.8, 1., 0., -0.4, 0.6}},
+		{"LKJCholesky.Logp", LKJCholesky{2},
+			[]float64{2., 1., 0., 0.6, 0.8}},
+		{"LKJCholesky.Logps", LKJCholesky{3},
+			[]float64{0.5,
+				1., 0., 0., 0.3, 0.9539392014169457, 0.,
+				-0.2, 0.4, 0.8944271909999159,
+				1., 0., 0., -0.5, 0.8660254037844386, 0.,
+				0.1, 0.2, 0.9746794344808963}},
+		{"Wishart.Logp", Wishart{2},
+			[]float64{4.5, 2., 0., 0.5, 1.5, 1., 0., 0.3, 0.8}},
+		{"Wishart.Logps", Wishart{2},
+			[]float64{4.5, 2., 0., 0.5, 1.5,
+				1., 0., 0.3, 0.8, 1.5, 0., -0.4, 0.6}},
+		{"InverseWishart.Logp", InverseWishart{2},
+			[]float64{4.5, 2., 0., 0.5, 1.5, 1., 0., 0.3, 0.8}},
+		{"InverseWishart.Logps", InverseWishart{2},
+			[]float64{4.5, 2., 0., 0.5, 1.5,
+				1., 0., 0.3, 0.8, 1.5, 0., -0.4, 0.6}},
+		{"D.CholeskyCorr", &corrModel{3},
+			[]float64{1.5, 0.3, -0.2, 0.4}},
+	})
+}
@@ -12,11 +12,11 @@ import (
 // Common constants
 
 var (
-	logpi, log2pi float64
+	log2, logpi, log2pi float64
 )
 
 func init() {
-	log2 := math.Log(2)
+	log2 = math.Log(2)
 	logpi = math.Log(math.Pi)
 	log2pi = log2 + logpi
 }
@@ -95,6 +95,141 @@ func (cauchy) Logps(x0, gamma float64, y ...float64) float64 {
 	return lp
 }
 
+// Student's t distribution
+type studentT struct{}
+
+// Student's t distribution, singleton instance
+var StudentT studentT
+
+// Observe implements the Model interface. The parameter
+// vector is nu, mu, sigma, observations.
+func (dist studentT) Observe(x []float64) float64 {
+	nu, mu, sigma, y := x[0], x[1], x[2], x[3:]
+	if len(y) == 1 {
+		return dist.Logp(nu, mu, sigma, y[0])
+	} else {
+		return dist.Logps(nu, mu, sigma, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (studentT) Logp(nu, mu, sigma float64, y float64) float64 {
+	d := (y - mu) / sigma
+	return mathx.LogGamma(0.5*(nu+1)) - mathx.LogGamma(0.5*nu) -
+		0.5*(math.Log(nu)+logpi) - math.Log(sigma) -
+		0.5*(nu+1)*math.Log(1+d*d/nu)
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (studentT) Logps(nu, mu, sigma float64, y ...float64) float64 {
+	lp := (mathx.LogGamma(0.5*(nu+1)) - mathx.LogGamma(0.5*nu) -
+		0.5*(math.Log(nu)+logpi) - math.Log(sigma)) * float64(len(y))
+	for i := range y {
+		d := (y[i] - mu) / sigma
+		lp -= 0.5 * (nu + 1) * math.Log(1+d*d/nu)
+	}
+	return lp
+}
+
+// Laplace distribution
+type laplace struct{}
+
+// Laplace distribution, singleton instance
+var Laplace laplace
+
+// Observe implements the Model interface. The parameter
+// vector is mu, b, observations.
+func (dist laplace) Observe(x []float64) float64 {
+	mu, b, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(mu, b, y[0])
+	} else {
+		return dist.Logps(mu, b, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (laplace) Logp(mu, b float64, y float64) float64 {
+	return -math.Log(2*b) - math.Abs(y-mu)/b
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (laplace) Logps(mu, b float64, y ...float64) float64 {
+	lp := -math.Log(2*b) * float64(len(y))
+	for i := range y {
+		lp -= math.Abs(y[i]-mu) / b
+	}
+	return lp
+}
+
+// Logistic distribution
+type logistic struct{}
+
+// Logistic distribution, singleton instance
+var Logistic logistic
+
+// Observe implements the Model interface. The parameter
+// vector is mu, s, observations.
+func (dist logistic) Observe(x []float64) float64 {
+	mu, s, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(mu, s, y[0])
+	} else {
+		return dist.Logps(mu, s, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (logistic) Logp(mu, s float64, y float64) float64 {
+	// The density is symmetric, the absolute value keeps
+	// the exponent in LogDSigm from overflowing.
+	z := math.Abs(y-mu) / s
+	return mathx.LogDSigm(-z) - math.Log(s)
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (logistic) Logps(mu, s float64, y ...float64) float64 {
+	lp := -math.Log(s) * float64(len(y))
+	for i := range y {
+		z := math.Abs(y[i]-mu) / s
+		lp += mathx.LogDSigm(-z)
+	}
+	return lp
+}
+
+// Gumbel distribution (of the maximum)
+type gumbel struct{}
+
+// Gumbel distribution, singleton instance
+var Gumbel gumbel
+
+// Observe implements the Model interface. The parameter
+// vector is mu, beta, observations.
+func (dist gumbel) Observe(x []float64) float64 {
+	mu, beta, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(mu, beta, y[0])
+	} else {
+		return dist.Logps(mu, beta, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (gumbel) Logp(mu, beta float64, y float64) float64 {
+	z := (y - mu) / beta
+	return -math.Log(beta) - z - math.Exp(-z)
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (gumbel) Logps(mu, beta float64, y ...float64) float64 {
+	lp := -math.Log(beta) * float64(len(y))
+	for i := range y {
+		z := (y[i] - mu) / beta
+		lp -= z + math.Exp(-z)
+	}
+	return lp
+}
+
 // Non-negative distributions
 
 // Exponential distribution
@@ -164,6 +299,219 @@ func (gamma) Logps(alpha, beta float64, y ...float64) float64 {
 	return lp
 }
 
+// Log-normal distribution
+type logNormal struct{}
+
+// Log-normal distribution, singleton instance
+var LogNormal logNormal
+
+// Observe implements the Model interface. The parameter
+// vector is mu, sigma, observations.
+func (dist logNormal) Observe(x []float64) float64 {
+	mu, sigma, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(mu, sigma, y[0])
+	} else {
+		return dist.Logps(mu, sigma, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (logNormal) Logp(mu, sigma float64, y float64) float64 {
+	vari := sigma * sigma
+	logv := math.Log(vari)
+	logy := math.Log(y)
+	d := logy - mu
+	return -0.5*(d*d/vari+logv+log2pi) - logy
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (logNormal) Logps(mu, sigma float64, y ...float64) float64 {
+	vari := sigma * sigma
+	logv := math.Log(vari)
+	lp := -0.5 * (logv + log2pi) * float64(len(y))
+	for i := range y {
+		logy := math.Log(y[i])
+		d := logy - mu
+		lp -= 0.5*d*d/vari + logy
+	}
+	return lp
+}
+
+// Inverse gamma distribution
+type inverseGamma struct{}
+
+// Inverse gamma distribution, singleton instance
+var InverseGamma inverseGamma
+
+// Observe implements the Model interface. The parameter
+// vector is alpha, beta, observations.
+func (dist inverseGamma) Observe(x []float64) float64 {
+	alpha, beta, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(alpha, beta, y[0])
+	} else {
+		return dist.Logps(alpha, beta, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (inverseGamma) Logp(alpha, beta float64, y float64) float64 {
+	return -(alpha+1)*math.Log(y) - beta/y -
+		mathx.LogGamma(alpha) + alpha*math.Log(beta)
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (inverseGamma) Logps(alpha, beta float64, y ...float64) float64 {
+	lp := (-mathx.LogGamma(alpha) +
+		alpha*math.Log(beta)) * float64(len(y))
+	for i := range y {
+		lp -= (alpha+1)*math.Log(y[i]) + beta/y[i]
+	}
+	return lp
+}
+
+// Weibull distribution
+type weibull struct{}
+
+// Weibull distribution, singleton instance
+var Weibull weibull
+
+// Observe implements the Model interface. The parameter
+// vector is k, lambda, observations.
+func (dist weibull) Observe(x []float64) float64 {
+	k, lambda, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(k, lambda, y[0])
+	} else {
+		return dist.Logps(k, lambda, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (weibull) Logp(k, lambda float64, y float64) float64 {
+	z := y / lambda
+	return math.Log(k) - math.Log(lambda) +
+		(k-1)*math.Log(z) - math.Pow(z, k)
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (weibull) Logps(k, lambda float64, y ...float64) float64 {
+	lp := (math.Log(k) - math.Log(lambda)) * float64(len(y))
+	for i := range y {
+		z := y[i] / lambda
+		lp += (k-1)*math.Log(z) - math.Pow(z, k)
+	}
+	return lp
+}
+
+// Half-normal distribution
+type halfNormal struct{}
+
+// Half-normal distribution, singleton instance
+var HalfNormal halfNormal
+
+// Observe implements the Model interface. The parameter
+// vector is sigma, observations.
+func (dist halfNormal) Observe(x []float64) float64 {
+	sigma, y := x[0], x[1:]
+	if len(y) == 1 {
+		return dist.Logp(sigma, y[0])
+	} else {
+		return dist.Logps(sigma, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (halfNormal) Logp(sigma float64, y float64) float64 {
+	vari := sigma * sigma
+	logv := math.Log(vari)
+	return -0.5*(y*y/vari+logv+log2pi) + log2
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (halfNormal) Logps(sigma float64, y ...float64) float64 {
+	vari := sigma * sigma
+	logv := math.Log(vari)
+	lp := (-0.5*(logv+log2pi) + log2) * float64(len(y))
+	for i := range y {
+		lp -= 0.5 * y[i] * y[i] / vari
+	}
+	return lp
+}
+
+// Half-Cauchy distribution
+type halfCauchy struct{}
+
+// Half-Cauchy distribution, singleton instance
+var HalfCauchy halfCauchy
+
+// Observe implements the Model interface. The parameter
+// vector is gamma, observations.
+func (dist halfCauchy) Observe(x []float64) float64 {
+	gamma, y := x[0], x[1:]
+	if len(y) == 1 {
+		return dist.Logp(gamma, y[0])
+	} else {
+		return dist.Logps(gamma, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (halfCauchy) Logp(gamma float64, y float64) float64 {
+	logGamma := math.Log(gamma)
+	d := y / gamma
+	return -logGamma - logpi + log2 - math.Log(1+d*d)
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (halfCauchy) Logps(gamma float64, y ...float64) float64 {
+	logGamma := math.Log(gamma)
+	lp := (-logGamma - logpi + log2) * float64(len(y))
+	for i := range y {
+		d := y[i] / gamma
+		lp -= math.Log(1 + d*d)
+	}
+	return lp
+}
+
+// Half-Student's t distribution
+type halfStudentT struct{}
+
+// Half-Student's t distribution, singleton instance
+var HalfStudentT halfStudentT
+
+// Observe implements the Model interface. The parameter
+// vector is nu, sigma, observations.
+func (dist halfStudentT) Observe(x []float64) float64 {
+	nu, sigma, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(nu, sigma, y[0])
+	} else {
+		return dist.Logps(nu, sigma, y...)
+	}
+}
+
+// Logp computes the log pdf of a single observation.
+func (halfStudentT) Logp(nu, sigma float64, y float64) float64 {
+	d := y / sigma
+	return mathx.LogGamma(0.5*(nu+1)) - mathx.LogGamma(0.5*nu) -
+		0.5*(math.Log(nu)+logpi) - math.Log(sigma) + log2 -
+		0.5*(nu+1)*math.Log(1+d*d/nu)
+}
+
+// Logps computes the log pdf of a vector of observations.
+func (halfStudentT) Logps(nu, sigma float64, y ...float64) float64 {
+	lp := (mathx.LogGamma(0.5*(nu+1)) - mathx.LogGamma(0.5*nu) -
+		0.5*(math.Log(nu)+logpi) - math.Log(sigma) + log2) *
+		float64(len(y))
+	for i := range y {
+		d := y[i] / sigma
+		lp -= 0.5 * (nu + 1) * math.Log(1+d*d/nu)
+	}
+	return lp
+}
+
 // Bounded distributions
 
 // Beta distribution
@@ -294,6 +642,544 @@ func (dist Dirichlet) LogZ(alpha []float64) float64 {
 	return sumLogGammaAlpha - mathx.LogGamma(sumAlpha)
 }
 
+// Multivariate distributions
+
+// Multivariate normal distribution, parameterized by the mean
+// and the lower-triangular Cholesky factor of the covariance
+type MultivariateNormal struct {
+	N int // number of dimensions
+}
+
+// Multivariate normal distribution, singleton instance; Observe
+// cannot be called on this instance, but Logp and Logps can.
+var MvNormal MultivariateNormal
+
+// Observe implements the Model interface. The parameters are
+// mu, the Cholesky factor row by row, and observations,
+// flattened. Only the lower triangle of the factor is used.
+func (dist MultivariateNormal) Observe(x []float64) float64 {
+	mu := x[:dist.N]
+	l := make([][]float64, dist.N)
+	for i := range l {
+		l[i] = x[dist.N*(i+1) : dist.N*(i+2)]
+	}
+	x = x[dist.N*(dist.N+1):]
+	if len(x) == dist.N {
+		return dist.Logp(mu, l, x)
+	} else {
+		ys := make([][]float64, len(x)/dist.N)
+		for i := range ys {
+			ys[i] = x[dist.N*i : dist.N*(i+1)]
+		}
+		return dist.Logps(mu, l, ys...)
+	}
+}
+
+// Logp computes log pdf of a single observation.
+func (dist MultivariateNormal) Logp(
+	mu []float64, l [][]float64, y []float64,
+) float64 {
+	return -0.5*(float64(len(y))*log2pi+dist.Mahalanobis(mu, l, y)) -
+		dist.LogDet(l)
+}
+
+// Logps computes log pdf of a vector of observations.
+func (dist MultivariateNormal) Logps(
+	mu []float64, l [][]float64, y ...[]float64,
+) float64 {
+	lp := -(0.5*float64(len(mu))*log2pi + dist.LogDet(l)) *
+		float64(len(y))
+	for i := range y {
+		lp -= 0.5 * dist.Mahalanobis(mu, l, y[i])
+	}
+	return lp
+}
+
+// Mahalanobis computes the squared Mahalanobis distance
+// between y and mu, solving Lz = y - mu by forward
+// substitution.
+func (dist MultivariateNormal) Mahalanobis(
+	mu []float64, l [][]float64, y []float64,
+) float64 {
+	z := make([]float64, len(y))
+	d2 := 0.
+	for i := range y {
+		z[i] = y[i] - mu[i]
+		for j := 0; j != i; j++ {
+			z[i] -= l[i][j] * z[j]
+		}
+		z[i] /= l[i][i]
+		d2 += z[i] * z[i]
+	}
+	return d2
+}
+
+// LogDet computes the log-determinant of the Cholesky factor,
+// half the log-determinant of the covariance.
+func (dist MultivariateNormal) LogDet(l [][]float64) float64 {
+	diag := make([]float64, len(l))
+	for i := range l {
+		diag[i] = l[i][i]
+	}
+	return mathx.LogDet(diag)
+}
+
+// Multivariate normal distribution, parameterized by the mean
+// and the lower-triangular Cholesky factor of the precision
+type MultivariateNormalPrec struct {
+	N int // number of dimensions
+}
+
+// Multivariate normal distribution in precision form, singleton
+// instance; Observe cannot be called on this instance, but Logp
+// and Logps can.
+var MvNormalPrec MultivariateNormalPrec
+
+// Observe implements the Model interface. The parameters are
+// mu, the Cholesky factor row by row, and observations,
+// flattened. Only the lower triangle of the factor is used.
+func (dist MultivariateNormalPrec) Observe(x []float64) float64 {
+	mu := x[:dist.N]
+	u := make([][]float64, dist.N)
+	for i := range u {
+		u[i] = x[dist.N*(i+1) : dist.N*(i+2)]
+	}
+	x = x[dist.N*(dist.N+1):]
+	if len(x) == dist.N {
+		return dist.Logp(mu, u, x)
+	} else {
+		ys := make([][]float64, len(x)/dist.N)
+		for i := range ys {
+			ys[i] = x[dist.N*i : dist.N*(i+1)]
+		}
+		return dist.Logps(mu, u, ys...)
+	}
+}
+
+// Logp computes log pdf of a single observation.
+func (dist MultivariateNormalPrec) Logp(
+	mu []float64, u [][]float64, y []float64,
+) float64 {
+	return -0.5*(float64(len(y))*log2pi+dist.Mahalanobis(mu, u, y)) +
+		MvNormal.LogDet(u)
+}
+
+// Logps computes log pdf of a vector of observations.
+func (dist MultivariateNormalPrec) Logps(
+	mu []float64, u [][]float64, y ...[]float64,
+) float64 {
+	lp := (-0.5*float64(len(mu))*log2pi + MvNormal.LogDet(u)) *
+		float64(len(y))
+	for i := range y {
+		lp -= 0.5 * dist.Mahalanobis(mu, u, y[i])
+	}
+	return lp
+}
+
+// Mahalanobis computes the squared Mahalanobis distance
+// between y and mu as the squared norm of U'(y - mu).
+func (dist MultivariateNormalPrec) Mahalanobis(
+	mu []float64, u [][]float64, y []float64,
+) float64 {
+	d2 := 0.
+	for j := range y {
+		w := 0.
+		for i := j; i != len(y); i++ {
+			w += u[i][j] * (y[i] - mu[i])
+		}
+		d2 += w * w
+	}
+	return d2
+}
+
+// LKJ distribution of Cholesky factors of correlation
+// matrices (https://doi.org/10.1016/j.jmva.2009.04.008)
+type LKJCholesky struct {
+	N int // number of dimensions
+}
+
+// LKJ distribution, singleton instance; Observe cannot be
+// called on this instance, but Logp and Logps can.
+var LKJ LKJCholesky
+
+// Observe implements the Model interface. The parameters are
+// eta and observations, the Cholesky factors row by row,
+// flattened.
+func (dist LKJCholesky) Observe(x []float64) float64 {
+	eta := x[0]
+	ls := make([][][]float64, (len(x)-1)/(dist.N*dist.N))
+	for i := range ls {
+		ls[i] = make([][]float64, dist.N)
+		for j := range ls[i] {
+			k := 1 + dist.N*(dist.N*i+j)
+			ls[i][j] = x[k : k+dist.N]
+		}
+	}
+	if len(ls) == 1 {
+		return dist.Logp(eta, ls[0])
+	} else {
+		return dist.Logps(eta, ls...)
+	}
+}
+
+// Logp computes log pdf of a single observation.
+func (dist LKJCholesky) Logp(eta float64, l [][]float64) float64 {
+	n := len(l)
+	lp := -dist.LogZ(eta, n)
+	for i := 1; i != n; i++ {
+		lp += (float64(n-i-3) + 2*eta) * math.Log(l[i][i])
+	}
+	return lp
+}
+
+// Logps computes log pdf of a vector of observations.
+func (dist LKJCholesky) Logps(eta float64, l ...[][]float64) float64 {
+	lp := 0.
+	if len(l) > 0 {
+		lp = -dist.LogZ(eta, len(l[0])) * float64(len(l))
+	}
+	for k := range l {
+		n := len(l[k])
+		for i := 1; i != n; i++ {
+			lp += (float64(n-i-3) + 2*eta) * math.Log(l[k][i][i])
+		}
+	}
+	return lp
+}
+
+// LogZ computes the normalization constant for n dimensions.
+func (dist LKJCholesky) LogZ(eta float64, n int) float64 {
+	logz := 0.
+	for k := 1; k != n; k++ {
+		m := float64(n - k)
+		b := eta + 0.5*(m-1)
+		logz += (2*eta-2+m)*m*log2 +
+			m*(2*mathx.LogGamma(b)-mathx.LogGamma(2*b))
+	}
+	return logz
+}
+
+// Wishart distribution of W = CC', parameterized by the degrees
+// of freedom nu and the lower-triangular Cholesky factor L of
+// the scale matrix; observations are Cholesky factors C
+type Wishart struct {
+	N int // number of dimensions
+}
+
+// Wishart distribution, singleton instance; Observe cannot be
+// called on this instance, but Logp and Logps can.
+var Wish Wishart
+
+// Observe implements the Model interface. The parameters are
+// nu, L and observations, the matrices row by row, flattened.
+func (dist Wishart) Observe(x []float64) float64 {
+	nu := x[0]
+	ms := make([][][]float64, (len(x)-1)/(dist.N*dist.N))
+	for i := range ms {
+		ms[i] = make([][]float64, dist.N)
+		for j := range ms[i] {
+			k := 1 + dist.N*(dist.N*i+j)
+			ms[i][j] = x[k : k+dist.N]
+		}
+	}
+	if len(ms) == 2 {
+		return dist.Logp(nu, ms[0], ms[1])
+	} else {
+		return dist.Logps(nu, ms[0], ms[1:]...)
+	}
+}
+
+// Logp computes log pdf of a single observation.
+func (dist Wishart) Logp(nu float64, l [][]float64, c [][]float64) float64 {
+	n := float64(len(l))
+	return (nu-n-1)*MvNormal.LogDet(c) - 0.5*D.SqNormTriSolve(l, c) -
+		dist.LogZ(nu, l)
+}
+
+// Logps computes log pdf of a vector of observations.
+func (dist Wishart) Logps(nu float64, l [][]float64, c ...[][]float64) float64 {
+	n := float64(len(l))
+	lp := -dist.LogZ(nu, l) * float64(len(c))
+	for i := range c {
+		lp += (nu-n-1)*MvNormal.LogDet(c[i]) -
+			0.5*D.SqNormTriSolve(l, c[i])
+	}
+	return lp
+}
+
+// LogZ computes the normalization constant.
+func (dist Wishart) LogZ(nu float64, l [][]float64) float64 {
+	n := len(l)
+	return nu*MvNormal.LogDet(l) + 0.5*nu*float64(n)*log2 +
+		D.LogMvGamma(0.5*nu, n)
+}
+
+// Inverse Wishart distribution of W = CC', parameterized by the
+// degrees of freedom nu and the lower-triangular Cholesky
+// factor L of the scale matrix; observations are Cholesky
+// factors C
+type InverseWishart struct {
+	N int // number of dimensions
+}
+
+// Inverse Wishart distribution, singleton instance; Observe
+// cannot be called on this instance, but Logp and Logps can.
+var InvWish InverseWishart
+
+// Observe implements the Model interface. The parameters are
+// nu, L and observations, the matrices row by row, flattened.
+func (dist InverseWishart) Observe(x []float64) float64 {
+	nu := x[0]
+	ms := make([][][]float64, (len(x)-1)/(dist.N*dist.N))
+	for i := range ms {
+		ms[i] = make([][]float64, dist.N)
+		for j := range ms[i] {
+			k := 1 + dist.N*(dist.N*i+j)
+			ms[i][j] = x[k : k+dist.N]
+		}
+	}
+	if len(ms) == 2 {
+		return dist.Logp(nu, ms[0], ms[1])
+	} else {
+		return dist.Logps(nu, ms[0], ms[1:]...)
+	}
+}
+
+// Logp computes log pdf of a single observation.
+func (dist InverseWishart) Logp(
+	nu float64, l [][]float64, c [][]float64,
+) float64 {
+	n := float64(len(l))
+	return -(nu+n+1)*MvNormal.LogDet(c) - 0.5*D.SqNormTriSolve(c, l) -
+		dist.LogZ(nu, l)
+}
+
+// Logps computes log pdf of a vector of observations.
+func (dist InverseWishart) Logps(
+	nu float64, l [][]float64, c ...[][]float64,
+) float64 {
+	n := float64(len(l))
+	lp := -dist.LogZ(nu, l) * float64(len(c))
+	for i := range c {
+		lp -= (nu+n+1)*MvNormal.LogDet(c[i]) +
+			0.5*D.SqNormTriSolve(c[i], l)
+	}
+	return lp
+}
+
+// LogZ computes the normalization constant.
+func (dist InverseWishart) LogZ(nu float64, l [][]float64) float64 {
+	n := len(l)
+	return -nu*MvNormal.LogDet(l) + 0.5*nu*float64(n)*log2 +
+		D.LogMvGamma(0.5*nu, n)
+}
+
+// Count distributions
+
+// Poisson distribution
+type poisson struct{}
+
+// Poisson distribution, singleton instance
+var Poisson poisson
+
+// Observe implements the Model interface. The parameter
+// vector is lambda, observations.
+func (dist poisson) Observe(x []float64) float64 {
+	lambda, y := x[0], x[1:]
+	if len(y) == 1 {
+		return dist.Logp(lambda, y[0])
+	} else {
+		return dist.Logps(lambda, y...)
+	}
+}
+
+// Logp computes the log pmf of a single observation.
+func (poisson) Logp(lambda float64, y float64) float64 {
+	return y*math.Log(lambda) - lambda - mathx.LogGamma(y+1)
+}
+
+// Logps computes the log pmf of a vector of observations.
+func (poisson) Logps(lambda float64, y ...float64) float64 {
+	logl := math.Log(lambda)
+	lp := -lambda * float64(len(y))
+	for i := range y {
+		lp += y[i]*logl - mathx.LogGamma(y[i]+1)
+	}
+	return lp
+}
+
+// Negative binomial distribution, parameterized by the mean mu
+// and the dispersion phi; the variance is mu + mu^2/phi.
+type negativeBinomial struct{}
+
+// Negative binomial distribution, singleton instance
+var NegativeBinomial negativeBinomial
+
+// Observe implements the Model interface. The parameter
+// vector is mu, phi, observations.
+func (dist negativeBinomial) Observe(x []float64) float64 {
+	mu, phi, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(mu, phi, y[0])
+	} else {
+		return dist.Logps(mu, phi, y...)
+	}
+}
+
+// Logp computes the log pmf of a single observation.
+func (negativeBinomial) Logp(mu, phi float64, y float64) float64 {
+	logmuphi := math.Log(mu + phi)
+	return mathx.LogGamma(y+phi) - mathx.LogGamma(y+1) -
+		mathx.LogGamma(phi) +
+		phi*(math.Log(phi)-logmuphi) + y*(math.Log(mu)-logmuphi)
+}
+
+// Logps computes the log pmf of a vector of observations.
+func (negativeBinomial) Logps(mu, phi float64, y ...float64) float64 {
+	logmuphi := math.Log(mu + phi)
+	logmu := math.Log(mu)
+	lp := (-mathx.LogGamma(phi) +
+		phi*(math.Log(phi)-logmuphi)) * float64(len(y))
+	for i := range y {
+		lp += mathx.LogGamma(y[i]+phi) - mathx.LogGamma(y[i]+1) +
+			y[i]*(logmu-logmuphi)
+	}
+	return lp
+}
+
+// Geometric distribution of the number of failures before the
+// first success
+type geometric struct{}
+
+// Geometric distribution, singleton instance
+var Geometric geometric
+
+// Observe implements the Model interface. The parameter
+// vector is p, observations.
+func (dist geometric) Observe(x []float64) float64 {
+	p, y := x[0], x[1:]
+	if len(y) == 1 {
+		return dist.Logp(p, y[0])
+	} else {
+		return dist.Logps(p, y...)
+	}
+}
+
+// Logp computes the log pmf of a single observation.
+func (geometric) Logp(p float64, y float64) float64 {
+	return y*math.Log(1-p) + math.Log(p)
+}
+
+// Logps computes the log pmf of a vector of observations.
+func (geometric) Logps(p float64, y ...float64) float64 {
+	log1p := math.Log(1 - p)
+	lp := math.Log(p) * float64(len(y))
+	for i := range y {
+		lp += y[i] * log1p
+	}
+	return lp
+}
+
+// Zero-inflated Poisson distribution: zero with probability
+// pi, Poisson otherwise
+type zeroInflatedPoisson struct{}
+
+// Zero-inflated Poisson distribution, singleton instance
+var ZeroInflatedPoisson zeroInflatedPoisson
+
+// Observe implements the Model interface. The parameter
+// vector is pi, lambda, observations.
+func (dist zeroInflatedPoisson) Observe(x []float64) float64 {
+	pi, lambda, y := x[0], x[1], x[2:]
+	if len(y) == 1 {
+		return dist.Logp(pi, lambda, y[0])
+	} else {
+		return dist.Logps(pi, lambda, y...)
+	}
+}
+
+// Logp computes the log pmf of a single observation.
+func (zeroInflatedPoisson) Logp(pi, lambda float64, y float64) float64 {
+	if y < 0.5 {
+		return mathx.LogSumExp(math.Log(pi), math.Log(1-pi)-lambda)
+	} else {
+		return math.Log(1-pi) + Poisson.Logp(lambda, y)
+	}
+}
+
+// Logps computes the log pmf of a vector of observations.
+func (zeroInflatedPoisson) Logps(pi, lambda float64, y ...float64) float64 {
+	logz := math.Log(pi)
+	log1z := math.Log(1 - pi)
+	logl := math.Log(lambda)
+	lp := 0.
+	for i := range y {
+		if y[i] < 0.5 {
+			lp += mathx.LogSumExp(logz, log1z-lambda)
+		} else {
+			lp += log1z + y[i]*logl - lambda -
+				mathx.LogGamma(y[i]+1)
+		}
+	}
+	return lp
+}
+
+// Zero-inflated negative binomial distribution: zero with
+// probability pi, negative binomial otherwise
+type zeroInflatedNegativeBinomial struct{}
+
+// Zero-inflated negative binomial distribution, singleton
+// instance
+var ZeroInflatedNegativeBinomial zeroInflatedNegativeBinomial
+
+// Observe implements the Model interface. The parameter
+// vector is pi, mu, phi, observations.
+func (dist zeroInflatedNegativeBinomial) Observe(x []float64) float64 {
+	pi, mu, phi, y := x[0], x[1], x[2], x[3:]
+	if len(y) == 1 {
+		return dist.Logp(pi, mu, phi, y[0])
+	} else {
+		return dist.Logps(pi, mu, phi, y...)
+	}
+}
+
+// Logp computes the log pmf of a single observation.
+func (zeroInflatedNegativeBinomial) Logp(
+	pi, mu, phi float64, y float64,
+) float64 {
+	if y < 0.5 {
+		// The probability of zero under the negative binomial
+		// is (phi/(mu + phi))^phi.
+		lp0 := phi * (math.Log(phi) - math.Log(mu+phi))
+		return mathx.LogSumExp(math.Log(pi), math.Log(1-pi)+lp0)
+	} else {
+		return math.Log(1-pi) + NegativeBinomial.Logp(mu, phi, y)
+	}
+}
+
+// Logps computes the log pmf of a vector of observations.
+func (zeroInflatedNegativeBinomial) Logps(
+	pi, mu, phi float64, y ...float64,
+) float64 {
+	logz := math.Log(pi)
+	log1z := math.Log(1 - pi)
+	logmuphi := math.Log(mu + phi)
+	logmu := math.Log(mu)
+	lp0 := phi * (math.Log(phi) - logmuphi)
+	lgphi := mathx.LogGamma(phi)
+	lp := 0.
+	for i := range y {
+		if y[i] < 0.5 {
+			lp += mathx.LogSumExp(logz, log1z+lp0)
+		} else {
+			lp += log1z + mathx.LogGamma(y[i]+phi) -
+				mathx.LogGamma(y[i]+1) - lgphi + lp0 +
+				y[i]*(logmu-logmuphi)
+		}
+	}
+	return lp
+}
+
 // Choice distributions
 
 // Bernoulli distribution
@@ -456,3 +1342,74 @@ func (d) LogSumExp(x []float64) float64 {
 
 	return max + math.Log(sumExp)
 }
+
+// CholeskyCorr transforms unconstrained parameters x to the
+// lower-triangular Cholesky factor l of a correlation matrix,
+// and returns the log-Jacobian of the transformation. x holds
+// n(n-1)/2 elements, l is n by n. The elements of x are mapped
+// by tanh to canonical partial correlations, which fill the
+// strictly lower triangle row by row.
+func (d) CholeskyCorr(x []float64, l [][]float64) float64 {
+	n := len(l)
+	if len(x) != n*(n-1)/2 {
+		panic(fmt.Sprintf("wrong number of parameters: "+
+			"got len(x)=%v, want %v", len(x), n*(n-1)/2))
+	}
+
+	logj := 0.
+	l[0][0] = 1
+	for j := 1; j != n; j++ {
+		l[0][j] = 0
+	}
+	k := 0
+	for i := 1; i != n; i++ {
+		sumsq := 0.
+		for j := 0; j != i; j++ {
+			// tanh(x) = 2 Sigm(2x) - 1, and
+			// log(1 - tanh(x)^2) = log 4 + LogDSigm(2x);
+			// the absolute value keeps the exponent in
+			// LogDSigm from overflowing.
+			z := 2*mathx.Sigm(2*x[k]) - 1
+			logj += 2*log2 + mathx.LogDSigm(-2*math.Abs(x[k]))
+			logj += 0.5 * math.Log(1-sumsq)
+			l[i][j] = z * math.Sqrt(1-sumsq)
+			sumsq += l[i][j] * l[i][j]
+			k++
+		}
+		l[i][i] = math.Sqrt(1 - sumsq)
+		for j := i + 1; j != n; j++ {
+			l[i][j] = 0
+		}
+	}
+	return logj
+}
+
+// LogMvGamma computes the logarithm of the multivariate gamma
+// function of dimension n.
+func (d) LogMvGamma(a float64, n int) float64 {
+	lg := 0.25 * float64(n*(n-1)) * logpi
+	for j := 0; j != n; j++ {
+		lg += mathx.LogGamma(a - 0.5*float64(j))
+	}
+	return lg
+}
+
+// SqNormTriSolve computes the squared Frobenius norm of the
+// solution X of AX = B for lower-triangular A and B. Only the
+// lower triangles of A and B are used.
+func (d) SqNormTriSolve(a, b [][]float64) float64 {
+	n := len(a)
+	x := make([]float64, n) // a column of X
+	sqnorm := 0.
+	for j := 0; j != n; j++ {
+		for i := j; i != n; i++ {
+			x[i] = b[i][j]
+			for k := j; k != i; k++ {
+				x[i] -= a[i][k] * x[k]
+			}
+			x[i] /= a[i][i]
+			sqnorm += x[i] * x[i]
+		}
+	}
+	return sqnorm
+}
@@ -73,30 +73,955 @@ func TestCauchy(t *testing.T) {
 	}
 }
 
+func TestStudentT(t *testing.T) {
+	for _, c := range []struct {
+		nu, mu, sigma float64
+		y             []float64
+		lp            float64
+	}{
+		{1., 0., 1., []float64{0.}, -1.1447298858494004},
+		{3., 1., 2., []float64{2.}, -1.8541214455305277},
+		{10., 0., 0.5, []float64{-1., 0.}, -2.352097644598683},
+	} {
+		lp := StudentT.Logps(c.nu, c.mu, c.sigma, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.nu, c.mu, c.sigma, c.y, lp, c.lp)
+		}
+		lpo := StudentT.Observe(append([]float64{c.nu, c.mu, c.sigma}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.nu, c.mu, c.sigma, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := StudentT.Logp(c.nu, c.mu, c.sigma, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.nu, c.mu, c.sigma, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestLaplace(t *testing.T) {
+	for _, c := range []struct {
+		mu, b float64
+		y     []float64
+		lp    float64
+	}{
+		{0., 1., []float64{0.}, -0.6931471805599453},
+		{1., 2., []float64{2.}, -1.8862943611198906},
+		{0., 0.5, []float64{-1., 0.}, -2.},
+	} {
+		lp := Laplace.Logps(c.mu, c.b, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.b, c.y, lp, c.lp)
+		}
+		lpo := Laplace.Observe(append([]float64{c.mu, c.b}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.mu, c.b, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Laplace.Logp(c.mu, c.b, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.mu, c.b, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestLogistic(t *testing.T) {
+	for _, c := range []struct {
+		mu, s float64
+		y     []float64
+		lp    float64
+	}{
+		{0., 1., []float64{0.}, -1.3862943611198906},
+		{1., 2., []float64{2.}, -2.1413011489201588},
+		{0., 0.5, []float64{-1., 0.}, -2.2538560220859454},
+		{0., 1., []float64{-1000.}, -1000.},
+	} {
+		lp := Logistic.Logps(c.mu, c.s, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.s, c.y, lp, c.lp)
+		}
+		lpo := Logistic.Observe(append([]float64{c.mu, c.s}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.mu, c.s, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Logistic.Logp(c.mu, c.s, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.mu, c.s, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestGumbel(t *testing.T) {
+	for _, c := range []struct {
+		mu, beta float64
+		y        []float64
+		lp       float64
+	}{
+		{0., 1., []float64{0.}, -1.},
+		{1., 2., []float64{2.}, -1.7996778402725788},
+		{0., 0.5, []float64{-1., 0.}, -5.00276173781076},
+	} {
+		lp := Gumbel.Logps(c.mu, c.beta, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.beta, c.y, lp, c.lp)
+		}
+		lpo := Gumbel.Observe(append([]float64{c.mu, c.beta}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.mu, c.beta, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Gumbel.Logp(c.mu, c.beta, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.mu, c.beta, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
 func TestExponential(t *testing.T) {
 	for _, c := range []struct {
 		lambda float64
 		y      []float64
 		lp     float64
 	}{
-		{1., []float64{1.}, -1},
-		{2., []float64{2.}, -3.3068528194400546},
-		{1., []float64{1., 2.}, -3},
+		{1., []float64{1.}, -1},
+		{2., []float64{2.}, -3.3068528194400546},
+		{1., []float64{1., 2.}, -3},
+	} {
+		lp := Exponential.Logps(c.lambda, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.lambda, c.y, lp, c.lp)
+		}
+		lpo := Exponential.Observe(append([]float64{c.lambda}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.lambda, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Exponential.Logp(c.lambda, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.lambda, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestGamma(t *testing.T) {
+	for _, c := range []struct {
+		alpha, beta float64
+		y           []float64
+		lp          float64
+	}{
+		{1., 1., []float64{1.}, -1},
+		{2., 2., []float64{2.}, -1.9205584583201638},
+		{1., 1., []float64{2., 3.}, -5},
+	} {
+		lp := Gamma.Logps(c.alpha, c.beta, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v,%.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.beta, c.y, lp, c.lp)
+		}
+		lpo := Gamma.Observe(append([]float64{c.alpha, c.beta}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.beta, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Gamma.Logp(c.alpha, c.beta, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.beta, c.alpha, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestLogNormal(t *testing.T) {
+	for _, c := range []struct {
+		mu, sigma float64
+		y         []float64
+		lp        float64
+	}{
+		{0., 1., []float64{1.}, -0.9189385332046727},
+		{1., 2., []float64{2.}, -2.3170027259243517},
+		{0., 0.5, []float64{0.5, 3.}, -4.231851762859186},
+	} {
+		lp := LogNormal.Logps(c.mu, c.sigma, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.sigma, c.y, lp, c.lp)
+		}
+		lpo := LogNormal.Observe(append([]float64{c.mu, c.sigma}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.mu, c.sigma, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := LogNormal.Logp(c.mu, c.sigma, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.mu, c.sigma, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestInverseGamma(t *testing.T) {
+	for _, c := range []struct {
+		alpha, beta float64
+		y           []float64
+		lp          float64
+	}{
+		{1., 1., []float64{1.}, -1},
+		{2., 3., []float64{2.}, -1.3822169643436162},
+		{3., 1., []float64{0.5, 3.}, -5.3414881268858805},
+	} {
+		lp := InverseGamma.Logps(c.alpha, c.beta, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.beta, c.y, lp, c.lp)
+		}
+		lpo := InverseGamma.Observe(append([]float64{c.alpha, c.beta}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.beta, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := InverseGamma.Logp(c.alpha, c.beta, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.alpha, c.beta, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestWeibull(t *testing.T) {
+	for _, c := range []struct {
+		k, lambda float64
+		y         []float64
+		lp        float64
+	}{
+		{1., 1., []float64{1.}, -1},
+		{2., 3., []float64{2.}, -1.2553746606607734},
+		{0.5, 1., []float64{0.5, 3.}, -4.028184503929397},
+	} {
+		lp := Weibull.Logps(c.k, c.lambda, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.k, c.lambda, c.y, lp, c.lp)
+		}
+		lpo := Weibull.Observe(append([]float64{c.k, c.lambda}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.k, c.lambda, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Weibull.Logp(c.k, c.lambda, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.k, c.lambda, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestHalfNormal(t *testing.T) {
+	for _, c := range []struct {
+		sigma float64
+		y     []float64
+		lp    float64
+	}{
+		{1., []float64{0.}, -0.2257913526447274},
+		{2., []float64{1.}, -1.0439385332046727},
+		{0.5, []float64{0.5, 2.}, -7.565288344169565},
+	} {
+		lp := HalfNormal.Logps(c.sigma, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.sigma, c.y, lp, c.lp)
+		}
+		lpo := HalfNormal.Observe(append([]float64{c.sigma}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.sigma, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := HalfNormal.Logp(c.sigma, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.sigma, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestHalfCauchy(t *testing.T) {
+	for _, c := range []struct {
+		gamma float64
+		y     []float64
+		lp    float64
+	}{
+		{1., []float64{0.}, -0.4515827052894548},
+		{2.5, []float64{2.}, -1.862569678999717},
+		{5., []float64{1., 0.}, -4.161261948600391},
+	} {
+		lp := HalfCauchy.Logps(c.gamma, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.gamma, c.y, lp, c.lp)
+		}
+		lpo := HalfCauchy.Observe(append([]float64{c.gamma}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.gamma, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := HalfCauchy.Logp(c.gamma, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.gamma, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestHalfStudentT(t *testing.T) {
+	for _, c := range []struct {
+		nu, sigma float64
+		y         []float64
+		lp        float64
+	}{
+		{1., 1., []float64{0.}, -0.4515827052894552},
+		{3., 2., []float64{2.}, -1.5762529945270711},
+		{10., 0.5, []float64{1., 0.}, -0.9658032834787923},
+	} {
+		lp := HalfStudentT.Logps(c.nu, c.sigma, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.nu, c.sigma, c.y, lp, c.lp)
+		}
+		lpo := HalfStudentT.Observe(append([]float64{c.nu, c.sigma}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.nu, c.sigma, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := HalfStudentT.Logp(c.nu, c.sigma, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.nu, c.sigma, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestBeta(t *testing.T) {
+	for _, c := range []struct {
+		alpha, beta float64
+		y           []float64
+		lp          float64
+	}{
+		{1., 1., []float64{0.5}, 0},
+		{2., 3., []float64{.25}, 0.523248143764548},
+		{3., 1., []float64{0.3, 0.6}, -1.2323722788476341},
+	} {
+		lp := Beta.Logps(c.alpha, c.beta, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v,%.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.beta, c.y, lp, c.lp)
+		}
+		lpo := Beta.Observe(append([]float64{c.alpha, c.beta}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.beta, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Beta.Logp(c.alpha, c.beta, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.alpha, c.beta, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestBinomial(t *testing.T) {
+	for _, c := range []struct {
+		n, p float64
+		y    []float64
+		lp   float64
+	}{
+		{1., 0.5, []float64{1.}, -0.6931471805599453},
+		{5, 0.3, []float64{3}, -2.022683207861227},
+		{10, 0.7, []float64{3, 8}, -6.1650253897071146},
+	} {
+		lp := Binomial.Logps(c.n, c.p, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+				"got %.4g, want %.4g",
+				c.n, c.p, c.y, lp, c.lp)
+		}
+		lpo := Binomial.Observe(append([]float64{c.n, c.p}, c.y...))
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+				"got %.4g, want %.4g",
+				c.n, c.p, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Binomial.Logp(c.n, c.p, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+					"got %.4g, want %.4g",
+					c.n, c.p, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestDirichlet(t *testing.T) {
+	for _, c := range []struct {
+		n     int
+		alpha []float64
+		y     [][]float64
+		lp    float64
+	}{
+		{
+			2,
+			[]float64{1., 1.},
+			[][]float64{{0.75, 0.25}},
+			0,
+		},
+		{
+			3,
+			[]float64{0.5, 1., 2.},
+			[][]float64{{0.1, 0.1, 0.8}},
+			1.5567576546051873,
+		},
+		{
+			2,
+			[]float64{3., 1.},
+			[][]float64{
+				{0.4, 0.6},
+				{0.6, 0.4},
+			},
+			-0.6570081339440723,
+		},
+	} {
+		lp := Dir.Logps(c.alpha, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%v, %v...): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.y, lp, c.lp)
+		}
+		dist := Dirichlet{c.n}
+		x := c.alpha
+		for _, y := range c.y {
+			x = append(x, y...)
+		}
+		lpo := dist.Observe(x)
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe(%v..., %v...): "+
+				"got %.4g, want %.4g",
+				c.alpha, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Dir.Logp(c.alpha, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%v, %v): "+
+					"got %.4g, want %.4g",
+					c.alpha, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestMultivariateNormal(t *testing.T) {
+	for _, c := range []struct {
+		n  int
+		mu []float64
+		l  [][]float64
+		y  [][]float64
+		lp float64
+	}{
+		{
+			2,
+			[]float64{0., 0.},
+			[][]float64{{1., 0.}, {0., 1.}},
+			[][]float64{{0., 0.}},
+			-1.8378770664093453,
+		},
+		{
+			2,
+			[]float64{1., -1.},
+			[][]float64{{2., 0.}, {0.5, 1.5}},
+			[][]float64{{0., 0.}},
+			-3.408711577299677,
+		},
+		{
+			3,
+			[]float64{0., 1., 2.},
+			[][]float64{
+				{1., 0., 0.},
+				{0.3, 0.8, 0.},
+				{-0.2, 0.4, 1.2},
+			},
+			[][]float64{
+				{0.5, 0.5, 0.5},
+				{-1., 2., 3.},
+			},
+			-8.116449015743083,
+		},
+	} {
+		lp := MvNormal.Logps(c.mu, c.l, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%v, %v, %v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.l, c.y, lp, c.lp)
+		}
+		dist := MultivariateNormal{c.n}
+		x := append([]float64{}, c.mu...)
+		for _, row := range c.l {
+			x = append(x, row...)
+		}
+		for _, y := range c.y {
+			x = append(x, y...)
+		}
+		lpo := dist.Observe(x)
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe(%v..., %v..., %v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.l, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := MvNormal.Logp(c.mu, c.l, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%v, %v, %v): "+
+					"got %.4g, want %.4g",
+					c.mu, c.l, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestMultivariateNormalPrec(t *testing.T) {
+	for _, c := range []struct {
+		n  int
+		mu []float64
+		u  [][]float64
+		y  [][]float64
+		lp float64
+	}{
+		{
+			2,
+			[]float64{0., 0.},
+			[][]float64{{1., 0.}, {0., 1.}},
+			[][]float64{{0., 0.}},
+			-1.8378770664093453,
+		},
+		// The precision is the inverse of the covariance in
+		// the second case of TestMultivariateNormal.
+		{
+			2,
+			[]float64{1., -1.},
+			[][]float64{
+				{0.5270462766947299, 0.},
+				{-0.21081851067789192, 0.6324555320336759},
+			},
+			[][]float64{{0., 0.}},
+			-3.408711577299677,
+		},
+		{
+			2,
+			[]float64{1., -1.},
+			[][]float64{{2., 0.}, {0.5, 1.5}},
+			[][]float64{
+				{0., 0.},
+				{1., 2.},
+			},
+			-14.97852955548247,
+		},
+	} {
+		lp := MvNormalPrec.Logps(c.mu, c.u, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%v, %v, %v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.u, c.y, lp, c.lp)
+		}
+		dist := MultivariateNormalPrec{c.n}
+		x := append([]float64{}, c.mu...)
+		for _, row := range c.u {
+			x = append(x, row...)
+		}
+		for _, y := range c.y {
+			x = append(x, y...)
+		}
+		lpo := dist.Observe(x)
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe(%v..., %v..., %v...): "+
+				"got %.4g, want %.4g",
+				c.mu, c.u, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := MvNormalPrec.Logp(c.mu, c.u, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%v, %v, %v): "+
+					"got %.4g, want %.4g",
+					c.mu, c.u, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+func TestLKJ(t *testing.T) {
+	l2 := [][]float64{{1., 0.}, {0.6, 0.8}}
+	l3 := [][]float64{
+		{1., 0., 0.},
+		{0.3, 0.9539392014169457, 0.},
+		{-0.2, 0.4, 0.8944271909999159},
+	}
+	for _, c := range []struct {
+		n   int
+		eta float64
+		y   [][][]float64
+		lp  float64
+	}{
+		// For two dimensions and eta = 1, the correlation is
+		// uniform on [-1, 1].
+		{2, 1., [][][]float64{l2}, -0.6931471805599453},
+		{2, 2., [][][]float64{l2}, -0.7339691750801998},
+		{3, 1., [][][]float64{l3}, -1.6434679308744755},
+		{3, 0.5, [][][]float64{l3}, -2.4194524713121868},
+		{3, 2., [][][]float64{l3, l3}, 2 * -0.980092908648203},
+	} {
+		lp := LKJ.Logps(c.eta, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%v, %v...): "+
+				"got %.4g, want %.4g",
+				c.eta, c.y, lp, c.lp)
+		}
+		dist := LKJCholesky{c.n}
+		x := []float64{c.eta}
+		for _, y := range c.y {
+			for _, row := range y {
+				x = append(x, row...)
+			}
+		}
+		lpo := dist.Observe(x)
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe(%v, %v...): "+
+				"got %.4g, want %.4g",
+				c.eta, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := LKJ.Logp(c.eta, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%v, %v): "+
+					"got %.4g, want %.4g",
+					c.eta, c.y[0], lp1, lp)
+			}
+		}
+	}
+}
+
+// The density of the LKJ distribution, transformed by
+// CholeskyCorr, must integrate to 1 over the unconstrained
+// parameters.
+func TestLKJNormalization(t *testing.T) {
+	for _, c := range []struct {
+		n     int
+		eta   float64
+		bound float64 // of integration
+		h     float64 // step of integration
+	}{
+		{2, 0.5, 10., 0.05},
+		{2, 1., 10., 0.05},
+		{2, 3., 10., 0.05},
+		{3, 1., 6., 0.2},
+		{3, 2., 6., 0.2},
+	} {
+		m := c.n * (c.n - 1) / 2
+		l := make([][]float64, c.n)
+		for i := range l {
+			l[i] = make([]float64, c.n)
+		}
+		// Integrate by the midpoint rule on a grid.
+		k := int(2 * c.bound / c.h)
+		x := make([]float64, m)
+		ix := make([]int, m)
+		z := 0.
+		for {
+			for i := range x {
+				x[i] = -c.bound + c.h*(float64(ix[i])+0.5)
+			}
+			logj := D.CholeskyCorr(x, l)
+			z += math.Exp(LKJ.Logp(c.eta, l)+logj) *
+				math.Pow(c.h, float64(m))
+			i := 0
+			for ; i != m; i++ {
+				ix[i]++
+				if ix[i] != k {
+					break
+				}
+				ix[i] = 0
+			}
+			if i == m {
+				break
+			}
+		}
+		if math.Abs(z-1) > 1e-3 {
+			t.Errorf("LKJ(%d, %.4g) is not normalized: "+
+				"integral is %.6g", c.n, c.eta, z)
+		}
+	}
+}
+
+func TestWishart(t *testing.T) {
+	s2 := [][]float64{{2., 0.}, {0.5, 1.5}}
+	w2 := [][]float64{{1., 0.}, {0.3, 0.8}}
+	for _, c := range []struct {
+		n  int
+		nu float64
+		l  [][]float64
+		y  [][][]float64
+		lp float64
+	}{
+		{
+			2,
+			3.,
+			[][]float64{{1., 0.}, {0., 1.}},
+			[][][]float64{{{1., 0.}, {0., 1.}}},
+			-3.5310242469692903,
+		},
+		{2, 4.5, s2, [][][]float64{w2}, -9.27824625307195},
+		{
+			3,
+			5.,
+			[][]float64{
+				{1., 0., 0.},
+				{0.3, 0.8, 0.},
+				{-0.2, 0.4, 1.2},
+			},
+			[][][]float64{{
+				{2., 0., 0.},
+				{0.5, 1., 0.},
+				{0.1, -0.3, 0.7},
+			}},
+			-9.825475439922208,
+		},
+		{
+			2,
+			4.5,
+			s2,
+			[][][]float64{w2, {{1.5, 0.}, {-0.4, 0.6}}},
+			-18.606762397103772,
+		},
+	} {
+		lp := Wish.Logps(c.nu, c.l, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%v, %v, %v...): "+
+				"got %.4g, want %.4g",
+				c.nu, c.l, c.y, lp, c.lp)
+		}
+		dist := Wishart{c.n}
+		x := []float64{c.nu}
+		for _, m := range append([][][]float64{c.l}, c.y...) {
+			for _, row := range m {
+				x = append(x, row...)
+			}
+		}
+		lpo := dist.Observe(x)
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe(%v, %v, %v...): "+
+				"got %.4g, want %.4g",
+				c.nu, c.l, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := Wish.Logp(c.nu, c.l, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%v, %v, %v): "+
+					"got %.4g, want %.4g",
+					c.nu, c.l, c.y[0], lp1, lp)
+			}
+		}
+	}
+
+	// In one dimension, W ~ Gamma(nu/2, 1/(2s^2)).
+	for _, nu := range []float64{1., 2.5, 4.} {
+		s, w := 1.5, 0.7
+		lp := Wish.Logp(nu, [][]float64{{s}}, [][]float64{{math.Sqrt(w)}})
+		lpg := Gamma.Logp(0.5*nu, 0.5/(s*s), w)
+		if math.Abs(lp-lpg) > 1e-6 {
+			t.Errorf("Wrong one-dimensional Logp(%v, %v, %v): "+
+				"got %.4g, want %.4g", nu, s, w, lp, lpg)
+		}
+	}
+}
+
+func TestInverseWishart(t *testing.T) {
+	s2 := [][]float64{{2., 0.}, {0.5, 1.5}}
+	w2 := [][]float64{{1., 0.}, {0.3, 0.8}}
+	for _, c := range []struct {
+		n  int
+		nu float64
+		l  [][]float64
+		y  [][][]float64
+		lp float64
+	}{
+		{
+			2,
+			3.,
+			[][]float64{{1., 0.}, {0., 1.}},
+			[][][]float64{{{1., 0.}, {0., 1.}}},
+			-3.5310242469692903,
+		},
+		{2, 4.5, s2, [][][]float64{w2}, -0.8802909154532989},
+		{
+			3,
+			5.,
+			[][]float64{
+				{1., 0., 0.},
+				{0.3, 0.8, 0.},
+				{-0.2, 0.4, 1.2},
+			},
+			[][][]float64{{
+				{2., 0., 0.},
+				{0.5, 1., 0.},
+				{0.1, -0.3, 0.7},
+			}},
+			-12.701908397595393,
+		},
+		{
+			2,
+			4.5,
+			s2,
+			[][][]float64{w2, {{1.5, 0.}, {-0.4, 0.6}}},
+			-4.375243178576388,
+		},
+	} {
+		lp := InvWish.Logps(c.nu, c.l, c.y...)
+		if math.Abs(lp-c.lp) > 1e-6 {
+			t.Errorf("Wrong logpdf of Logps(%v, %v, %v...): "+
+				"got %.4g, want %.4g",
+				c.nu, c.l, c.y, lp, c.lp)
+		}
+		dist := InverseWishart{c.n}
+		x := []float64{c.nu}
+		for _, m := range append([][][]float64{c.l}, c.y...) {
+			for _, row := range m {
+				x = append(x, row...)
+			}
+		}
+		lpo := dist.Observe(x)
+		if math.Abs(lp-lpo) > 1e-6 {
+			t.Errorf("Wrong result of Observe(%v, %v, %v...): "+
+				"got %.4g, want %.4g",
+				c.nu, c.l, c.y, lpo, lp)
+		}
+		if len(c.y) == 1 {
+			lp1 := InvWish.Logp(c.nu, c.l, c.y[0])
+			if math.Abs(lp-lp1) > 1e-6 {
+				t.Errorf("Wrong result of Logp(%v, %v, %v): "+
+					"got %.4g, want %.4g",
+					c.nu, c.l, c.y[0], lp1, lp)
+			}
+		}
+	}
+
+	// In one dimension, W ~ InverseGamma(nu/2, psi/2).
+	for _, nu := range []float64{1., 2.5, 4.} {
+		psi, w := 1.5, 0.7
+		lp := InvWish.Logp(nu, [][]float64{{math.Sqrt(psi)}},
+			[][]float64{{math.Sqrt(w)}})
+		lpg := InverseGamma.Logp(0.5*nu, 0.5*psi, w)
+		if math.Abs(lp-lpg) > 1e-6 {
+			t.Errorf("Wrong one-dimensional Logp(%v, %v, %v): "+
+				"got %.4g, want %.4g", nu, psi, w, lp, lpg)
+		}
+	}
+}
+
+func TestPoisson(t *testing.T) {
+	for _, c := range []struct {
+		lambda float64
+		y      []float64
+		lp     float64
+	}{
+		{1., []float64{0.}, -1.},
+		{2.5, []float64{3.}, -1.54288727360559},
+		{4., []float64{1., 5.}, -4.469725576062703},
 	} {
-		lp := Exponential.Logps(c.lambda, c.y...)
+		lp := Poisson.Logps(c.lambda, c.y...)
 		if math.Abs(lp-c.lp) > 1e-6 {
-			t.Errorf("Wrong logpdf of Logps(%.v, %.v...): "+
+			t.Errorf("Wrong logpmf of Logps(%.v, %.v...): "+
 				"got %.4g, want %.4g",
 				c.lambda, c.y, lp, c.lp)
 		}
-		lpo := Exponential.Observe(append([]float64{c.lambda}, c.y...))
+		lpo := Poisson.Observe(append([]float64{c.lambda}, c.y...))
 		if math.Abs(lp-lpo) > 1e-6 {
 			t.Errorf("Wrong result of Observe([%.4g, %v...]): "+
 				"got %.4g, want %.4g",
 				c.lambda, c.y, lpo, lp)
 		}
 		if len(c.y) == 1 {
-			lp1 := Exponential.Logp(c.lambda, c.y[0])
+			lp1 := Poisson.Logp(c.lambda, c.y[0])
 			if math.Abs(lp-lp1) > 1e-6 {
 				t.Errorf("Wrong result of Logp(%.4g, %.4g): "+
 					"got %.4g, want %.4g",
@@ -106,157 +1031,133 @@ func TestExponential(t *testing.T) {
 	}
 }
 
-func TestGamma(t *testing.T) {
+func TestNegativeBinomial(t *testing.T) {
 	for _, c := range []struct {
-		alpha, beta float64
-		y           []float64
-		lp          float64
+		mu, phi float64
+		y       []float64
+		lp      float64
 	}{
-		{1., 1., []float64{1.}, -1},
-		{2., 2., []float64{2.}, -1.9205584583201638},
-		{1., 1., []float64{2., 3.}, -5},
+		{1., 1., []float64{0.}, -0.6931471805599453},
+		{2.5, 3., []float64{3.}, -1.8811943988097108},
+		{4., 0.5, []float64{1., 5.}, -4.999112689922497},
 	} {
-		lp := Gamma.Logps(c.alpha, c.beta, c.y...)
+		lp := NegativeBinomial.Logps(c.mu, c.phi, c.y...)
 		if math.Abs(lp-c.lp) > 1e-6 {
-			t.Errorf("Wrong logpdf of Logps(%.v,%.v, %.v...): "+
+			t.Errorf("Wrong logpmf of Logps(%.v, %.v, %.v...): "+
 				"got %.4g, want %.4g",
-				c.alpha, c.beta, c.y, lp, c.lp)
+				c.mu, c.phi, c.y, lp, c.lp)
 		}
-		lpo := Gamma.Observe(append([]float64{c.alpha, c.beta}, c.y...))
+		lpo := NegativeBinomial.Observe(append([]float64{c.mu, c.phi}, c.y...))
 		if math.Abs(lp-lpo) > 1e-6 {
 			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
 				"got %.4g, want %.4g",
-				c.alpha, c.beta, c.y, lpo, lp)
+				c.mu, c.phi, c.y, lpo, lp)
 		}
 		if len(c.y) == 1 {
-			lp1 := Gamma.Logp(c.alpha, c.beta, c.y[0])
+			lp1 := NegativeBinomial.Logp(c.mu, c.phi, c.y[0])
 			if math.Abs(lp-lp1) > 1e-6 {
 				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
 					"got %.4g, want %.4g",
-					c.beta, c.alpha, c.y[0], lp1, lp)
+					c.mu, c.phi, c.y[0], lp1, lp)
 			}
 		}
 	}
 }
 
-func TestBeta(t *testing.T) {
+func TestGeometric(t *testing.T) {
 	for _, c := range []struct {
-		alpha, beta float64
-		y           []float64
-		lp          float64
+		p  float64
+		y  []float64
+		lp float64
 	}{
-		{1., 1., []float64{0.5}, 0},
-		{2., 3., []float64{.25}, 0.523248143764548},
-		{3., 1., []float64{0.3, 0.6}, -1.2323722788476341},
+		{0.5, []float64{0.}, -0.6931471805599453},
+		{0.2, []float64{3.}, -2.2788685663767296},
+		{0.7, []float64{1., 5.}, -7.93718671383308},
 	} {
-		lp := Beta.Logps(c.alpha, c.beta, c.y...)
+		lp := Geometric.Logps(c.p, c.y...)
 		if math.Abs(lp-c.lp) > 1e-6 {
-			t.Errorf("Wrong logpdf of Logps(%.v,%.v, %.v...): "+
+			t.Errorf("Wrong logpmf of Logps(%.v, %.v...): "+
 				"got %.4g, want %.4g",
-				c.alpha, c.beta, c.y, lp, c.lp)
+				c.p, c.y, lp, c.lp)
 		}
-		lpo := Beta.Observe(append([]float64{c.alpha, c.beta}, c.y...))
+		lpo := Geometric.Observe(append([]float64{c.p}, c.y...))
 		if math.Abs(lp-lpo) > 1e-6 {
-			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
+			t.Errorf("Wrong result of Observe([%.4g, %v...]): "+
 				"got %.4g, want %.4g",
-				c.alpha, c.beta, c.y, lpo, lp)
+				c.p, c.y, lpo, lp)
 		}
 		if len(c.y) == 1 {
-			lp1 := Beta.Logp(c.alpha, c.beta, c.y[0])
+			lp1 := Geometric.Logp(c.p, c.y[0])
 			if math.Abs(lp-lp1) > 1e-6 {
-				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
+				t.Errorf("Wrong result of Logp(%.4g, %.4g): "+
 					"got %.4g, want %.4g",
-					c.alpha, c.beta, c.y[0], lp1, lp)
+					c.p, c.y[0], lp1, lp)
 			}
 		}
 	}
 }
 
-func TestBinomial(t *testing.T) {
+func TestZeroInflatedPoisson(t *testing.T) {
 	for _, c := range []struct {
-		n, p float64
-		y    []float64
-		lp   float64
+		pi, lambda float64
+		y          []float64
+		lp         float64
 	}{
-		{1., 0.5, []float64{1.}, -0.6931471805599453},
-		{5, 0.3, []float64{3}, -2.022683207861227},
-		{10, 0.7, []float64{3, 8}, -6.1650253897071146},
+		{0.3, 1., []float64{0.}, -0.5842647781563713},
+		{0.3, 2.5, []float64{3.}, -1.8995622175443225},
+		{0.1, 4., []float64{0., 1., 5.}, -6.830447317978553},
 	} {
-		lp := Binomial.Logps(c.n, c.p, c.y...)
+		lp := ZeroInflatedPoisson.Logps(c.pi, c.lambda, c.y...)
 		if math.Abs(lp-c.lp) > 1e-6 {
-			t.Errorf("Wrong logpdf of Logps(%.v, %.v, %.v...): "+
+			t.Errorf("Wrong logpmf of Logps(%.v, %.v, %.v...): "+
 				"got %.4g, want %.4g",
-				c.n, c.p, c.y, lp, c.lp)
+				c.pi, c.lambda, c.y, lp, c.lp)
 		}
-		lpo := Binomial.Observe(append([]float64{c.n, c.p}, c.y...))
+		lpo := ZeroInflatedPoisson.Observe(append([]float64{c.pi, c.lambda}, c.y...))
 		if math.Abs(lp-lpo) > 1e-6 {
 			t.Errorf("Wrong result of Observe([%.4g, %.4g, %v...]): "+
 				"got %.4g, want %.4g",
-				c.n, c.p, c.y, lpo, lp)
+				c.pi, c.lambda, c.y, lpo, lp)
 		}
 		if len(c.y) == 1 {
-			lp1 := Binomial.Logp(c.n, c.p, c.y[0])
+			lp1 := ZeroInflatedPoisson.Logp(c.pi, c.lambda, c.y[0])
 			if math.Abs(lp-lp1) > 1e-6 {
 				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g): "+
 					"got %.4g, want %.4g",
-					c.n, c.p, c.y[0], lp1, lp)
+					c.pi, c.lambda, c.y[0], lp1, lp)
 			}
 		}
 	}
 }
 
-func TestDirichlet(t *testing.T) {
+func TestZeroInflatedNegativeBinomial(t *testing.T) {
 	for _, c := range []struct {
-		n     int
-		alpha []float64
-		y     [][]float64
-		lp    float64
+		pi, mu, phi float64
+		y           []float64
+		lp          float64
 	}{
-		{
-			2,
-			[]float64{1., 1.},
-			[][]float64{{0.75, 0.25}},
-			0,
-		},
-		{
-			3,
-			[]float64{0.5, 1., 2.},
-			[][]float64{{0.1, 0.1, 0.8}},
-			1.5567576546051873,
-		},
-		{
-			2,
-			[]float64{3., 1.},
-			[][]float64{
-				{0.4, 0.6},
-				{0.6, 0.4},
-			},
-			-0.6570081339440723,
-		},
+		{0.3, 1., 1., []float64{0.}, -0.4307829160924544},
+		{0.3, 2.5, 3., []float64{3.}, -2.2378693427484433},
+		{0.1, 4., 0.5, []float64{0., 1., 5.}, -6.126124453112305},
 	} {
-		lp := Dir.Logps(c.alpha, c.y...)
+		lp := ZeroInflatedNegativeBinomial.Logps(c.pi, c.mu, c.phi, c.y...)
 		if math.Abs(lp-c.lp) > 1e-6 {
-			t.Errorf("Wrong logpdf of Logps(%v, %v...): "+
+			t.Errorf("Wrong logpmf of Logps(%.v, %.v, %.v, %.v...): "+
 				"got %.4g, want %.4g",
-				c.alpha, c.y, lp, c.lp)
-		}
-		dist := Dirichlet{c.n}
-		x := c.alpha
-		for _, y := range c.y {
-			x = append(x, y...)
+				c.pi, c.mu, c.phi, c.y, lp, c.lp)
 		}
-		lpo := dist.Observe(x)
+		lpo := ZeroInflatedNegativeBinomial.Observe(append([]float64{c.pi, c.mu, c.phi}, c.y...))
 		if math.Abs(lp-lpo) > 1e-6 {
-			t.Errorf("Wrong result of Observe(%v..., %v...): "+
+			t.Errorf("Wrong result of Observe([%.4g, %.4g, %.4g, %v...]): "+
 				"got %.4g, want %.4g",
-				c.alpha, c.y, lpo, lp)
+				c.pi, c.mu, c.phi, c.y, lpo, lp)
 		}
 		if len(c.y) == 1 {
-			lp1 := Dir.Logp(c.alpha, c.y[0])
+			lp1 := ZeroInflatedNegativeBinomial.Logp(c.pi, c.mu, c.phi, c.y[0])
 			if math.Abs(lp-lp1) > 1e-6 {
-				t.Errorf("Wrong result of Logp(%v, %v): "+
+				t.Errorf("Wrong result of Logp(%.4g, %.4g, %.4g, %.4g): "+
 					"got %.4g, want %.4g",
-					c.alpha, c.y[0], lp1, lp)
+					c.pi, c.mu, c.phi, c.y[0], lp1, lp)
 			}
 		}
 	}
@@ -428,3 +1329,88 @@ func TestLogSumExp(t *testing.T) {
 		}
 	}
 }
+
+func TestCholeskyCorr(t *testing.T) {
+	for _, c := range []struct {
+		x    []float64
+		l    [][]float64
+		logj float64
+	}{
+		{
+			[]float64{},
+			[][]float64{{1.}},
+			0.,
+		},
+		{
+			[]float64{0.},
+			[][]float64{{1., 0.}, {0., 1.}},
+			0.,
+		},
+		{
+			[]float64{0.6931471805599453},
+			[][]float64{{1., 0.}, {0.6, 0.8}},
+			-0.4462871026284195,
+		},
+		{
+			[]float64{
+				0.30951960420311175,
+				-0.2027325540540822,
+				0.43350736324528266,
+			},
+			[][]float64{
+				{1., 0., 0.},
+				{0.3, 0.9539392014169457, 0.},
+				{-0.2, 0.4, 0.8944271909999159},
+			},
+			-0.3378652280455787,
+		},
+	} {
+		l := make([][]float64, len(c.l))
+		for i := range l {
+			l[i] = make([]float64, len(c.l))
+		}
+		logj := D.CholeskyCorr(c.x, l)
+		if math.Abs(logj-c.logj) > 1e-6 {
+			t.Errorf("Wrong log-Jacobian of CholeskyCorr(%v): "+
+				"got %.6g, want %.6g", c.x, logj, c.logj)
+		}
+	rows:
+		for i := range l {
+			for j := range l[i] {
+				if math.Abs(l[i][j]-c.l[i][j]) > 1e-6 {
+					t.Errorf("Wrong result of CholeskyCorr(%v): "+
+						"got %v, want %v", c.x, l, c.l)
+					break rows
+				}
+			}
+		}
+	}
+}
+
+func TestLogMvGamma(t *testing.T) {
+	for _, c := range []struct {
+		a  float64
+		n  int
+		lg float64
+	}{
+		{2.5, 1, 0.2846828704729196},
+		{1.5, 2, 0.45158270528945466},
+		{3., 3, 2.694924879806965},
+	} {
+		lg := D.LogMvGamma(c.a, c.n)
+		if math.Abs(lg-c.lg) > 1e-6 {
+			t.Errorf("Wrong result of LogMvGamma(%v, %v): "+
+				"got %.6g, want %.6g", c.a, c.n, lg, c.lg)
+		}
+	}
+}
+
+func TestSqNormTriSolve(t *testing.T) {
+	a := [][]float64{{2., 0.}, {1., 4.}}
+	b := [][]float64{{4., 0.}, {6., 8.}}
+	// X = [[2, 0], [1, 2]]
+	if sq := D.SqNormTriSolve(a, b); math.Abs(sq-9) > 1e-12 {
+		t.Errorf("Wrong result of SqNormTriSolve(%v, %v): "+
+			"got %.6g, want 9", a, b, sq)
+	}
+}
@@ -24,10 +24,10 @@ var (
 	NADPT = 10
 	DEPTH = 3.
 	RATE  = 0.01
+	SEED  = int64(0)
 )
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
 	flag.Usage = func() {
 		log.Printf(`Gaussian mixture model:
 		gmm [OPTIONS]` + "\n")
@@ -41,11 +41,16 @@ func init() {
 		"number of steps per adaptation")
 	flag.Float64Var(&DEPTH, "depth", DEPTH, "target NUTS tree depth")
 	flag.Float64Var(&RATE, "rate", RATE, "adaptation rate")
+	flag.Int64Var(&SEED, "seed", SEED, "random seed, time-based if 0")
 	log.SetFlags(0)
 }
 
 func main() {
 	flag.Parse()
+	if SEED == 0 {
+		SEED = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(SEED))
 	if NBURN == 0 {
 		NBURN = NITER
 	}
@@ -104,12 +109,13 @@ func main() {
 	}
 	// Add some noise for a real-life experience.
 	for k := 0; k != len(x); k++ {
-		x[k] += 0.1 * rand.NormFloat64()
+		x[k] += 0.1 * rng.NormFloat64()
 	}
 
 	// Let's infer the posterior with NUTS.
 	nuts := &infer.NUTS{
-		Eps: STEP / math.Sqrt(float64(len(m.Data))),
+		Sampler: infer.Sampler{Rng: rng},
+		Eps:     STEP / math.Sqrt(float64(len(m.Data))),
 	}
 	samples := make(chan []float64)
 	nuts.Sample(m, x, samples)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bitbucket.org/dtolpin/infergo/diag"
 	. "bitbucket.org/dtolpin/infergo/examples/gmm/model/ad"
 	"bitbucket.org/dtolpin/infergo/infer"
 	"encoding/csv"
@@ -24,10 +25,10 @@ var (
 	STEP  = 0.5
 	NBURN = 0
 	NITER = 100
+	SEED  = int64(0)
 )
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
 	flag.Usage = func() {
 		log.Printf(`Gaussian mixture model:
 		gmm [OPTIONS]` + "\n")
@@ -43,11 +44,16 @@ func init() {
 	flag.Float64Var(&STEP, "step", STEP, "NUTS step")
 	flag.IntVar(&NBURN, "nburn", NBURN, "number of burn-in iterations")
 	flag.IntVar(&NITER, "niter", NITER, "number of iterations")
+	flag.Int64Var(&SEED, "seed", SEED, "random seed, time-based if 0")
 	log.SetFlags(0)
 }
 
 func main() {
 	flag.Parse()
+	if SEED == 0 {
+		SEED = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(SEED))
 	if NBURN == 0 {
 		NBURN = NITER
 	}
@@ -124,7 +130,8 @@ func main() {
 
 	// Now let's infer the posterior with NUTS.
 	nuts := &infer.NUTS{
-		Eps: STEP / math.Sqrt(float64(len(m.Data))),
+		Sampler: infer.Sampler{Rng: rng},
+		Eps:     STEP / math.Sqrt(float64(len(m.Data))),
 	}
 	samples := make(chan []float64)
 	nuts.Sample(m, x, samples)
@@ -136,29 +143,21 @@ func main() {
 	}
 
 	// Collect after burn-in
-	mean := make([]float64, m.NComp)
-	stddev := make([]float64, m.NComp)
-	n := 0.
-	for i := 0; i != NITER; i++ {
-		x := <-samples
-		if len(x) == 0 {
-			break
-		}
+	draws := diag.Collect(samples, NITER)
+	nuts.Stop()
+	for _, x := range draws {
 		for j := 0; j != m.NComp; j++ {
-			mean[j] += x[2*j]
-			stddev[j] += math.Exp(x[2*j+1])
+			x[2*j+1] = math.Exp(x[2*j+1])
 		}
-		n++
 	}
-	for j := 0; j != m.NComp; j++ {
-		mean[j] /= n
-		stddev[j] /= n
-	}
-	nuts.Stop()
+	summaries := diag.SummarizeAll([][][]float64{draws})
 	log.Printf("Mean components:\n")
 	for j := 0; j != m.NComp; j++ {
-		log.Printf("\t%d: mean=%.4g, stddev=%.4g\n",
-			j, mean[j], stddev[j])
+		mean, stddev := summaries[2*j], summaries[2*j+1]
+		log.Printf("\t%d: mean=%.4g±%.2g, stddev=%.4g±%.2g, "+
+			"R-hat=%.4g, %.4g\n",
+			j, mean.Mean, mean.MCSE, stddev.Mean, stddev.MCSE,
+			mean.RHat, stddev.RHat)
 	}
 
 	log.Printf(`NUTS:
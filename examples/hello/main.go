@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bitbucket.org/dtolpin/infergo/diag"
 	. "bitbucket.org/dtolpin/infergo/examples/hello/model/ad"
 	"bitbucket.org/dtolpin/infergo/infer"
 	"encoding/csv"
@@ -23,10 +24,10 @@ var (
 	NITER  = 1000
 	NSTEPS = 10
 	STEP   = 0.5
+	SEED   = int64(0)
 )
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
 	flag.Usage = func() {
 		log.Printf(`Inferring parameters of the normal distribution:
 		hello [OPTIONS] [data.csv]` + "\n")
@@ -38,11 +39,16 @@ func init() {
 	flag.IntVar(&NITER, "niter", NITER, "number of iterations")
 	flag.IntVar(&NSTEPS, "nsteps", NSTEPS, "number of leapfrog steps")
 	flag.Float64Var(&STEP, "step", STEP, "leapfrog step size")
+	flag.Int64Var(&SEED, "seed", SEED, "random seed, time-based if 0")
 	log.SetFlags(0)
 }
 
 func main() {
 	flag.Parse()
+	if SEED == 0 {
+		SEED = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(SEED))
 
 	if flag.NArg() > 1 {
 		log.Fatalf("unexpected positional arguments: %v",
@@ -92,7 +98,7 @@ func main() {
 		s2/float64(len(m.Data)) - sampleMean*sampleMean)
 
 	// First estimate the maximum likelihood values.
-	x := []float64{0.5 * rand.NormFloat64(), 1 + 0.5*rand.NormFloat64()}
+	x := []float64{0.5 * rng.NormFloat64(), 1 + 0.5*rng.NormFloat64()}
 	ll := m.Observe(x)
 	printState := func(when string) {
 		log.Printf(`
@@ -124,12 +130,12 @@ func main() {
 
 	// Now let's infer the posterior with HMC.
 	hmc := &infer.HMC{
-		L:   NSTEPS,
-		Eps: STEP / math.Sqrt(float64(len(m.Data))),
+		Sampler: infer.Sampler{Rng: rng},
+		L:       NSTEPS,
+		Eps:     STEP / math.Sqrt(float64(len(m.Data))),
 	}
 	samples := make(chan []float64)
 	hmc.Sample(m, x, samples)
-	mean, stddev := 0., 0.
 
 	// Burn
 	for i := 0; i != NITER; i++ {
@@ -137,25 +143,24 @@ func main() {
 	}
 
 	// Collect after burn-in
-	n := 0.
-	for i := 0; i != NITER; i++ {
-		x := <-samples
-		if len(x) == 0 {
-			break
-		}
-		mean += x[0]
-		stddev += math.Exp(x[1])
-		n++
-	}
+	draws := diag.Collect(samples, NITER)
 	hmc.Stop()
-	x[0], x[1] = mean/n, math.Log(stddev/n)
+	for _, x := range draws {
+		x[1] = math.Exp(x[1])
+	}
+	summaries := diag.SummarizeAll([][][]float64{draws})
+	x[0], x[1] = summaries[0].Mean, math.Log(summaries[1].Mean)
 	ll = m.Observe(x)
 	printState("Posterior")
 	log.Printf(`HMC:
 	accepted: %d
 	rejected: %d
 	rate: %.4g
+	R-hat: %.4g, %.4g
+	ESS: %.4g, %.4g
 `,
 		hmc.NAcc, hmc.NRej,
-		float64(hmc.NAcc)/float64(hmc.NAcc+hmc.NRej))
+		float64(hmc.NAcc)/float64(hmc.NAcc+hmc.NRej),
+		summaries[0].RHat, summaries[1].RHat,
+		summaries[0].BulkESS, summaries[1].BulkESS)
 }
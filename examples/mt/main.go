@@ -4,6 +4,7 @@ import (
 	"bitbucket.org/dtolpin/infergo/ad"
 	. "bitbucket.org/dtolpin/infergo/examples/mt/model/ad"
 	"bitbucket.org/dtolpin/infergo/infer"
+	"context"
 	"encoding/csv"
 	"flag"
 	"io"
@@ -25,10 +26,10 @@ var (
 	NSTEPS = 10
 	STEP   = 0.5
 	NGO    = 2
+	SEED   = int64(0)
 )
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
 	flag.Usage = func() {
 		log.Printf(`Inferring parameters of the normal distribution:
 		mt [OPTIONS] [data.csv]` + "\n")
@@ -40,19 +41,18 @@ func init() {
 	flag.IntVar(&NITER, "niter", NITER, "number of iterations")
 	flag.IntVar(&NSTEPS, "nsteps", NSTEPS, "number of leapfrog steps")
 	flag.Float64Var(&STEP, "step", STEP, "leapfrog step size")
-	flag.IntVar(&NGO, "ngo", NGO, "number of inference goroutines")
+	flag.IntVar(&NGO, "ngo", NGO, "number of chains")
+	flag.Int64Var(&SEED, "seed", SEED, "random seed, time-based if 0")
 	log.SetFlags(0)
 	ad.MTSafeOn()
 }
 
 func main() {
 	flag.Parse()
-
-	if NGO > 1 && !ad.IsMTSafe() {
-		log.Printf("Multithreading is not supported, " +
-			"forcing -ngo 1.")
-		NGO = 1
+	if SEED == 0 {
+		SEED = time.Now().UnixNano()
 	}
+	rng := rand.New(rand.NewSource(SEED))
 
 	if flag.NArg() > 1 {
 		log.Fatalf("unexpected positional arguments: %v",
@@ -102,7 +102,7 @@ func main() {
 		s2/float64(len(m.Data)) - sampleMean*sampleMean)
 
 	// First estimate the maximum likelihood values.
-	x := []float64{0.5 * rand.NormFloat64(), 1 + 0.5*rand.NormFloat64()}
+	x := []float64{0.5 * rng.NormFloat64(), 1 + 0.5*rng.NormFloat64()}
 	ll := m.Observe(x)
 	printState := func(when string) {
 		log.Printf(`%s:
@@ -132,56 +132,42 @@ func main() {
 	printState("Maximum likelihood")
 
 	// Now let's infer the posterior with HMC.
-	igos := make(chan int, NGO)
-	finished := make(chan int, NGO)
-	for igo := 0; igo != NGO; igo++ {
-		igos <- igo
-		go func() {
-			igo := <-igos
+	result, err := infer.Run(context.Background(),
+		// Samplers are created in the order of chains, each
+		// chain is seeded from rng.
+		func() infer.MCMC {
 			hmc := &infer.HMC{
 				L:   NSTEPS,
 				Eps: STEP / math.Sqrt(float64(len(m.Data))),
 			}
-			samples := make(chan []float64)
-			hmc.Sample(m, x, samples)
-			// Burn
-			for i := 0; i != NITER; i++ {
-				<-samples
-			}
-
-			// Collect after burn-in
-			mean, stddev := 0., 0.
-			n := 0.
-			for i := 0; i != NITER; i++ {
-				x := <-samples
-				if len(x) == 0 {
-					break
-				}
-				mean += x[0]
-				stddev += math.Exp(x[1])
-				n++
-			}
-			hmc.Stop()
-			x[0], x[1] = mean/n, math.Log(stddev/n)
-			ll = m.Observe(x)
-			if NGO != 1 {
-				log.Printf("\nGoroutine %v:", igo+1)
-			}
-			printState("Posterior")
-			log.Printf(`HMC:
+			hmc.Rng = rand.New(rand.NewSource(rng.Int63()))
+			return hmc
+		},
+		m, [][]float64{x}, NGO, NITER, NITER)
+	if err != nil {
+		// A failed chain ends early, with too few draws to
+		// summarize.
+		log.Fatalf("cannot run HMC: %v", err)
+	}
+	for igo, chain := range result.Chains {
+		mean, stddev := 0., 0.
+		for _, x := range chain.Draws {
+			mean += x[0]
+			stddev += math.Exp(x[1])
+		}
+		n := float64(len(chain.Draws))
+		x[0], x[1] = mean/n, math.Log(stddev/n)
+		ll = m.Observe(x)
+		if NGO != 1 {
+			log.Printf("\nChain %v:", igo+1)
+		}
+		printState("Posterior")
+		log.Printf(`HMC:
 	accepted: %d
 	rejected: %d
 	rate: %.4g
 `,
-				hmc.NAcc, hmc.NRej,
-				float64(hmc.NAcc)/float64(hmc.NAcc+hmc.NRej))
-			finished <- igo
-		}()
-	}
-	for jgo := 0; jgo != NGO; jgo++ {
-		igo := <-finished
-		if NGO != 1 {
-			log.Printf("Goroutine %d finished.", igo+1)
-		}
+			chain.NAcc, chain.NRej,
+			float64(chain.NAcc)/float64(chain.NAcc+chain.NRej))
 	}
 }
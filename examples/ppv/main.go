@@ -30,10 +30,10 @@ var (
 	NITER = 100
 	NADPT = 10
 	DEPTH = 3.
+	SEED  = int64(0)
 )
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
 	flag.Usage = func() {
 		fmt.Printf(`Inferring best bandwidth. Usage:
 		goppv [OPTIONS]` + "\n")
@@ -50,11 +50,16 @@ func init() {
 	flag.IntVar(&NITER, "niter", NITER, "number of iterations")
 	flag.IntVar(&NADPT, "nadpt", NADPT, "number of steps per adaptation")
 	flag.Float64Var(&DEPTH, "depth", DEPTH, "target NUTS tree depth")
+	flag.Int64Var(&SEED, "seed", SEED, "random seed, time-based if 0")
 	log.SetFlags(0)
 }
 
 func main() {
 	flag.Parse()
+	if SEED == 0 {
+		SEED = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(SEED))
 	if NBURN == 0 {
 		NBURN = NITER
 	}
@@ -146,7 +151,8 @@ func main() {
 	if POST {
 		// Now let's infer the posterior with NUTS.
 		nuts := &infer.NUTS{
-			Eps: STEP,
+			Sampler: infer.Sampler{Rng: rng},
+			Eps:     STEP,
 		}
 		samples := make(chan []float64)
 		nuts.Sample(m, x, samples)
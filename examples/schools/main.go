@@ -22,6 +22,7 @@ var (
 	STAU      = 2.
 	SETA      = 2.
 	OPTIMIZER = "Adam"
+	SEED      = int64(0)
 )
 
 func init() {
@@ -37,11 +38,15 @@ func init() {
 	flag.Float64Var(&SETA, "seta", SETA, "sigma of eta priors")
 	flag.StringVar(&OPTIMIZER, "optimizer", OPTIMIZER,
 		"optimizer (Gradient, Momentum or Adam)")
-	rand.Seed(time.Now().UnixNano())
+	flag.Int64Var(&SEED, "seed", SEED, "random seed, time-based if 0")
 }
 
 func main() {
 	flag.Parse()
+	if SEED == 0 {
+		SEED = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(SEED))
 
 	if flag.NArg() > 0 {
 		fmt.Printf("unexpected positional arguments: %v",
@@ -60,10 +65,10 @@ func main() {
 	x := make([]float64, 2+m.J)
 
 	// Set a starting point
-	x[0] = rand.NormFloat64()
-	x[1] = rand.NormFloat64()
+	x[0] = rng.NormFloat64()
+	x[1] = rng.NormFloat64()
 	for i := 2; i != len(x); i++ {
-		x[i] = rand.NormFloat64()
+		x[i] = rng.NormFloat64()
 	}
 	// Compute log-likelihood of the starting point,
 	// for comparison.
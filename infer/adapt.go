@@ -48,21 +48,23 @@ func (da *DepthAdapter) Adapt(
 		if (i+1)%da.NAdpt == 0 {
 			t := float64(i / da.NAdpt)
 			Eps := nuts.Eps
+			eps := Eps
 			depth := nuts.MeanDepth()
 			if t == 0 {
 				// Guess initial value.
 				// Step is roughly inverse proportional to depth.
-				nuts.Eps *= depth / da.Depth
+				eps *= depth / da.Depth
 			} else {
 				grad := (da.Depth - depth) / da.Depth
 				if math.Abs(grad) < da.MinGrad {
 					break
 				}
 				gradSum += grad
-				nuts.Eps = da.Step(t, nuts.Eps, gradSum)
+				eps = da.Step(t, eps, gradSum)
 			}
+			nuts.setEps(&nuts.Eps, eps)
 			log.Printf("Adapting: depth: %.4g, step: %.4g => %.4g",
-				depth, Eps, nuts.Eps)
+				depth, Eps, eps)
 			if i+da.NAdpt < nIter {
 				nuts.Depth = nil // forget the depth
 			}
@@ -84,3 +86,251 @@ func (da *DepthAdapter) setDefaults() {
 		da.MinGrad = 0.01
 	}
 }
+
+// Parameters of step size adaptation to the target acceptance
+// statistic by dual averaging, as in Algorithm 5 of
+// https://arxiv.org/abs/1111.4246.
+type StepAdapter struct {
+	Target float64 // target acceptance statistic
+	Gamma  float64 // shrinkage towards the initial guess
+	T0     float64 // iteration offset
+	Kappa  float64 // decay rate of the averaged step
+	// State of dual averaging
+	mu, hbar, logEpsBar, t float64
+}
+
+// Adapt adapts the step size of an HMC variant to the target
+// acceptance statistic. At most nIter iterations are run. The
+// step size is set to the averaged step size at the end of
+// adaptation.
+func (sa *StepAdapter) Adapt(
+	h Hamiltonian,
+	samples <-chan []float64,
+	nIter int,
+) {
+	sa.setDefaults()
+	// Only the adapter writes the step size, and reads it
+	// without the lock. The acceptance statistic is that of
+	// the received sample.
+	a := h.tuning()
+	eps, _ := h.parameters()
+	sa.restart(*eps)
+	acc := 0.
+	for i := 0; i != nIter; i++ {
+		x := <-samples
+		if len(x) == 0 {
+			break
+		}
+		stat := a.acceptance(x)
+		acc += stat
+		a.setEps(eps, sa.update(stat))
+		if (i+1)%100 == 0 {
+			log.Printf("Adapting: acceptance: %.4g, step: %.4g",
+				acc/100, *eps)
+			acc = 0
+		}
+	}
+	a.setEps(eps, sa.final())
+	log.Printf("Adapted: step: %.4g", *eps)
+}
+
+// restart restarts dual averaging from step size eps.
+func (sa *StepAdapter) restart(eps float64) {
+	sa.mu = math.Log(10 * eps)
+	sa.hbar, sa.logEpsBar, sa.t = 0, math.Log(eps), 0
+}
+
+// update updates the state with acceptance statistic acc and
+// returns the next step size.
+func (sa *StepAdapter) update(acc float64) float64 {
+	sa.t++
+	eta := 1 / (sa.t + sa.T0)
+	sa.hbar = (1-eta)*sa.hbar + eta*(sa.Target-acc)
+	logEps := sa.mu - math.Sqrt(sa.t)/sa.Gamma*sa.hbar
+	w := math.Pow(sa.t, -sa.Kappa)
+	sa.logEpsBar = w*logEps + (1-w)*sa.logEpsBar
+	return math.Exp(logEps)
+}
+
+// final returns the averaged step size.
+func (sa *StepAdapter) final() float64 {
+	return math.Exp(sa.logEpsBar)
+}
+
+// setDefaults sets defaults for StepAdapter fields.
+func (sa *StepAdapter) setDefaults() {
+	if sa.Target == 0 {
+		sa.Target = 0.8
+	}
+	if sa.Gamma == 0 {
+		sa.Gamma = 0.05
+	}
+	if sa.T0 == 0 {
+		sa.T0 = 10
+	}
+	if sa.Kappa == 0 {
+		sa.Kappa = 0.75
+	}
+}
+
+// Parameters of windowed metric adaptation, after Stan. The
+// warm-up starts with an initial buffer, followed by slow
+// windows of doubling size, and ends with a terminal buffer.
+// At the end of each slow window, the metric is estimated from
+// the samples in the window, regularised towards the identity.
+// If Step is not nil, the step size is adapted along with the
+// metric, and the step size adaptation restarts whenever the
+// metric changes.
+type MetricAdapter struct {
+	Dense      bool         // dense rather than diagonal metric
+	InitBuffer int          // size of the initial buffer
+	TermBuffer int          // size of the terminal buffer
+	Window     int          // size of the first slow window
+	Step       *StepAdapter // step size adaptation, if not nil
+}
+
+// Adapt adapts the metric of an HMC variant during nIter
+// warm-up iterations.
+func (ma *MetricAdapter) Adapt(
+	h Hamiltonian,
+	samples <-chan []float64,
+	nIter int,
+) {
+	ma.setDefaults()
+	start, ends := ma.windows(nIter)
+	// Only the adapter writes the parameters, and reads them
+	// without the lock.
+	a := h.tuning()
+	eps, metric := h.parameters()
+	if ma.Step != nil {
+		ma.Step.setDefaults()
+		ma.Step.restart(*eps)
+	}
+	var (
+		n    float64     // number of samples in the window
+		mean []float64   // running mean
+		m2   [][]float64 // running sums of products
+	)
+	for i := 0; i != nIter; i++ {
+		x := <-samples
+		if len(x) == 0 {
+			break
+		}
+		if ma.Step != nil {
+			a.setEps(eps, ma.Step.update(a.acceptance(x)))
+		}
+		if len(ends) == 0 || i < start {
+			continue
+		}
+		if mean == nil {
+			mean = make([]float64, len(x))
+			m2 = make([][]float64, len(x))
+			for j := range m2 {
+				m2[j] = make([]float64, len(x))
+			}
+		}
+		// Welford's online update.
+		n++
+		dx := make([]float64, len(x))
+		for j := range x {
+			dx[j] = x[j] - mean[j]
+			mean[j] += dx[j] / n
+		}
+		for j := range x {
+			for k := range x {
+				if ma.Dense || j == k {
+					m2[j][k] += dx[j] * (x[k] - mean[k])
+				}
+			}
+		}
+		if i+1 != ends[0] {
+			continue
+		}
+
+		// The window is complete, estimate the metric.
+		ends = ends[1:]
+		w := n / (n + 5)
+		reg := 1e-3 * 5 / (n + 5)
+		if ma.Dense {
+			cov := make([][]float64, len(x))
+			for j := range cov {
+				cov[j] = make([]float64, len(x))
+				for k := range cov[j] {
+					cov[j][k] = w * m2[j][k] / (n - 1)
+				}
+				cov[j][j] += reg
+			}
+			dense, err := NewDenseMetric(cov)
+			if err != nil {
+				log.Printf("Adapting: metric not updated: %v", err)
+			} else {
+				a.setMetric(metric, dense)
+			}
+		} else {
+			diag := make(DiagMetric, len(x))
+			for j := range diag {
+				diag[j] = w*m2[j][j]/(n-1) + reg
+			}
+			a.setMetric(metric, diag)
+		}
+		log.Printf("Adapting: metric estimated from %d samples",
+			int(n))
+		if ma.Step != nil {
+			ma.Step.restart(*eps)
+		}
+		n = 0
+		for j := range mean {
+			mean[j] = 0
+			for k := range m2[j] {
+				m2[j][k] = 0
+			}
+		}
+	}
+	if ma.Step != nil {
+		a.setEps(eps, ma.Step.final())
+		log.Printf("Adapted: step: %.4g", *eps)
+	}
+}
+
+// windows returns the start of the first slow window and the
+// ends of the slow windows. If the buffers and the first window
+// do not fit into nIter iterations, they are shrunk to 15%,
+// 10%, and 75% of the iterations, respectively. Each window
+// is twice as long as the previous one; if the next window
+// would not fit, the current window extends to the terminal
+// buffer.
+func (ma *MetricAdapter) windows(nIter int) (start int, ends []int) {
+	init, term, window := ma.InitBuffer, ma.TermBuffer, ma.Window
+	if init+term+window > nIter {
+		init = nIter * 15 / 100
+		term = nIter / 10
+		window = nIter - init - term
+	}
+	if window < 2 {
+		// Too few iterations to estimate the metric.
+		return nIter, nil
+	}
+	last := nIter - term
+	for end := init + window; ; window *= 2 {
+		if end+2*window > last {
+			ends = append(ends, last)
+			break
+		}
+		ends = append(ends, end)
+		end += 2 * window
+	}
+	return init, ends
+}
+
+// setDefaults sets defaults for MetricAdapter fields.
+func (ma *MetricAdapter) setDefaults() {
+	if ma.InitBuffer == 0 {
+		ma.InitBuffer = 75
+	}
+	if ma.TermBuffer == 0 {
+		ma.TermBuffer = 50
+	}
+	if ma.Window == 0 {
+		ma.Window = 25
+	}
+}
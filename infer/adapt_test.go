@@ -3,6 +3,8 @@ package infer
 // Testing adaptation.
 
 import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
 	"math"
 	"testing"
 )
@@ -23,3 +25,208 @@ func TestDualAveraging(t *testing.T) {
 		}
 	}
 }
+
+func TestMetricWindows(t *testing.T) {
+	for _, c := range []struct {
+		nIter int
+		start int
+		ends  []int
+	}{
+		{1000, 75, []int{100, 150, 250, 450, 950}},
+		{200, 75, []int{100, 150}},
+		{100, 15, []int{90}},
+		{10, 1, []int{9}},
+		{1, 1, nil},
+	} {
+		ma := &MetricAdapter{}
+		ma.setDefaults()
+		start, ends := ma.windows(c.nIter)
+		if start != c.start && len(c.ends) != 0 ||
+			len(ends) != len(c.ends) {
+			t.Errorf("wrong windows for %d: got %d, %v, want %d, %v",
+				c.nIter, start, ends, c.start, c.ends)
+			continue
+		}
+		for i := range ends {
+			if ends[i] != c.ends[i] {
+				t.Errorf("wrong windows for %d: got %v, want %v",
+					c.nIter, ends, c.ends)
+				break
+			}
+		}
+	}
+}
+
+// A poorly scaled normal model, with standard deviations 10
+// and 0.1.
+type scaledModel struct{}
+
+func (m *scaledModel) Observe(x []float64) float64 {
+	ad.Setup(x)
+	var ll float64
+	ad.Assignment(&ll, ad.Arithmetic(ad.OpNeg,
+		ad.Arithmetic(ad.OpAdd,
+			ad.Arithmetic(ad.OpMul, ad.Value(0.005),
+				ad.Arithmetic(ad.OpMul, &x[0], &x[0])),
+			ad.Arithmetic(ad.OpMul, ad.Value(50),
+				ad.Arithmetic(ad.OpMul, &x[1], &x[1])))))
+	return ad.Return(&ll)
+}
+
+// The adapted metric approximates the posterior variance.
+func TestMetricAdapter(t *testing.T) {
+	variance := []float64{100, 0.01}
+	for _, dense := range []bool{false, true} {
+		if !repeatedly(5,
+			func() bool {
+				nuts := &NUTS{Eps: 0.05, MaxDepth: 10}
+				samples := make(chan []float64)
+				nuts.Sample(&scaledModel{}, []float64{1, 0.1}, samples)
+				ma := &MetricAdapter{Dense: dense}
+				ma.Adapt(nuts, samples, 500)
+				nuts.Stop()
+				for i := range variance {
+					var v float64
+					switch metric := nuts.Metric.(type) {
+					case DiagMetric:
+						v = metric[i]
+					case *DenseMetric:
+						v = metric.Minv[i][i]
+					default:
+						t.Fatalf("wrong metric type: %T", metric)
+					}
+					if math.Abs(math.Log(v/variance[i])) > 0.5 {
+						return false
+					}
+				}
+				return true
+			},
+			true) {
+			t.Errorf("metric was not adapted (dense: %v)", dense)
+		}
+	}
+}
+
+// Acceptance statistics are paired with the samples, in the
+// order of sending, and the statistics of samples never
+// received are dropped.
+func TestAcceptanceRecord(t *testing.T) {
+	a := &adaptation{}
+	samples := make(chan []float64, 1)
+	x, y := []float64{1}, []float64{2}
+	// A rejected sample is sent twice.
+	a.record(x, 0.1, samples)
+	a.record(x, 0.2, samples)
+	a.record(y, 0.3, samples)
+	for i, c := range []struct {
+		x   []float64
+		acc float64
+	}{
+		{x, 0.1},
+		{x, 0.2},
+		{y, 0.3},
+		{y, 0},
+	} {
+		if acc := a.acceptance(c.x); acc != c.acc {
+			t.Errorf("%d: wrong acceptance: got %v, want %v",
+				i, acc, c.acc)
+		}
+	}
+	// At most cap(samples)+2 statistics are kept.
+	for i := 0; i != 4; i++ {
+		a.record([]float64{float64(i)}, 1, samples)
+	}
+	if len(a.order) != 3 || len(a.accs) != 3 {
+		t.Errorf("wrong number of statistics: got %d, want 3",
+			len(a.order))
+	}
+}
+
+// Dual averaging finds the step size for which the acceptance
+// statistic is on target.
+func TestStepAdapterUpdate(t *testing.T) {
+	sa := &StepAdapter{}
+	sa.setDefaults()
+	eps := 1.
+	sa.restart(eps)
+	for i := 0; i != 2000; i++ {
+		// The acceptance decreases with the step size.
+		eps = sa.update(math.Exp(-eps))
+	}
+	want := -math.Log(sa.Target)
+	if got := sa.final(); math.Abs(got-want) > 0.01 {
+		t.Errorf("wrong step size: got %.4g, want %.4g", got, want)
+	}
+}
+
+// The step size of HMC and NUTS is adapted to the target
+// acceptance statistic. Dual averaging tries large step sizes
+// early on, HMC is tested on a normal model, which does not
+// diverge.
+func TestStepAdapter(t *testing.T) {
+	for _, c := range []struct {
+		sampler func() Hamiltonian
+		m       model.Model
+		adapter func(h Hamiltonian, samples <-chan []float64)
+	}{
+		{
+			func() Hamiltonian { return &HMC{L: 10, Eps: 0.01} },
+			&scaledModel{},
+			func(h Hamiltonian, samples <-chan []float64) {
+				(&StepAdapter{}).Adapt(h, samples, 500)
+			},
+		},
+		{
+			func() Hamiltonian { return &NUTS{Eps: 1} },
+			&testModel{testData},
+			func(h Hamiltonian, samples <-chan []float64) {
+				(&StepAdapter{}).Adapt(h, samples, 500)
+			},
+		},
+		{
+			func() Hamiltonian { return &MALA{Eps: 0.01} },
+			&scaledModel{},
+			func(h Hamiltonian, samples <-chan []float64) {
+				(&StepAdapter{}).Adapt(h, samples, 500)
+			},
+		},
+		{
+			func() Hamiltonian { return &MNUTS{NUTS{Eps: 1}} },
+			&testModel{testData},
+			func(h Hamiltonian, samples <-chan []float64) {
+				(&StepAdapter{}).Adapt(h, samples, 500)
+			},
+		},
+		{
+			func() Hamiltonian { return &NUTS{Eps: 1} },
+			&testModel{testData},
+			func(h Hamiltonian, samples <-chan []float64) {
+				(&MetricAdapter{Step: &StepAdapter{}}).Adapt(
+					h, samples, 500)
+			},
+		},
+	} {
+		if !repeatedly(5,
+			func() bool {
+				h := c.sampler()
+				samples := make(chan []float64)
+				h.Sample(c.m, []float64{0, 0}, samples)
+				c.adapter(h, samples)
+				// Estimate the acceptance with the adapted
+				// step size.
+				acc := 0.
+				const N = 200
+				for i := 0; i != N; i++ {
+					acc += h.tuning().acceptance(<-samples)
+				}
+				h.Stop()
+				acc /= N
+				// The averaged step size is conservative, and
+				// the acceptance is mostly above the target.
+				return acc > 0.7 && acc < 0.99
+			},
+			true) {
+			t.Errorf("step size of %T was not adapted", c.sampler())
+		}
+	}
+}
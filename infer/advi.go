@@ -0,0 +1,214 @@
+package infer
+
+// Automatic differentiation variational inference
+// (https://arxiv.org/abs/1603.00788).
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"math"
+	"math/rand"
+)
+
+// ADVI approximates the posterior by a Gaussian, either
+// mean-field (diagonal) or full-rank, maximizing the evidence
+// lower bound (ELBO) by a gradient-based optimizer. The
+// gradient of the ELBO is estimated by Monte Carlo through the
+// reparameterization trick.
+type ADVI struct {
+	// Parameters
+	FullRank bool       // full-rank rather than mean-field
+	NSamples int        // number of draws per gradient estimate
+	Opt      Grad       // optimizer, Adam if nil
+	Rng      *rand.Rand // random number generator
+	// Approximation
+	Mean  []float64   // mean
+	Scale [][]float64 // lower-triangular Cholesky factor of the covariance
+	// Statistics
+	ELBO []float64 // ELBO estimates, one per iteration
+}
+
+// Fit fits the approximation to the posterior of m in niter
+// iterations, starting with mean x and the identity
+// covariance. The ELBO estimate of each iteration is appended
+// to ELBO.
+func (advi *ADVI) Fit(m model.Model, x []float64, niter int) {
+	advi.setDefaults()
+	n := len(x)
+	e := &elbo{
+		advi: advi,
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m: model.OnTape(m, ad.NewTape()),
+		n: n,
+	}
+	// The variational parameters are the mean followed by the
+	// scale: the diagonal for mean-field approximation, the
+	// lower triangle row by row for full-rank. The diagonal of
+	// the scale is in log space.
+	phi := make([]float64, n+e.nscale())
+	copy(phi, x)
+	for iter := 0; iter != niter; iter++ {
+		l, _ := advi.Opt.Step(e, phi)
+		advi.ELBO = append(advi.ELBO, l)
+	}
+
+	advi.Mean = clone(phi[:n])
+	advi.Scale = make([][]float64, n)
+	for i := range advi.Scale {
+		advi.Scale[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			advi.Scale[i][j] = e.scale(phi, i, j)
+		}
+	}
+}
+
+// Cov returns the covariance of the approximation.
+func (advi *ADVI) Cov() [][]float64 {
+	cov := make([][]float64, len(advi.Scale))
+	for i := range cov {
+		cov[i] = make([]float64, len(advi.Scale))
+		for j := range cov[i] {
+			for k := 0; k <= i && k <= j; k++ {
+				cov[i][j] += advi.Scale[i][k] * advi.Scale[j][k]
+			}
+		}
+	}
+	return cov
+}
+
+// Draw draws from the approximation.
+func (advi *ADVI) Draw() []float64 {
+	if advi.Rng == nil {
+		advi.setDefaults()
+	}
+	z := make([]float64, len(advi.Mean))
+	for i := range z {
+		z[i] = advi.Rng.NormFloat64()
+	}
+	x := make([]float64, len(advi.Mean))
+	for i := range x {
+		x[i] = advi.Mean[i]
+		for j := 0; j <= i; j++ {
+			x[i] += advi.Scale[i][j] * z[j]
+		}
+	}
+	return x
+}
+
+// setDefaults sets the default value for auxiliary parameters.
+func (advi *ADVI) setDefaults() {
+	if advi.NSamples == 0 {
+		advi.NSamples = 1
+	}
+	if advi.Opt == nil {
+		advi.Opt = &Adam{Rate: 0.01}
+	}
+	if advi.Rng == nil {
+		advi.Rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+}
+
+// elbo is the ELBO as an elemental model of the variational
+// parameters, optimized by Grad optimizers.
+type elbo struct {
+	advi *ADVI
+	m    model.Model
+	n    int       // number of model parameters
+	grad []float64 // gradient of the latest estimate
+}
+
+// Observe estimates the ELBO. A part of the Model interface.
+func (e *elbo) Observe(phi []float64) float64 {
+	advi, n := e.advi, e.n
+	if e.grad == nil {
+		e.grad = make([]float64, len(phi))
+	}
+	for k := range e.grad {
+		e.grad[k] = 0
+	}
+	z := make([]float64, n)
+	x := make([]float64, n)
+	l := 0.
+	w := 1 / float64(advi.NSamples)
+	for s := 0; s != advi.NSamples; s++ {
+		// Reparameterize x = mean + Lz, z ~ N(0, I).
+		for i := range z {
+			z[i] = advi.Rng.NormFloat64()
+		}
+		for i := range x {
+			x[i] = phi[i]
+			for j := 0; j <= i; j++ {
+				if e.nonzero(i, j) {
+					x[i] += e.scale(phi, i, j) * z[j]
+				}
+			}
+		}
+		ll, grad := e.m.Observe(x), model.Gradient(e.m)
+		l += w * ll
+		for i := range grad {
+			e.grad[i] += w * grad[i]
+			for j := 0; j <= i; j++ {
+				if e.nonzero(i, j) {
+					g := grad[i] * z[j]
+					if i == j {
+						// The diagonal is in log space.
+						g *= e.scale(phi, i, i)
+					}
+					e.grad[n+e.index(i, j)] += w * g
+				}
+			}
+		}
+	}
+
+	// The entropy of the Gaussian is the sum of logarithms of
+	// the diagonal of the scale, up to a constant.
+	for i := 0; i != n; i++ {
+		k := n + e.index(i, i)
+		l += phi[k]
+		e.grad[k]++
+	}
+	l += 0.5 * float64(n) * (1 + math.Log(2*math.Pi))
+	return l
+}
+
+// Gradient returns the gradient of the latest estimate. A part
+// of the ElementalModel interface.
+func (e *elbo) Gradient() []float64 {
+	return e.grad
+}
+
+// nscale returns the number of scale parameters.
+func (e *elbo) nscale() int {
+	if e.advi.FullRank {
+		return e.n * (e.n + 1) / 2
+	}
+	return e.n
+}
+
+// nonzero returns true if element (i, j) of the scale may be
+// non-zero.
+func (e *elbo) nonzero(i, j int) bool {
+	return i == j || e.advi.FullRank
+}
+
+// index returns the index of element (i, j), j <= i, of the
+// scale among the scale parameters.
+func (e *elbo) index(i, j int) int {
+	if e.advi.FullRank {
+		return i*(i+1)/2 + j
+	}
+	return i
+}
+
+// scale returns element (i, j) of the scale.
+func (e *elbo) scale(phi []float64, i, j int) float64 {
+	if !e.nonzero(i, j) {
+		return 0
+	}
+	s := phi[e.n+e.index(i, j)]
+	if i == j {
+		s = math.Exp(s)
+	}
+	return s
+}
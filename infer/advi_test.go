@@ -0,0 +1,128 @@
+package infer
+
+// Testing variational inference.
+
+import (
+	"math"
+	"testing"
+)
+
+// A Gaussian model with elemental gradient: mean mu and
+// precision matrix p.
+type gaussianModel struct {
+	mu   []float64
+	p    [][]float64
+	grad []float64
+}
+
+func (m *gaussianModel) Observe(x []float64) float64 {
+	m.grad = make([]float64, len(x))
+	l := 0.
+	for i := range x {
+		for j := range x {
+			d := m.p[i][j] * (x[j] - m.mu[j])
+			l -= 0.5 * (x[i] - m.mu[i]) * d
+			m.grad[i] -= d
+		}
+	}
+	return l
+}
+
+func (m *gaussianModel) Gradient() []float64 {
+	return m.grad
+}
+
+func TestADVI(t *testing.T) {
+	// The covariance is [[1, 0.8], [0.8, 1]], the precision is
+	// its inverse.
+	m := &gaussianModel{
+		mu: []float64{1, -2},
+		p:  [][]float64{{1 / 0.36, -0.8 / 0.36}, {-0.8 / 0.36, 1 / 0.36}},
+	}
+	for _, c := range []struct {
+		advi *ADVI
+		cov  [][]float64
+	}{
+		// The mean-field approximation matches the
+		// conditional variances.
+		{&ADVI{NSamples: 10, Opt: &Adam{Rate: 0.005}},
+			[][]float64{{0.36, 0}, {0, 0.36}}},
+		{&ADVI{FullRank: true, NSamples: 10, Opt: &Adam{Rate: 0.005}},
+			[][]float64{{1, 0.8}, {0.8, 1}}},
+	} {
+		advi := c.advi
+		advi.Fit(m, []float64{0, 0}, 5000)
+		name := "mean-field"
+		if advi.FullRank {
+			name = "full-rank"
+		}
+		if len(advi.ELBO) != 5000 {
+			t.Errorf("%s: wrong length of ELBO trace: got %d, want 5000",
+				name, len(advi.ELBO))
+		}
+		// ELBO increases.
+		first, last := 0., 0.
+		for i := 0; i != 100; i++ {
+			first += advi.ELBO[i]
+			last += advi.ELBO[len(advi.ELBO)-1-i]
+		}
+		if last <= first {
+			t.Errorf("%s: ELBO did not increase: %.4g => %.4g",
+				name, first/100, last/100)
+		}
+		for i := range m.mu {
+			if math.Abs(advi.Mean[i]-m.mu[i]) > 0.1 {
+				t.Errorf("%s: wrong mean: got %v, want %v",
+					name, advi.Mean, m.mu)
+				break
+			}
+		}
+		cov := advi.Cov()
+		for i := range cov {
+			for j := range cov {
+				if math.Abs(cov[i][j]-c.cov[i][j]) > 0.15 {
+					t.Errorf("%s: wrong covariance: got %v, want %v",
+						name, cov, c.cov)
+				}
+			}
+		}
+
+		// The draws follow the approximation.
+		const N = 10000
+		mean := make([]float64, 2)
+		for n := 0; n != N; n++ {
+			x := advi.Draw()
+			for i := range x {
+				mean[i] += x[i] / N
+			}
+		}
+		for i := range mean {
+			if math.Abs(mean[i]-advi.Mean[i]) > 0.05 {
+				t.Errorf("%s: wrong mean of draws: got %v, want %v",
+					name, mean, advi.Mean)
+				break
+			}
+		}
+	}
+}
+
+func TestADVIModel(t *testing.T) {
+	// Differentiated models are fitted with any optimizer.
+	for _, c := range []struct {
+		opt   Grad
+		niter int
+	}{
+		{nil, 5000},
+		{&Momentum{Rate: 0.0005, Gamma: 0.9}, 3000},
+	} {
+		advi := &ADVI{Opt: c.opt, NSamples: 10}
+		advi.Fit(&testModel{testData}, []float64{0, 0}, c.niter)
+		if math.Abs(advi.Mean[0]-testMean) > 0.1 ||
+			math.Abs(math.Exp(advi.Mean[1])-testStddev) > 0.2 {
+			t.Errorf("%T: wrong approximation: mean=%.4g, "+
+				"want %.4g; stddev=%.4g, want about %.4g",
+				advi.Opt, advi.Mean[0], testMean,
+				math.Exp(advi.Mean[1]), testStddev)
+		}
+	}
+}
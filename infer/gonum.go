@@ -14,7 +14,34 @@ func FuncGrad(m model.Model) (
 	Grad func(grad, x []float64),
 ) {
 	_, isElemental := m.(model.ElementalModel)
-	if ad.IsMTSafe() && !isElemental {
+	tm, isTape := m.(model.TapeModel)
+	if isTape && !isElemental {
+		// The model runs on a tape of its own in each call,
+		// tapes are reused through a pool.
+		tapes := &sync.Pool{
+			New: func() interface{} {
+				return ad.NewTape()
+			},
+		}
+
+		Func = func(x []float64) float64 {
+			tape := tapes.Get().(*ad.Tape)
+			ll := tm.ObserveTape(tape, x)
+			tape.Pop()
+			tapes.Put(tape)
+			return -ll
+		}
+
+		Grad = func(grad, x []float64) {
+			tape := tapes.Get().(*ad.Tape)
+			tm.ObserveTape(tape, x)
+			grad_ := tape.Gradient()
+			tapes.Put(tape)
+			for i := range grad_ {
+				grad[i] = -grad_[i]
+			}
+		}
+	} else if ad.IsMTSafe() && !isElemental {
 		// It is safe to run multiple differentiations in
 		// parallel, no locking.
 
@@ -0,0 +1,50 @@
+package infer
+
+// Testing the gonum adapter.
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+// Func and Grad of a model with the tape parameter can be
+// called concurrently without multithreading support in the
+// tape store.
+func TestFuncGradParallel(t *testing.T) {
+	Func, Grad := FuncGrad(&tapeTestModel{testData})
+	x := []float64{0.5, -0.5}
+	f := Func(x)
+	grad := make([]float64, len(x))
+	Grad(grad, x)
+
+	const N = 16
+	var wg sync.WaitGroup
+	errs := make(chan string, 2*N)
+	for i := 0; i != N; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			x := []float64{0.5, -0.5}
+			for j := 0; j != 100; j++ {
+				if f_ := Func(x); f_ != f {
+					errs <- "wrong value"
+					return
+				}
+				grad_ := make([]float64, len(x))
+				Grad(grad_, x)
+				for k := range grad {
+					if math.Abs(grad_[k]-grad[k]) > 1e-12 {
+						errs <- "wrong gradient"
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("%s", err)
+	}
+}
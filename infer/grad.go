@@ -19,10 +19,11 @@ type Grad interface {
 // factor is not set, and thus 0, reduces to vanilla gradient
 // ascent.
 type Momentum struct {
-	Rate  float64   //learning rate
-	Decay float64   // rate decay
-	Gamma float64   // gradient momentum factor
-	u     []float64 // last update
+	Rate  float64 //learning rate
+	Decay float64 // rate decay
+	Gamma float64 // gradient momentum factor
+	Clip
+	u []float64 // last update
 }
 
 // Step implements the Optimizer interface.
@@ -39,8 +40,9 @@ func (opt *Momentum) Step(
 		// u is initialized to zeros.
 		opt.u = make([]float64, len(x))
 	}
+	g := opt.clip(grad)
 	for i := range x {
-		u := opt.Rate*grad[i] + opt.u[i]*opt.Gamma
+		u := opt.Rate*g[i] + opt.u[i]*opt.Gamma
 		x[i] += u
 		opt.u[i] = u
 	}
@@ -56,14 +58,15 @@ func (opt *Momentum) setDefaults() {
 
 // Adam (https://arxiv.org/abs/1412.6980).
 type Adam struct {
-	Rate  float64   // learning rate
-	Beta1 float64   // first momentum factor
-	Beta2 float64   // second momentum factor
-	Eps   float64   // stabilizer
-	u     []float64 // first momentum
-	v     []float64 // second momentum
-	b1t   float64   // Beta1^t
-	b2t   float64   // Beta2^t
+	Rate  float64 // learning rate
+	Beta1 float64 // first momentum factor
+	Beta2 float64 // second momentum factor
+	Eps   float64 // stabilizer
+	Clip
+	u   []float64 // first momentum
+	v   []float64 // second momentum
+	b1t float64   // Beta1^t
+	b2t float64   // Beta2^t
 }
 
 // Step implements the Optimizer interface.
@@ -85,10 +88,11 @@ func (opt *Adam) Step(
 		opt.b2t = opt.Beta2
 	}
 
+	g := opt.clip(grad)
 	for i := range x {
 		// Compute the new momenta.
-		u := opt.Beta1*opt.u[i] + (1-opt.Beta1)*grad[i]
-		v := opt.Beta2*opt.v[i] + (1-opt.Beta2)*grad[i]*grad[i]
+		u := opt.Beta1*opt.u[i] + (1-opt.Beta1)*g[i]
+		v := opt.Beta2*opt.v[i] + (1-opt.Beta2)*g[i]*g[i]
 		opt.u[i] = u
 		opt.v[i] = v
 
@@ -121,9 +125,295 @@ func (opt *Adam) setDefaults() {
 	}
 }
 
+// RMSProp (http://www.cs.toronto.edu/~tijmen/csc321/slides/lecture_slides_lec6.pdf).
+type RMSProp struct {
+	Rate float64 // learning rate
+	Rho  float64 // decay of the squared gradient average
+	Eps  float64 // stabilizer
+	Clip
+	v []float64 // squared gradient average
+}
+
+// Step implements the Optimizer interface.
+func (opt *RMSProp) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	ll, grad = m.Observe(x), model.Gradient(m)
+
+	if opt.v == nil {
+		opt.setDefaults()
+		// The average is initialized to zeros.
+		opt.v = make([]float64, len(x))
+	}
+
+	g := opt.clip(grad)
+	for i := range x {
+		opt.v[i] = opt.Rho*opt.v[i] + (1-opt.Rho)*g[i]*g[i]
+		x[i] += opt.Rate / (math.Sqrt(opt.v[i]) + opt.Eps) * g[i]
+	}
+
+	return ll, grad
+}
+
+// setDefaults sets default parameter values for the RMSProp
+// optimizer unless initialized.
+func (opt *RMSProp) setDefaults() {
+	if opt.Rho == 0 {
+		opt.Rho = 0.9
+	}
+	if opt.Eps == 0 {
+		opt.Eps = 1e-8
+	}
+}
+
+// AdaGrad (https://jmlr.org/papers/v12/duchi11a.html).
+type AdaGrad struct {
+	Rate float64 // learning rate
+	Eps  float64 // stabilizer
+	Clip
+	v []float64 // sum of squared gradients
+}
+
+// Step implements the Optimizer interface.
+func (opt *AdaGrad) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	ll, grad = m.Observe(x), model.Gradient(m)
+
+	if opt.v == nil {
+		opt.setDefaults()
+		// The sum is initialized to zeros.
+		opt.v = make([]float64, len(x))
+	}
+
+	g := opt.clip(grad)
+	for i := range x {
+		opt.v[i] += g[i] * g[i]
+		x[i] += opt.Rate / (math.Sqrt(opt.v[i]) + opt.Eps) * g[i]
+	}
+
+	return ll, grad
+}
+
+// setDefaults sets default parameter values for the AdaGrad
+// optimizer unless initialized.
+func (opt *AdaGrad) setDefaults() {
+	if opt.Eps == 0 {
+		opt.Eps = 1e-8
+	}
+}
+
+// AMSGrad (https://openreview.net/forum?id=ryQu7f-RZ), a
+// variant of Adam with the maximum of the second momenta
+// instead of the second momentum.
+type AMSGrad struct {
+	Rate  float64 // learning rate
+	Beta1 float64 // first momentum factor
+	Beta2 float64 // second momentum factor
+	Eps   float64 // stabilizer
+	Clip
+	u    []float64 // first momentum
+	v    []float64 // second momentum
+	vmax []float64 // maximum of second momenta
+	b1t  float64   // Beta1^t
+	b2t  float64   // Beta2^t
+}
+
+// Step implements the Optimizer interface.
+func (opt *AMSGrad) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	ll, grad = m.Observe(x), model.Gradient(m)
+
+	if opt.u == nil {
+		opt.setDefaults()
+		// The momenta are initalized to zeros.
+		opt.u = make([]float64, len(x))
+		opt.v = make([]float64, len(x))
+		opt.vmax = make([]float64, len(x))
+		opt.b1t = opt.Beta1
+		opt.b2t = opt.Beta2
+	}
+
+	g := opt.clip(grad)
+	for i := range x {
+		// Compute the new momenta.
+		opt.u[i] = opt.Beta1*opt.u[i] + (1-opt.Beta1)*g[i]
+		opt.v[i] = opt.Beta2*opt.v[i] + (1-opt.Beta2)*g[i]*g[i]
+		opt.vmax[i] = math.Max(opt.vmax[i], opt.v[i])
+
+		// Correct the bias.
+		u := opt.u[i] / (1 - opt.b1t)
+		v := opt.vmax[i] / (1 - opt.b2t)
+
+		// Update the parameters.
+		x[i] += opt.Rate / (math.Sqrt(v) + opt.Eps) * u
+	}
+
+	// Update momentum factors for the next step.
+	opt.b1t *= opt.Beta1
+	opt.b2t *= opt.Beta2
+
+	return ll, grad
+}
+
+// setDefaults sets default parameter values for the AMSGrad
+// optimizer unless initialized.
+func (opt *AMSGrad) setDefaults() {
+	if opt.Beta1 == 0 {
+		opt.Beta1 = 0.9
+	}
+	if opt.Beta2 == 0 {
+		opt.Beta2 = 0.999
+	}
+	if opt.Eps == 0 {
+		opt.Eps = 1e-8
+	}
+}
+
+// Nadam (https://openreview.net/forum?id=OM0jvwB8jIp57ZJjtNEZ),
+// Adam with Nesterov momentum.
+type Nadam struct {
+	Rate  float64 // learning rate
+	Beta1 float64 // first momentum factor
+	Beta2 float64 // second momentum factor
+	Eps   float64 // stabilizer
+	Clip
+	u   []float64 // first momentum
+	v   []float64 // second momentum
+	b1t float64   // Beta1^t
+	b2t float64   // Beta2^t
+}
+
+// Step implements the Optimizer interface.
+func (opt *Nadam) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	ll, grad = m.Observe(x), model.Gradient(m)
+
+	if opt.u == nil {
+		opt.setDefaults()
+		// The momenta are initalized to zeros.
+		opt.u = make([]float64, len(x))
+		opt.v = make([]float64, len(x))
+		opt.b1t = opt.Beta1
+		opt.b2t = opt.Beta2
+	}
+
+	g := opt.clip(grad)
+	for i := range x {
+		// Compute the new momenta.
+		opt.u[i] = opt.Beta1*opt.u[i] + (1-opt.Beta1)*g[i]
+		opt.v[i] = opt.Beta2*opt.v[i] + (1-opt.Beta2)*g[i]*g[i]
+
+		// Correct the bias, looking ahead for the first
+		// momentum.
+		u := opt.Beta1*opt.u[i]/(1-opt.b1t*opt.Beta1) +
+			(1-opt.Beta1)*g[i]/(1-opt.b1t)
+		v := opt.v[i] / (1 - opt.b2t)
+
+		// Update the parameters.
+		x[i] += opt.Rate / (math.Sqrt(v) + opt.Eps) * u
+	}
+
+	// Update momentum factors for the next step.
+	opt.b1t *= opt.Beta1
+	opt.b2t *= opt.Beta2
+
+	return ll, grad
+}
+
+// setDefaults sets default parameter values for the Nadam
+// optimizer unless initialized.
+func (opt *Nadam) setDefaults() {
+	if opt.Beta1 == 0 {
+		opt.Beta1 = 0.9
+	}
+	if opt.Beta2 == 0 {
+		opt.Beta2 = 0.999
+	}
+	if opt.Eps == 0 {
+		opt.Eps = 1e-8
+	}
+}
+
+// AdamW (https://arxiv.org/abs/1711.05101), Adam with
+// decoupled weight decay. Without weight decay, AdamW is Adam;
+// a common choice of the decay factor is 0.01.
+type AdamW struct {
+	Adam
+	WeightDecay float64 // weight decay factor, no decay if 0
+}
+
+// Step implements the Optimizer interface.
+func (opt *AdamW) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	// The decay is proportional to the parameters before the
+	// update.
+	x0 := clone(x)
+	ll, grad = opt.Adam.Step(m, x)
+	for i := range x {
+		x[i] -= opt.Rate * opt.WeightDecay * x0[i]
+	}
+	return ll, grad
+}
+
+// Clip is gradient clipping, embedded in first-order
+// optimizers. The gradient is first clipped element-wise to
+// Value, then scaled down to Norm.
+type Clip struct {
+	Value float64 // maximum absolute value of elements, unless 0
+	Norm  float64 // maximum norm, unless 0
+}
+
+// clip returns the clipped gradient; grad is returned
+// unchanged if no clipping is needed.
+func (c *Clip) clip(grad []float64) []float64 {
+	if c.Value == 0 && c.Norm == 0 {
+		return grad
+	}
+	g := clone(grad)
+	if c.Value != 0 {
+		for i := range g {
+			g[i] = math.Max(-c.Value, math.Min(c.Value, g[i]))
+		}
+	}
+	if c.Norm != 0 {
+		if n := norm(g); n > c.Norm {
+			for i := range g {
+				g[i] *= c.Norm / n
+			}
+		}
+	}
+	return g
+}
+
 // Optimize wraps a gradient-based optimizer into
 // an optimization loop with early stopping if a
-// plateau is reached.
+// plateau is reached. Adaptation of the learning rate to the
+// log-likelihood by the schedule of a Scheduled optimizer, such
+// as reduction by Plateau, restarts the plateau count.
 func Optimize(
 	opt Grad,
 	m model.Model, x []float64,
@@ -133,6 +423,9 @@ func Optimize(
 	iter int,
 	ll0, ll float64,
 ) {
+	scheduled, _ := opt.(*Scheduled)
+	nadapt := 0
+
 	ll0, _ = opt.Step(m, x)
 	iter, ll = 0, ll0
 	plateau, llprev := 0, ll0
@@ -156,8 +449,371 @@ func Optimize(
 		} else {
 			plateau = 0
 		}
+		if scheduled != nil && scheduled.NAdapt != nadapt {
+			// Give the adapted rate a chance.
+			nadapt = scheduled.NAdapt
+			plateau = 0
+		}
 		llprev = ll_
 	}
 
 	return iter, ll0, ll
 }
+
+// Limited-memory BFGS (https://doi.org/10.1007/BF01589116)
+// with a line search satisfying the strong Wolfe conditions.
+// Parameters with bounds are optimized on the unconstrained
+// scale: a parameter bounded from below by a is a + exp(y),
+// from above by b is b - exp(y), and from both sides is
+// a + (b - a)/(1 + exp(-y)), where y is unconstrained. Initial
+// values of bounded parameters must be strictly within the
+// bounds.
+type LBFGS struct {
+	// Parameters
+	M     int       // number of stored corrections
+	Lower []float64 // lower bounds, -Inf or nil if unbounded
+	Upper []float64 // upper bounds, +Inf or nil if unbounded
+	GTol  float64   // tolerance on the gradient norm
+	FTol  float64   // tolerance on the relative change
+	// Statistics
+	GradNorm  float64 // norm of the gradient on the unconstrained scale
+	Change    float64 // change of the log-likelihood in the latest step
+	Converged bool    // the gradient norm or the change is within tolerance
+	// State
+	s, y [][]float64 // corrections, oldest first
+	// The log-likelihood and the gradients at the latest
+	// point, reused by the next step.
+	x     []float64 // latest point, as written to the caller
+	u     []float64 // latest point, unconstrained
+	l     float64   // log-likelihood
+	gradx []float64 // gradient of the log-likelihood
+	gradu []float64 // gradient of the negated log-likelihood, unconstrained
+}
+
+// Step implements the Optimizer interface.
+func (opt *LBFGS) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	if opt.M == 0 {
+		opt.setDefaults()
+	}
+	if !equal(x, opt.x) {
+		// The first step, or x was changed by the caller. The
+		// point is compared on the constrained scale, since
+		// transforming a bounded parameter back and forth may
+		// change the value.
+		opt.s, opt.y = nil, nil
+		opt.x = clone(x)
+		opt.u = opt.unconstrain(x)
+		opt.l, opt.gradx, opt.gradu = opt.evaluate(m, opt.u)
+		opt.GradNorm = norm(opt.gradu)
+		opt.Converged = false
+	}
+	ll, grad = opt.l, opt.gradx
+	u := opt.u
+	if opt.Converged {
+		opt.Change = 0
+		return ll, grad
+	}
+
+	// Search direction d = -Hg by the two-loop recursion.
+	d := make([]float64, len(u))
+	for i := range d {
+		d[i] = -opt.gradu[i]
+	}
+	alpha := make([]float64, len(opt.s))
+	for k := len(opt.s) - 1; k >= 0; k-- {
+		alpha[k] = dot(opt.s[k], d) / dot(opt.y[k], opt.s[k])
+		for i := range d {
+			d[i] -= alpha[k] * opt.y[k][i]
+		}
+	}
+	if k := len(opt.s) - 1; k >= 0 {
+		gamma := dot(opt.s[k], opt.y[k]) / dot(opt.y[k], opt.y[k])
+		for i := range d {
+			d[i] *= gamma
+		}
+	}
+	for k := range opt.s {
+		beta := dot(opt.y[k], d) / dot(opt.y[k], opt.s[k])
+		for i := range d {
+			d[i] += (alpha[k] - beta) * opt.s[k][i]
+		}
+	}
+	a0 := 1.
+	if len(opt.s) == 0 {
+		// Unknown scale, the first step is of unit length.
+		a0 = 1 / norm(d)
+	}
+
+	// Line search along d.
+	p, ok := opt.lineSearch(m, u, d, a0)
+	if !ok {
+		// The line search failed, drop the corrections; the
+		// next step is along the gradient.
+		steepest := len(opt.s) == 0
+		opt.s, opt.y = nil, nil
+		if p == nil {
+			// No decrease along the direction; if the
+			// direction is the gradient, the optimizer is
+			// stuck.
+			opt.Change = 0
+			opt.Converged = steepest
+			return ll, grad
+		}
+	}
+
+	// Store the correction and move to the new point.
+	s := make([]float64, len(u))
+	y := make([]float64, len(u))
+	for i := range u {
+		s[i] = p.u[i] - u[i]
+		y[i] = p.gradu[i] - opt.gradu[i]
+	}
+	if dot(s, y) > 1e-10*dot(y, y) {
+		opt.s, opt.y = append(opt.s, s), append(opt.y, y)
+		if len(opt.s) > opt.M {
+			opt.s, opt.y = opt.s[1:], opt.y[1:]
+		}
+	}
+	opt.Change = p.l - opt.l
+	opt.u, opt.l, opt.gradx, opt.gradu = p.u, p.l, p.gradx, p.gradu
+	opt.GradNorm = norm(opt.gradu)
+	opt.Converged = opt.GradNorm <= opt.GTol ||
+		math.Abs(opt.Change) <= opt.FTol*math.Max(1, math.Abs(opt.l))
+	opt.x = opt.constrain(opt.u)
+	copy(x, opt.x)
+	return ll, grad
+}
+
+// lbfgsPoint is a point evaluated by the line search.
+type lbfgsPoint struct {
+	a     float64   // step
+	u     []float64 // point
+	l     float64   // log-likelihood
+	df    float64   // directional derivative of -l
+	gradx []float64
+	gradu []float64
+}
+
+// lineSearch searches for a step along d from u satisfying the
+// strong Wolfe conditions (Algorithms 3.5 and 3.6 in Nocedal &
+// Wright, Numerical Optimization), starting with step a. The
+// search minimizes the negated log-likelihood; non-finite
+// log-likelihood is treated as too long a step. If the
+// conditions are not satisfied, lineSearch returns the best
+// point with sufficient decrease, if any, and false.
+func (opt *LBFGS) lineSearch(
+	m model.Model,
+	u, d []float64,
+	a float64,
+) (*lbfgsPoint, bool) {
+	const (
+		c1      = 1e-4
+		c2      = 0.9
+		maxIter = 20
+	)
+	f0, df0 := -opt.l, dot(opt.gradu, d)
+	if df0 >= 0 {
+		// Not a descent direction.
+		return nil, false
+	}
+	eval := func(a float64) *lbfgsPoint {
+		p := &lbfgsPoint{a: a, u: make([]float64, len(u))}
+		for i := range u {
+			p.u[i] = u[i] + a*d[i]
+		}
+		p.l, p.gradx, p.gradu = opt.evaluate(m, p.u)
+		p.df = dot(p.gradu, d)
+		return p
+	}
+	// bad returns true if the step is too long.
+	bad := func(p *lbfgsPoint, prev *lbfgsPoint) bool {
+		f := -p.l
+		return math.IsNaN(f) || math.IsInf(f, 0) ||
+			f > f0+c1*p.a*df0 ||
+			prev != nil && f >= -prev.l
+	}
+	// curved returns true if the curvature condition holds.
+	curved := func(p *lbfgsPoint) bool {
+		return math.Abs(p.df) <= -c2*df0
+	}
+	// zoom finds a point in the interval between lo and hi;
+	// lo is nil for the initial point.
+	zoom := func(lo, hi *lbfgsPoint) (*lbfgsPoint, bool) {
+		for iter := 0; iter != maxIter; iter++ {
+			alo := 0.
+			if lo != nil {
+				alo = lo.a
+			}
+			p := eval(0.5 * (alo + hi.a))
+			if bad(p, lo) {
+				hi = p
+				continue
+			}
+			if curved(p) {
+				return p, true
+			}
+			if p.df*(hi.a-alo) >= 0 {
+				hi = prevOrOrigin(lo)
+			}
+			lo = p
+		}
+		return lo, false
+	}
+
+	var prev *lbfgsPoint
+	for iter := 0; iter != maxIter; iter++ {
+		p := eval(a)
+		if bad(p, prev) {
+			return zoom(prev, p)
+		}
+		if curved(p) {
+			return p, true
+		}
+		if p.df >= 0 {
+			return zoom(p, prevOrOrigin(prev))
+		}
+		prev = p
+		a *= 2
+	}
+	return prev, false
+}
+
+// prevOrOrigin returns p, or the initial point of the line
+// search if p is nil.
+func prevOrOrigin(p *lbfgsPoint) *lbfgsPoint {
+	if p == nil {
+		return &lbfgsPoint{a: 0}
+	}
+	return p
+}
+
+// evaluate returns the log-likelihood and its gradient at the
+// constrained point corresponding to u, and the gradient of
+// the negated log-likelihood with respect to u.
+func (opt *LBFGS) evaluate(
+	m model.Model,
+	u []float64,
+) (
+	l float64,
+	gradx, gradu []float64,
+) {
+	x := opt.constrain(u)
+	l = m.Observe(x)
+	gradx = make([]float64, len(x))
+	copy(gradx, model.Gradient(m))
+	gradu = make([]float64, len(x))
+	for i := range x {
+		lo, hi := opt.bounds(i)
+		// dx/du
+		var j float64
+		switch {
+		case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+			j = 1
+		case math.IsInf(hi, 1):
+			j = x[i] - lo
+		case math.IsInf(lo, -1):
+			j = -(hi - x[i])
+		default:
+			j = (x[i] - lo) * (hi - x[i]) / (hi - lo)
+		}
+		gradu[i] = -gradx[i] * j
+	}
+	return l, gradx, gradu
+}
+
+// bounds returns the bounds of parameter i.
+func (opt *LBFGS) bounds(i int) (lo, hi float64) {
+	lo, hi = math.Inf(-1), math.Inf(1)
+	if opt.Lower != nil {
+		lo = opt.Lower[i]
+	}
+	if opt.Upper != nil {
+		hi = opt.Upper[i]
+	}
+	return lo, hi
+}
+
+// unconstrain maps x to the unconstrained scale.
+func (opt *LBFGS) unconstrain(x []float64) []float64 {
+	u := make([]float64, len(x))
+	for i := range x {
+		lo, hi := opt.bounds(i)
+		switch {
+		case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+			u[i] = x[i]
+		case math.IsInf(hi, 1):
+			u[i] = math.Log(x[i] - lo)
+		case math.IsInf(lo, -1):
+			u[i] = math.Log(hi - x[i])
+		default:
+			u[i] = math.Log((x[i] - lo) / (hi - x[i]))
+		}
+	}
+	return u
+}
+
+// constrain maps u from the unconstrained scale.
+func (opt *LBFGS) constrain(u []float64) []float64 {
+	x := make([]float64, len(u))
+	for i := range u {
+		lo, hi := opt.bounds(i)
+		switch {
+		case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+			x[i] = u[i]
+		case math.IsInf(hi, 1):
+			x[i] = lo + math.Exp(u[i])
+		case math.IsInf(lo, -1):
+			x[i] = hi - math.Exp(u[i])
+		default:
+			x[i] = lo + (hi-lo)/(1+math.Exp(-u[i]))
+		}
+	}
+	return x
+}
+
+// setDefaults sets default parameter values for the L-BFGS
+// optimizer unless initialized.
+func (opt *LBFGS) setDefaults() {
+	if opt.M == 0 {
+		opt.M = 10
+	}
+	if opt.GTol == 0 {
+		opt.GTol = 1e-8
+	}
+	if opt.FTol == 0 {
+		opt.FTol = 1e-12
+	}
+}
+
+// dot returns the dot product of a and b.
+func dot(a, b []float64) float64 {
+	s := 0.
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// norm returns the Euclidean norm of a.
+func norm(a []float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// equal returns true if a and b are equal element-wise.
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -110,3 +110,244 @@ func TestAdam(t *testing.T) {
 		}
 	}
 }
+
+func TestAdamWNoDecay(t *testing.T) {
+	m := &quadratic{mode: []float64{1, -2}}
+	adam := &Adam{Rate: 0.1}
+	adamw := &AdamW{Adam: Adam{Rate: 0.1}, WeightDecay: 0}
+	x, xw := []float64{3, 3}, []float64{3, 3}
+	for k := 0; k != 10; k++ {
+		adam.Step(m, x)
+		adamw.Step(m, xw)
+		for i := range x {
+			if x[i] != xw[i] {
+				t.Fatalf("update %d differs from Adam: got %v, want %v",
+					k+1, xw, x)
+			}
+		}
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	m := &constGrad{
+		grad: []float64{1, 2},
+	}
+	for _, c := range []struct {
+		opt   Grad
+		xNext [][]float64
+	}{
+		{&RMSProp{Rate: 0.1},
+			[][]float64{{0.316228, 0.316228}, {0.545643, 0.545643}}},
+		{&AdaGrad{Rate: 0.1},
+			[][]float64{{0.1, 0.1}, {0.170711, 0.170711}}},
+		{&AMSGrad{Rate: 0.1},
+			[][]float64{{0.1, 0.1}, {0.2, 0.2}}},
+		{&Nadam{Rate: 0.1},
+			[][]float64{{0.147368, 0.147368}, {0.2631, 0.2631}}},
+		{&AdamW{Adam: Adam{Rate: 0.1}, WeightDecay: 0.01},
+			[][]float64{{0.1, 0.1}, {0.1999, 0.1999}}},
+		// Without weight decay, AdamW is Adam.
+		{&AdamW{Adam: Adam{Rate: 0.1}},
+			[][]float64{{0.1, 0.1}, {0.2, 0.2}}},
+	} {
+		x := []float64{0, 0}
+		for k, xNext := range c.xNext {
+			c.opt.Step(m, x)
+			for i := range x {
+				if math.Abs(xNext[i]-x[i]) > 1e-6 {
+					t.Errorf("%T: wrong update %d: got x[%d] = %.6g, "+
+						"want %.6g", c.opt, k+1, i, x[i], xNext[i])
+				}
+			}
+		}
+	}
+}
+
+func TestClip(t *testing.T) {
+	m := &constGrad{
+		grad: []float64{1, -2},
+	}
+	for _, c := range []struct {
+		clip  Clip
+		xNext []float64
+	}{
+		{Clip{}, []float64{1, -2}},
+		{Clip{Value: 1}, []float64{1, -1}},
+		{Clip{Norm: 1}, []float64{1 / math.Sqrt(5), -2 / math.Sqrt(5)}},
+		{Clip{Value: 1, Norm: 1}, []float64{1 / math.Sqrt(2), -1 / math.Sqrt(2)}},
+		{Clip{Value: 3, Norm: 3}, []float64{1, -2}},
+	} {
+		opt := &Momentum{Rate: 1, Clip: c.clip}
+		x := []float64{0, 0}
+		_, grad := opt.Step(m, x)
+		for i := range x {
+			if math.Abs(c.xNext[i]-x[i]) > 1e-6 {
+				t.Errorf("%+v: wrong update: got x[%d] = %.6g, want %.6g",
+					c.clip, i, x[i], c.xNext[i])
+			}
+		}
+		// The returned gradient is not clipped.
+		for i := range grad {
+			if grad[i] != m.grad[i] {
+				t.Errorf("%+v: gradient clipped: got %v, want %v",
+					c.clip, grad, m.grad)
+				break
+			}
+		}
+	}
+}
+
+func TestOptimizers(t *testing.T) {
+	m := &quadratic{mode: []float64{1, -2}}
+	for _, opt := range []Grad{
+		&Momentum{Rate: 0.01, Gamma: 0.9, Clip: Clip{Norm: 1}},
+		&Adam{Rate: 0.01, Clip: Clip{Value: 1}},
+		&RMSProp{Rate: 0.001},
+		&AdaGrad{Rate: 0.1},
+		&AMSGrad{Rate: 0.01},
+		&Nadam{Rate: 0.01},
+		&AdamW{Adam: Adam{Rate: 0.01}, WeightDecay: 1e-6},
+	} {
+		x := []float64{0, 0}
+		Optimize(opt, m, x, 10000, 100, 1e-12)
+		for i := range x {
+			if math.Abs(x[i]-m.mode[i]) > 0.01 {
+				t.Errorf("%T: wrong optimum: got %v, want %v",
+					opt, x, m.mode)
+				break
+			}
+		}
+	}
+}
+
+// Negated Rosenbrock function, with elemental gradient.
+type rosenbrock struct {
+	grad []float64
+}
+
+func (m *rosenbrock) Observe(x []float64) float64 {
+	a, b := 1-x[0], x[1]-x[0]*x[0]
+	m.grad = []float64{2*a + 400*x[0]*b, -200 * b}
+	return -(a*a + 100*b*b)
+}
+
+func (m *rosenbrock) Gradient() []float64 {
+	return m.grad
+}
+
+// A quadratic with maximum at mode, with elemental gradient.
+type quadratic struct {
+	mode []float64
+	grad []float64
+}
+
+func (m *quadratic) Observe(x []float64) float64 {
+	m.grad = make([]float64, len(x))
+	l := 0.
+	for i := range x {
+		l -= (x[i] - m.mode[i]) * (x[i] - m.mode[i])
+		m.grad[i] = -2 * (x[i] - m.mode[i])
+	}
+	return l
+}
+
+func (m *quadratic) Gradient() []float64 {
+	return m.grad
+}
+
+func TestLBFGS(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		opt  *LBFGS
+		m    model.Model
+		x    []float64
+		want []float64
+		prec float64
+	}{
+		{"Rosenbrock", &LBFGS{}, &rosenbrock{},
+			[]float64{-1.2, 1}, []float64{1, 1}, 1e-6},
+		{"normal", &LBFGS{}, &testModel{testData},
+			[]float64{0, 0}, []float64{testMean, math.Log(testStddev)},
+			1e-6},
+		// log(1 - x²) is not finite for |x| >= 1.
+		{"bounded support", &LBFGS{}, &boundedModel{},
+			[]float64{0.9}, []float64{0}, 1e-6},
+		{"within bounds", &LBFGS{
+			Lower: []float64{0, math.Inf(-1)},
+			Upper: []float64{1, 0}},
+			&quadratic{mode: []float64{0.5, -2}},
+			[]float64{0.1, -0.1}, []float64{0.5, -2}, 1e-5},
+		{"on bounds", &LBFGS{
+			Lower: []float64{0, -1},
+			Upper: []float64{1, math.Inf(1)}},
+			&quadratic{mode: []float64{2, -3}},
+			[]float64{0.5, 0}, []float64{1, -1}, 1e-3},
+	} {
+		x := make([]float64, len(c.x))
+		copy(x, c.x)
+		iter, _, _ := Optimize(c.opt, c.m, x, 1000, 3, 0)
+		for i := range x {
+			if math.Abs(x[i]-c.want[i]) > c.prec {
+				t.Errorf("%s: wrong optimum: got %v, want %v",
+					c.name, x, c.want)
+				break
+			}
+		}
+		if c.opt.Lower != nil {
+			for i := range x {
+				if x[i] < c.opt.Lower[i] || x[i] > c.opt.Upper[i] {
+					t.Errorf("%s: optimum out of bounds: %v",
+						c.name, x)
+				}
+			}
+		}
+		if iter == 1000 {
+			t.Errorf("%s: did not converge in %d iterations: "+
+				"gradient norm %.4g, change %.4g",
+				c.name, iter, c.opt.GradNorm, c.opt.Change)
+		}
+		if !c.opt.Converged {
+			t.Errorf("%s: convergence not reported: "+
+				"gradient norm %.4g, change %.4g",
+				c.name, c.opt.GradNorm, c.opt.Change)
+		}
+	}
+}
+
+func TestLBFGSBounded(t *testing.T) {
+	// A bound which is not active at the optimum must not slow
+	// down convergence: the corrections are kept between steps
+	// although the bounded parameter is transformed.
+	run := func(opt *LBFGS) int {
+		x := []float64{2, 2}
+		maxCorr := 0
+		for iter := 1; iter != 100; iter++ {
+			opt.Step(&rosenbrock{}, x)
+			if len(opt.s) > maxCorr {
+				maxCorr = len(opt.s)
+			}
+			if opt.Converged {
+				if maxCorr <= 1 {
+					t.Errorf("corrections are not kept: "+
+						"at most %d stored", maxCorr)
+				}
+				if math.Abs(x[0]-1) > 1e-4 || math.Abs(x[1]-1) > 1e-4 {
+					t.Errorf("wrong optimum: got %v, want [1 1]", x)
+				}
+				return iter
+			}
+		}
+		t.Errorf("did not converge: x=%v, gradient norm %.4g",
+			x, opt.GradNorm)
+		return 100
+	}
+	unbounded := run(&LBFGS{})
+	bounded := run(&LBFGS{
+		Lower: []float64{0.7, math.Inf(-1)},
+		Upper: []float64{math.Inf(1), math.Inf(1)},
+	})
+	if bounded > 2*unbounded {
+		t.Errorf("bounded problem converged in %d steps, "+
+			"unbounded in %d", bounded, unbounded)
+	}
+}
@@ -0,0 +1,179 @@
+package infer
+
+// Laplace approximation of the posterior.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// LaplaceApprox is a Gaussian approximation of the posterior
+// around the mode, with the covariance equal to the negative
+// inverse Hessian of the log-likelihood at the mode.
+type LaplaceApprox struct {
+	Mean  []float64   // mode
+	Cov   [][]float64 // covariance
+	Scale [][]float64 // lower-triangular Cholesky factor of Cov
+	LogML float64     // estimate of the log marginal likelihood
+	Rng   *rand.Rand  // random number generator for Draw
+}
+
+// Laplace finds the mode of m with Optimize, starting at x,
+// and returns the Laplace approximation around the mode. The
+// Hessian of a model differentiated by deriv is computed
+// exactly by ad.Hessian if the Hessians of all elementals
+// called by the model are registered. Otherwise, the Hessian is
+// computed by central finite differences of the gradient. An
+// error is returned if the Hessian at the mode is not negative
+// definite.
+func Laplace(
+	opt Grad,
+	m model.Model, x []float64,
+	niter, nplateau int,
+	eps float64,
+) (*LaplaceApprox, error) {
+	x = clone(x)
+	_, _, ll := Optimize(opt, m, x, niter, nplateau, eps)
+	// The log-likelihood returned by Optimize is computed
+	// before the step of the best iteration, recompute it at
+	// the mode.
+	ll, _ = observeGradient(m, x)
+
+	n := len(x)
+	l, err := cholesky(precision(m, x))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Hessian at the mode is not negative definite: %v", err)
+	}
+	cov := invert(l)
+	scale, err := cholesky(cov)
+	if err != nil {
+		return nil, fmt.Errorf("covariance is degenerate: %v", err)
+	}
+
+	// log Z ≈ log p(x*) + n/2 log 2π - 1/2 log det P.
+	logml := ll + 0.5*float64(n)*math.Log(2*math.Pi)
+	for i := range l {
+		logml -= math.Log(l[i][i])
+	}
+
+	return &LaplaceApprox{
+		Mean:  x,
+		Cov:   cov,
+		Scale: scale,
+		LogML: logml,
+	}, nil
+}
+
+// Draw draws from the approximation.
+func (la *LaplaceApprox) Draw() []float64 {
+	if la.Rng == nil {
+		la.Rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+	z := make([]float64, len(la.Mean))
+	for i := range z {
+		z[i] = la.Rng.NormFloat64()
+	}
+	x := make([]float64, len(la.Mean))
+	for i := range x {
+		x[i] = la.Mean[i]
+		for j := 0; j <= i; j++ {
+			x[i] += la.Scale[i][j] * z[j]
+		}
+	}
+	return x
+}
+
+// precision returns the precision, the negative Hessian of the
+// log-likelihood of m at x, symmetrized.
+func precision(m model.Model, x []float64) [][]float64 {
+	n := len(x)
+	p := make([][]float64, n)
+	if h := exactHessian(m, x); h != nil {
+		for i := range p {
+			p[i] = make([]float64, n)
+			for j := range p[i] {
+				p[i][j] = -h[i][j]
+			}
+		}
+		return p
+	}
+	for i := range p {
+		p[i] = make([]float64, n)
+	}
+	for j := range x {
+		h := 1e-5 * math.Max(1, math.Abs(x[j]))
+		xj := x[j]
+		x[j] = xj + h
+		_, gplus := observeGradient(m, x)
+		x[j] = xj - h
+		_, gminus := observeGradient(m, x)
+		x[j] = xj
+		for i := range p {
+			d := -(gplus[i] - gminus[i]) / (2 * h)
+			p[i][j] += 0.5 * d
+			p[j][i] += 0.5 * d
+		}
+	}
+	return p
+}
+
+// exactHessian returns the Hessian of m at x computed by
+// ad.Hessian, or nil if m is not differentiated by deriv or
+// calls an elemental without a registered Hessian.
+func exactHessian(m model.Model, x []float64) (h [][]float64) {
+	if _, ok := m.(model.ElementalModel); ok {
+		return nil
+	}
+	if _, ok := m.(model.TapeModel); !ok {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			h = nil
+		}
+	}()
+	return ad.Hessian(m, x)
+}
+
+// observeGradient returns the log-likelihood and a copy of the
+// gradient of m at x.
+func observeGradient(m model.Model, x []float64) (float64, []float64) {
+	l := m.Observe(x)
+	return l, clone(model.Gradient(m))
+}
+
+// invert returns the inverse of matrix LL', given the
+// lower-triangular Cholesky factor l.
+func invert(l [][]float64) [][]float64 {
+	n := len(l)
+	// Invert L by forward substitution, column by column.
+	linv := make([][]float64, n)
+	for i := range linv {
+		linv[i] = make([]float64, n)
+	}
+	for j := 0; j != n; j++ {
+		linv[j][j] = 1 / l[j][j]
+		for i := j + 1; i != n; i++ {
+			s := 0.
+			for k := j; k != i; k++ {
+				s -= l[i][k] * linv[k][j]
+			}
+			linv[i][j] = s / l[i][i]
+		}
+	}
+	// (LL')⁻¹ = L'⁻¹L⁻¹
+	inv := make([][]float64, n)
+	for i := range inv {
+		inv[i] = make([]float64, n)
+		for j := range inv[i] {
+			for k := max(i, j); k != n; k++ {
+				inv[i][j] += linv[k][i] * linv[k][j]
+			}
+		}
+	}
+	return inv
+}
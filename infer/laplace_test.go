@@ -0,0 +1,154 @@
+package infer
+
+// Testing Laplace approximation.
+
+import (
+	"math"
+	"testing"
+)
+
+// A flat model, the Hessian of which is zero.
+type flatModel struct{}
+
+func (m *flatModel) Observe(x []float64) float64 {
+	return 0
+}
+
+func (m *flatModel) Gradient() []float64 {
+	return []float64{0, 0}
+}
+
+func TestInvert(t *testing.T) {
+	a := [][]float64{{4, 2, 0.4}, {2, 5, 1}, {0.4, 1, 3}}
+	l, err := cholesky(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv := invert(l)
+	for i := range a {
+		for j := range a {
+			p := 0.
+			for k := range a {
+				p += a[i][k] * inv[k][j]
+			}
+			want := 0.
+			if i == j {
+				want = 1
+			}
+			if math.Abs(p-want) > 1e-12 {
+				t.Errorf("wrong inverse: %v", inv)
+				return
+			}
+		}
+	}
+}
+
+func TestLaplace(t *testing.T) {
+	// For a Gaussian, the approximation is exact.
+	m := &gaussianModel{
+		mu: []float64{1, -2},
+		p:  [][]float64{{1 / 0.36, -0.8 / 0.36}, {-0.8 / 0.36, 1 / 0.36}},
+	}
+	la, err := Laplace(&Adam{Rate: 0.01}, m, []float64{0, 0},
+		10000, 10, 1e-12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cov := [][]float64{{1, 0.8}, {0.8, 1}}
+	for i := range la.Mean {
+		if math.Abs(la.Mean[i]-m.mu[i]) > 1e-3 {
+			t.Errorf("wrong mean: got %v, want %v", la.Mean, m.mu)
+			break
+		}
+	}
+	for i := range cov {
+		for j := range cov {
+			if math.Abs(la.Cov[i][j]-cov[i][j]) > 1e-4 {
+				t.Errorf("wrong covariance: got %v, want %v",
+					la.Cov, cov)
+			}
+		}
+	}
+	// The marginal likelihood is the normalization constant
+	// of the Gaussian, 2π sqrt(det cov).
+	logml := math.Log(2 * math.Pi * math.Sqrt(1-0.8*0.8))
+	if math.Abs(la.LogML-logml) > 1e-4 {
+		t.Errorf("wrong log marginal likelihood: got %.6g, want %.6g",
+			la.LogML, logml)
+	}
+	// The draws follow the approximation.
+	const N = 10000
+	var mean [2]float64
+	var c01 float64
+	for n := 0; n != N; n++ {
+		x := la.Draw()
+		for i := range x {
+			mean[i] += x[i] / N
+		}
+		c01 += (x[0] - la.Mean[0]) * (x[1] - la.Mean[1]) / N
+	}
+	if math.Abs(mean[0]-la.Mean[0]) > 0.05 ||
+		math.Abs(mean[1]-la.Mean[1]) > 0.05 ||
+		math.Abs(c01-cov[0][1]) > 0.05 {
+		t.Errorf("wrong draws: mean %v, covariance %.4g", mean, c01)
+	}
+
+	// Differentiated models are approximated as well.
+	la, err = Laplace(&Momentum{Rate: 0.01, Gamma: 0.5},
+		&testModel{testData}, []float64{0, 0}, 1000, 10, 1e-9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(la.Mean[0]-testMean) > 1e-3 ||
+		math.Abs(math.Exp(la.Mean[1])-testStddev) > 1e-3 {
+		t.Errorf("wrong mode: got %v, want %.4g, log(%.4g)",
+			la.Mean, testMean, testStddev)
+	}
+	// The standard error of the mean is stddev/sqrt(n).
+	if se := math.Sqrt(la.Cov[0][0]); math.Abs(se-testStddev/
+		math.Sqrt(float64(len(testData)))) > 1e-3 {
+		t.Errorf("wrong standard error: got %.4g, want %.4g",
+			se, testStddev/math.Sqrt(float64(len(testData))))
+	}
+
+	// The Hessian of a model differentiated by deriv is exact,
+	// and so is the covariance of the approximation.
+	if exactHessian(&testModel{testData}, []float64{0, 0}) != nil {
+		t.Errorf("exact Hessian of a model without ObserveTape")
+	}
+	la, err = Laplace(&Momentum{Rate: 0.01, Gamma: 0.5},
+		&tapeTestModel{testData}, []float64{0, 0}, 1000, 10, 1e-9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The precision of the mean and of the log stddev of the
+	// Normal, at the mode.
+	mu, stddev := la.Mean[0], math.Exp(la.Mean[1])
+	n, s1, s2 := float64(len(testData)), 0., 0.
+	for _, y := range testData {
+		s1 += y - mu
+		s2 += (y - mu) * (y - mu)
+	}
+	p := [][]float64{
+		{n / (stddev * stddev), 2 * s1 / (stddev * stddev)},
+		{2 * s1 / (stddev * stddev), 2 * s2 / (stddev * stddev)},
+	}
+	det := p[0][0]*p[1][1] - p[0][1]*p[1][0]
+	cov = [][]float64{
+		{p[1][1] / det, -p[0][1] / det},
+		{-p[1][0] / det, p[0][0] / det},
+	}
+	for i := range cov {
+		for j := range cov {
+			if math.Abs(la.Cov[i][j]-cov[i][j]) > 1e-12 {
+				t.Errorf("wrong covariance: got %v, want %v",
+					la.Cov, cov)
+			}
+		}
+	}
+
+	if _, err := Laplace(&Adam{}, &flatModel{}, []float64{0, 0},
+		10, 10, 0); err == nil {
+		t.Errorf("no error for a flat model")
+	}
+}
@@ -0,0 +1,111 @@
+package infer
+
+// Metropolis-adjusted Langevin algorithm.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"log"
+	"math"
+)
+
+// Metropolis-adjusted Langevin algorithm
+// (https://doi.org/10.2307/3318418). A Langevin step with
+// Metropolis-Hastings correction is a single leapfrog step of
+// HMC from freshly drawn momentum, and MALA is implemented as
+// such.
+type MALA struct {
+	Sampler
+	adaptation
+	// Parameters
+	Eps    float64 // step size
+	Metric Metric  // inverse mass matrix, identity if nil
+	Delta  float64 // bound on energy error for divergence
+	// Statistics
+	AccStat float64 // acceptance probability of the latest iteration
+}
+
+func (mala *MALA) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	mala.setDefaults()
+	mala.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: MALA: %v", r)
+			}
+		}()
+		r := make([]float64, len(x))
+		x_ := make([]float64, len(x))
+		// The log-likelihood and the gradient at x are reused
+		// until a proposal is accepted. Elemental models may
+		// reuse the gradient buffer, hence the gradient is
+		// cloned.
+		l0, grad := m.Observe(x), clone(model.Gradient(m))
+		for {
+			if mala.stopped() {
+				break
+			}
+			// The parameters may be adapted concurrently and
+			// are fixed for the iteration.
+			eps, metric := mala.load(&mala.Eps, &mala.Metric)
+			momentum(mala.Rng, metric, r)
+			e0 := energy(l0, r, velocity(metric, r))
+			copy(x_, x)
+			l, grad_ := leapfrog(m, metric, grad, x, r, eps)
+			e := energy(l, r, velocity(metric, r))
+
+			// Accept with MH probability. A divergent
+			// transition is rejected.
+			div := diverged(e, e0, mala.Delta)
+			if div {
+				mala.diverge(x_)
+				mala.AccStat = 0
+			} else {
+				mala.AccStat = math.Min(1, math.Exp(e-e0))
+			}
+			if !div && mala.accept(e-e0) {
+				l0, grad = l, clone(grad_)
+				mala.NAcc++
+			} else {
+				// Rejected, restore x.
+				x, x_ = x_, x
+				mala.NRej++
+			}
+
+			// Write a sample to the channel.
+			// x is modified in place by leapfrog and
+			// therefore must be cloned.
+			sample := clone(x)
+			mala.record(sample, mala.AccStat, samples)
+			samples <- sample
+		}
+	}()
+}
+
+// parameters returns the parameters for adaptation. A part of
+// the Hamiltonian interface.
+func (mala *MALA) parameters() (*float64, *Metric) {
+	return &mala.Eps, &mala.Metric
+}
+
+// setDefaults sets the default value for auxiliary parameters.
+func (mala *MALA) setDefaults() {
+	mala.Sampler.setDefaults()
+	if mala.Delta == 0 {
+		mala.Delta = 1e3
+	}
+}
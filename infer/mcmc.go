@@ -4,11 +4,13 @@ package infer
 
 import (
 	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/mathx"
 	"bitbucket.org/dtolpin/infergo/model"
 	"log"
 	"math"
 	"math/rand"
-	"time"
+	"sync"
+	"sync/atomic"
 )
 
 // MCMC is the interface of MCMC samplers.
@@ -21,13 +23,140 @@ type MCMC interface {
 	Stop()
 }
 
+// Hamiltonian is the interface of HMC variants, the
+// parameters of which can be adapted (see adapt.go).
+type Hamiltonian interface {
+	MCMC
+	// parameters returns the addresses of the step size and
+	// of the metric, accessed through tuning.
+	parameters() (eps *float64, metric *Metric)
+	// tuning returns the structure synchronizing the sampler
+	// with the adapters.
+	tuning() *adaptation
+}
+
 // Sampler is the structure for embedding into concrete
 // samplers.
 type Sampler struct {
 	Stopped bool
+	stop    int32 // set atomically by Stop, polled by the sampler
 	Samples chan []float64
+	// Random number generator; if nil, a generator seeded from
+	// the global source is created. A sampler with a generator
+	// of a fixed seed reproduces the same samples. The generator
+	// must not be shared by concurrently running samplers.
+	Rng *rand.Rand
+	// Maximum number of divergent transitions recorded in
+	// Divergences; 100 if 0. Further divergent transitions are
+	// only counted in NDiv.
+	MaxDivergences int
 	// Statistics
-	NAcc, NRej int // the number of accepted and rejected samples
+	NAcc, NRej  int          // the number of accepted and rejected samples
+	NDiv        int          // the number of divergent transitions
+	Divergences []Divergence // the first divergent transitions
+}
+
+// Divergence records a divergent transition of an HMC variant:
+// the energy error exceeded the bound, or the log-likelihood
+// was not finite.
+type Divergence struct {
+	Iter int       // iteration, starting from 0
+	X    []float64 // state at the beginning of the transition
+}
+
+// adaptation is the structure for embedding into HMC variants.
+// The adapters run in a goroutine of their own, and the
+// parameters are read and written under the lock. The
+// acceptance statistic of each sample is handed over under the
+// lock as well.
+type adaptation struct {
+	mu sync.Mutex
+	// Acceptance statistics of the samples in flight, keyed by
+	// the sample. A rejected sample of NUTS is the same slice
+	// as the previous one, hence a queue per sample.
+	accs  map[*float64][]float64
+	order []*float64 // keys in the order of sending
+}
+
+// tuning returns the adaptation. A part of the Hamiltonian
+// interface.
+func (a *adaptation) tuning() *adaptation {
+	return a
+}
+
+// load returns the step size and the metric stored at eps and
+// metric.
+func (a *adaptation) load(eps *float64, metric *Metric) (float64, Metric) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return *eps, *metric
+}
+
+// setEps stores step size value at eps.
+func (a *adaptation) setEps(eps *float64, value float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	*eps = value
+}
+
+// setMetric stores metric value at metric.
+func (a *adaptation) setMetric(metric *Metric, value Metric) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	*metric = value
+}
+
+// record records acceptance statistic acc of sample x, before
+// x is sent to samples. The statistics of at most cap(samples)+2
+// samples are kept: the samples in the channel, the sample being
+// sent, and the sample just received.
+func (a *adaptation) record(x []float64, acc float64, samples chan []float64) {
+	if len(x) == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.accs == nil {
+		a.accs = make(map[*float64][]float64)
+	}
+	a.accs[&x[0]] = append(a.accs[&x[0]], acc)
+	a.order = append(a.order, &x[0])
+	if len(a.order) > cap(samples)+2 {
+		a.pop(a.order[0])
+	}
+}
+
+// acceptance returns the acceptance statistic of sample x,
+// which must have just been received from the samples, or 0
+// if the statistic was not recorded.
+func (a *adaptation) acceptance(x []float64) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.pop(&x[0])
+}
+
+// pop removes and returns the earliest recorded statistic for
+// key; called under the lock.
+func (a *adaptation) pop(key *float64) float64 {
+	accs, ok := a.accs[key]
+	if !ok {
+		return 0
+	}
+	if len(accs) == 1 {
+		delete(a.accs, key)
+	} else {
+		a.accs[key] = accs[1:]
+	}
+	for i := range a.order {
+		if a.order[i] == key {
+			a.order = append(a.order[:i], a.order[i+1:]...)
+			break
+		}
+	}
+	return accs[0]
 }
 
 // Helper functions
@@ -37,32 +166,60 @@ type Sampler struct {
 // to differentiated code. A part of the MCMC interface.
 func (s *Sampler) Stop() {
 	s.Stopped = true
+	atomic.StoreInt32(&s.stop, 1)
 	// The differentiated code is not necessarily thread-safe,
 	// hence we must exhaust samples before returning from Stop,
 	// so that an Observe called afterwards does not overlap
-	// with an Observe called in the sampler.
-	for {
-		select {
-		case _, ok := <-s.Samples:
-			if !ok { // channel closed, safe to leave
-				return
-			}
-			// Discard the sample and continue.
-		default:
-			// No data but the channel is open, the sampler is
-			// computing a sample. Wait for the computation to
-			// complete.
-			time.Sleep(1000) // one millisecond
-		}
+	// with an Observe called in the sampler. The sampler
+	// closes the channel on exit; the samples are discarded.
+	for range s.Samples {
 	}
 }
 
-// energy computes the energy of a particle; used
-// by HMC variants.
-func energy(l float64, r []float64) float64 {
+// stopped returns true if Stop was called; safe to call from
+// the goroutine of the sampler.
+func (s *Sampler) stopped() bool {
+	return atomic.LoadInt32(&s.stop) != 0
+}
+
+// stats returns the sampler, for reading the statistics of a
+// concrete sampler embedding Sampler.
+func (s *Sampler) stats() *Sampler {
+	return s
+}
+
+// setDefaults creates the random number generator if not set,
+// and sets the default value for MaxDivergences.
+func (s *Sampler) setDefaults() {
+	if s.Rng == nil {
+		s.Rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+	if s.MaxDivergences == 0 {
+		s.MaxDivergences = 100
+	}
+}
+
+// diverge records a divergent transition starting at x.
+func (s *Sampler) diverge(x []float64) {
+	s.NDiv++
+	if len(s.Divergences) < s.MaxDivergences {
+		s.Divergences = append(s.Divergences,
+			Divergence{Iter: s.NAcc + s.NRej, X: clone(x)})
+	}
+}
+
+// diverged returns true iff energy e is not finite or is lower
+// than energy e0 by more than delta.
+func diverged(e, e0, delta float64) bool {
+	return math.IsNaN(e) || math.IsInf(e, 0) || e0-e > delta
+}
+
+// energy computes the energy of a particle with momentum r
+// and velocity v; used by HMC variants.
+func energy(l float64, r, v []float64) float64 {
 	k := 0.
-	for _, ri := range r {
-		k += ri * ri
+	for i := range r {
+		k += r[i] * v[i]
 	}
 	return l - 0.5*k
 }
@@ -71,18 +228,19 @@ func energy(l float64, r []float64) float64 {
 // by HMC variants.
 func leapfrog(
 	m model.Model,
+	metric Metric,
 	grad []float64,
 	x, r []float64,
 	eps float64,
 ) (l float64, _ []float64) {
 	for i := range x {
 		r[i] += 0.5 * eps * grad[i]
-		x[i] += eps * r[i]
 	}
-	l, grad = m.Observe(x), model.Gradient(m)
-	if math.IsNaN(l) {
-		panic("energy diverged")
+	v := velocity(metric, r)
+	for i := range x {
+		x[i] += eps * v[i]
 	}
+	l, grad = m.Observe(x), model.Gradient(m)
 	for i := range x {
 		r[i] += 0.5 * eps * grad[i]
 	}
@@ -101,9 +259,14 @@ func clone(x []float64) []float64 {
 // Vanilla Hamiltonian Monte Carlo Sampler.
 type HMC struct {
 	Sampler
+	adaptation
 	// Parameters
-	L   int     // number of leapfrog steps
-	Eps float64 // leapfrog step size
+	L      int     // number of leapfrog steps
+	Eps    float64 // leapfrog step size
+	Metric Metric  // inverse mass matrix, identity if nil
+	Delta  float64 // bound on energy error for divergence
+	// Statistics
+	AccStat float64 // acceptance probability of the latest iteration
 }
 
 func (hmc *HMC) Sample(
@@ -114,6 +277,9 @@ func (hmc *HMC) Sample(
 	hmc.setDefaults()
 	hmc.Samples = samples // Stop needs access to samples
 	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
 		// On exit:
 		// * drop the tape;
 		defer ad.DropTape()
@@ -129,25 +295,37 @@ func (hmc *HMC) Sample(
 		r := make([]float64, len(x))
 		x_ := make([]float64, len(x))
 		for {
-			if hmc.Stopped {
+			if hmc.stopped() {
 				break
 			}
-			// Sample the next r.
-			for i := range r {
-				r[i] = rand.NormFloat64()
-			}
+			// Sample the next r. The parameters may be
+			// adapted concurrently and are fixed for the
+			// iteration.
+			eps, metric := hmc.load(&hmc.Eps, &hmc.Metric)
+			momentum(hmc.Rng, metric, r)
 
 			l0, grad := m.Observe(x), model.Gradient(m)
-			e0 := energy(l0, r) // initial energy
+			e0 := energy(l0, r, velocity(metric, r)) // initial energy
 			var l float64
 			copy(x_, x)
 			for i := 0; i != hmc.L; i++ {
-				l, grad = leapfrog(m, grad, x, r, hmc.Eps)
+				l, grad = leapfrog(m, metric, grad, x, r, eps)
+				if math.IsNaN(l) || math.IsInf(l, 0) {
+					break
+				}
 			}
-			e := energy(l, r) // final energy
-
-			// Accept with MH probability.
-			if e-e0 >= math.Log(1-rand.Float64()) {
+			e := energy(l, r, velocity(metric, r)) // final energy
+
+			// Accept with MH probability. A divergent
+			// transition is rejected.
+			div := diverged(e, e0, hmc.Delta)
+			if div {
+				hmc.diverge(x_)
+				hmc.AccStat = 0
+			} else {
+				hmc.AccStat = math.Min(1, math.Exp(e-e0))
+			}
+			if !div && e-e0 >= math.Log(1-hmc.Rng.Float64()) {
 				hmc.NAcc++
 			} else {
 				// Rejected, restore x.
@@ -158,24 +336,38 @@ func (hmc *HMC) Sample(
 			// Write a sample to the channel.
 			// x is modified in place by leapfrog and
 			// therefore must be cloned.
-			samples <- clone(x)
+			sample := clone(x)
+			hmc.record(sample, hmc.AccStat, samples)
+			samples <- sample
 		}
 	}()
 }
 
+// parameters returns the parameters for adaptation. A part of
+// the Hamiltonian interface.
+func (hmc *HMC) parameters() (*float64, *Metric) {
+	return &hmc.Eps, &hmc.Metric
+}
+
 // setDefaults sets the default value for auxiliary parameters.
 func (hmc *HMC) setDefaults() {
+	hmc.Sampler.setDefaults()
 	if hmc.L == 0 {
 		hmc.L = 10
 	}
+	if hmc.Delta == 0 {
+		hmc.Delta = 1e3
+	}
 }
 
 // No U-Turn Sampler (https://arxiv.org/abs/1111.4246).
 type NUTS struct {
 	Sampler
+	adaptation
 	// Parameters
 	Eps      float64 // step size
-	Delta    float64 // lower bound on energy for doubling
+	Metric   Metric  // inverse mass matrix, identity if nil
+	Delta    float64 // bound on energy error for divergence
 	MaxDepth int     // maximum depth
 	// Statistics
 	// Depth belief is encoded as a vector of beta-bernoulli
@@ -183,10 +375,20 @@ type NUTS struct {
 	// index i, Depth[i][0] is incremented; for index depth,
 	// Depth[depth][1] is incremented.
 	Depth [][2]float64 // depth belief
+	// Acceptance statistic of the latest iteration, the mean
+	// acceptance probability of the states in the trajectory.
+	AccStat float64
 	// Cached model run
 	x    []float64
 	l    float64
 	grad []float64
+	// Parameters of the current iteration
+	eps    float64
+	metric Metric
+	// Acceptance statistic of the current iteration
+	e0, alpha, nalpha float64
+	// Whether the current iteration diverged
+	diverged bool
 }
 
 func (nuts *NUTS) Sample(
@@ -198,6 +400,9 @@ func (nuts *NUTS) Sample(
 	nuts.Samples = samples // Stop needs access to samples
 	nuts.x = nil           // invalidate gradient cache
 	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
 		// On exit:
 		// * drop the tape;
 		defer ad.DropTape()
@@ -213,21 +418,25 @@ func (nuts *NUTS) Sample(
 
 		r := make([]float64, len(x))
 		for {
-			if nuts.Stopped {
+			if nuts.stopped() {
 				break
 			}
 
-			// Sample the next r.
-			for i := range r {
-				r[i] = rand.NormFloat64()
-			}
+			// Sample the next r. The parameters may be
+			// adapted concurrently and are fixed for the
+			// iteration.
+			nuts.eps, nuts.metric = nuts.load(&nuts.Eps, &nuts.Metric)
+			momentum(nuts.Rng, nuts.metric, r)
 
 			// Compute the energy.
 			l, _ := nuts.observe(m, x)
-			e := energy(l, r)
+			e := energy(l, r, velocity(nuts.metric, r))
+			nuts.e0, nuts.alpha, nuts.nalpha = e, 0, 0
+			nuts.diverged = false
+			x0 := x
 
 			// Sample the slice variable
-			logu := math.Log((1 - rand.Float64())) + e
+			logu := math.Log((1 - nuts.Rng.Float64())) + e
 
 			// Initialize the tree
 			xl, rl, xr, rr, depth, nelem := x, r, x, r, 0, 1.
@@ -238,7 +447,7 @@ func (nuts *NUTS) Sample(
 
 				// Choose direction.
 				var dir float64
-				if rand.Float64() < 0.5 {
+				if nuts.Rng.Float64() < 0.5 {
 					dir = -1
 				} else {
 					dir = 1
@@ -257,7 +466,7 @@ func (nuts *NUTS) Sample(
 				}
 
 				// Accept or reject
-				if nelem_/nelem > rand.Float64() {
+				if nelem_/nelem > nuts.Rng.Float64() {
 					accepted = true
 					x = x_
 				}
@@ -272,16 +481,25 @@ func (nuts *NUTS) Sample(
 			}
 
 			// Collect statistics
+			if nuts.diverged {
+				nuts.diverge(x0)
+			}
 			if accepted {
 				nuts.NAcc++
 			} else {
 				nuts.NRej++
 			}
 			nuts.updateDepth(depth)
+			if nuts.nalpha > 0 {
+				nuts.AccStat = nuts.alpha / nuts.nalpha
+			} else {
+				nuts.AccStat = 0
+			}
 
 			// Write a sample to the channel.
 			// x need not be cloned here since it is cloned
 			// before the call to leapfrog.
+			nuts.record(x, nuts.AccStat, samples)
 			samples <- x
 		}
 	}()
@@ -306,7 +524,8 @@ func (nuts *NUTS) buildLeftOrRightTree(
 			nuts.buildTree(m, xr, rr, logu, dir, depth)
 	}
 
-	if uTurn(xl, xr, rl) || uTurn(xl, xr, rr) {
+	if uTurn(xl, xr, velocity(nuts.metric, rl)) ||
+		uTurn(xl, xr, velocity(nuts.metric, rr)) {
 		stop = true
 	}
 
@@ -329,13 +548,19 @@ func (nuts *NUTS) buildTree(
 		// are copied because leapfrog modifies them in place.
 		x, r := clone(x), clone(r)
 		_, grad := nuts.observe(m, x)
-		l, grad := leapfrog(m, grad, x, r, dir*nuts.Eps)
+		l, grad := leapfrog(m, nuts.metric, grad, x, r, dir*nuts.eps)
 		// Cache model run inside leapfrog
 		nuts.x, nuts.l, nuts.grad = x, l, grad
-		if energy(l, r) >= logu {
+		e := energy(l, r, velocity(nuts.metric, r))
+		if !math.IsNaN(e) {
+			nuts.alpha += math.Min(1, math.Exp(e-nuts.e0))
+		}
+		nuts.nalpha++
+		if e >= logu {
 			nelem = 1
 		}
-		if energy(l, r)+nuts.Delta <= logu {
+		if diverged(e, logu, nuts.Delta) {
+			nuts.diverged = true
 			stop = true
 		}
 		return x, r, x, r, x, nelem, stop
@@ -354,7 +579,7 @@ func (nuts *NUTS) buildTree(
 		nelem += nelem_
 
 		// Select uniformly from nodes.
-		if nelem_/nelem > rand.Float64() {
+		if nelem_/nelem > nuts.Rng.Float64() {
 			x = x_
 		}
 
@@ -385,8 +610,15 @@ func (nuts *NUTS) observe(m model.Model, x []float64) (
 	return nuts.l, nuts.grad
 }
 
+// parameters returns the parameters for adaptation. A part of
+// the Hamiltonian interface.
+func (nuts *NUTS) parameters() (*float64, *Metric) {
+	return &nuts.Eps, &nuts.Metric
+}
+
 // setDefaults sets the default value for auxiliary parameters.
 func (nuts *NUTS) setDefaults() {
+	nuts.Sampler.setDefaults()
 	if nuts.Delta == 0 {
 		nuts.Delta = 1e3
 	}
@@ -416,13 +648,243 @@ func (nuts *NUTS) MeanDepth() float64 {
 	return meanDepth
 }
 
-// uTurn returns true iff there is a u-turn.
-func uTurn(xl, xr, r []float64) bool {
-	// Dot product of changes and moment to
+// uTurn returns true iff there is a u-turn. v is the velocity
+// at either end.
+func uTurn(xl, xr, v []float64) bool {
+	// Dot product of changes and velocity to
 	// stop on U-turn.
 	dot := 0.
 	for i := range xl {
-		dot += (xr[i] - xl[i]) * r[i]
+		dot += (xr[i] - xl[i]) * v[i]
 	}
 	return dot < 0
 }
+
+// Multinomial No U-Turn Sampler (https://arxiv.org/abs/1701.02434).
+// The proposal is drawn from the whole trajectory with
+// probabilities proportional to the joint density, through
+// biased progressive sampling when the trajectory doubles, and
+// uniform progressive sampling within the subtrees. The
+// trajectory stops on the generalized no-U-turn criterion. The
+// parameters and the statistics, including Depth, are those of
+// NUTS; Delta bounds the energy error of a trajectory.
+type MNUTS struct {
+	NUTS
+}
+
+// mtree is a subtree of a multinomial NUTS trajectory.
+type mtree struct {
+	xl, rl, gl []float64 // leftmost state, momentum, gradient
+	xr, rr, gr []float64 // rightmost state, momentum, gradient
+	x, g       []float64 // proposal and its gradient
+	l          float64   // log-likelihood of the proposal
+	logw       float64   // log of the sum of weights
+	rho        []float64 // sum of momenta
+}
+
+func (nuts *MNUTS) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	nuts.setDefaults()
+	nuts.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: MNUTS: %v", r)
+			}
+		}()
+
+		l, grad := m.Observe(x), model.Gradient(m)
+		for {
+			if nuts.stopped() {
+				break
+			}
+
+			// Sample the next r. The parameters may be
+			// adapted concurrently and are fixed for the
+			// iteration.
+			nuts.eps, nuts.metric = nuts.load(&nuts.Eps, &nuts.Metric)
+			r := make([]float64, len(x))
+			momentum(nuts.Rng, nuts.metric, r)
+
+			// The trajectory starts as a single state.
+			e := energy(l, r, velocity(nuts.metric, r))
+			nuts.e0, nuts.alpha, nuts.nalpha = e, 0, 0
+			nuts.diverged = false
+			tree := &mtree{
+				xl: x, rl: r, gl: grad,
+				xr: x, rr: r, gr: grad,
+				x: x, g: grad, l: l,
+				logw: 0,
+				rho:  clone(r),
+			}
+
+			depth := 0
+			for {
+				// Choose direction.
+				var dir float64
+				if nuts.Rng.Float64() < 0.5 {
+					dir = -1
+				} else {
+					dir = 1
+				}
+
+				// Build a subtree of the same size in the
+				// chosen direction.
+				var subtree *mtree
+				if dir == -1 {
+					subtree = nuts.buildTree(m,
+						tree.xl, tree.rl, tree.gl, dir, depth)
+				} else {
+					subtree = nuts.buildTree(m,
+						tree.xr, tree.rr, tree.gr, dir, depth)
+				}
+				if subtree == nil {
+					break
+				}
+
+				// Biased progressive sampling: prefer the
+				// new subtree.
+				if math.Log(1-nuts.Rng.Float64()) <
+					subtree.logw-tree.logw {
+					tree.x, tree.g, tree.l =
+						subtree.x, subtree.g, subtree.l
+				}
+				tree.logw = mathx.LogSumExp(tree.logw, subtree.logw)
+				if nuts.merge(tree, subtree, dir) {
+					break
+				}
+
+				depth++
+				// Maximum depth of 0 (which is the default)
+				// means unlimited depth.
+				if depth == nuts.MaxDepth {
+					break
+				}
+			}
+
+			// Collect statistics
+			if nuts.diverged {
+				nuts.diverge(x)
+			}
+			if &tree.x[0] != &x[0] {
+				nuts.NAcc++
+			} else {
+				nuts.NRej++
+			}
+			nuts.updateDepth(depth)
+			if nuts.nalpha > 0 {
+				nuts.AccStat = nuts.alpha / nuts.nalpha
+			} else {
+				nuts.AccStat = 0
+			}
+
+			// Write a sample to the channel.
+			// x need not be cloned here since it is cloned
+			// before the call to leapfrog.
+			x, grad, l = tree.x, tree.g, tree.l
+			nuts.record(x, nuts.AccStat, samples)
+			samples <- x
+		}
+	}()
+}
+
+// buildTree builds a subtree of 2^depth states starting from x,
+// r, grad in direction dir. buildTree returns nil if the
+// subtree diverges or turns.
+func (nuts *MNUTS) buildTree(
+	m model.Model,
+	x, r, grad []float64,
+	dir float64,
+	depth int,
+) *mtree {
+	if depth == 0 {
+		// Base case: single leapfrog. State x and momentum r
+		// are copied because leapfrog modifies them in place.
+		x, r := clone(x), clone(r)
+		l, grad := leapfrog(m, nuts.metric, grad, x, r, dir*nuts.eps)
+		e := energy(l, r, velocity(nuts.metric, r))
+		if !math.IsNaN(e) {
+			nuts.alpha += math.Min(1, math.Exp(e-nuts.e0))
+		}
+		nuts.nalpha++
+		if diverged(e, nuts.e0, nuts.Delta) {
+			nuts.diverged = true
+			return nil
+		}
+		return &mtree{
+			xl: x, rl: r, gl: grad,
+			xr: x, rr: r, gr: grad,
+			x: x, g: grad, l: l,
+			logw: e - nuts.e0,
+			rho:  clone(r),
+		}
+	}
+
+	depth--
+	tree := nuts.buildTree(m, x, r, grad, dir, depth)
+	if tree == nil {
+		return nil
+	}
+	var subtree *mtree
+	if dir == -1 {
+		subtree = nuts.buildTree(m, tree.xl, tree.rl, tree.gl, dir, depth)
+	} else {
+		subtree = nuts.buildTree(m, tree.xr, tree.rr, tree.gr, dir, depth)
+	}
+	if subtree == nil {
+		return nil
+	}
+
+	// Uniform progressive sampling: select from the states
+	// proportionally to the weights.
+	logw := mathx.LogSumExp(tree.logw, subtree.logw)
+	if math.Log(1-nuts.Rng.Float64()) < subtree.logw-logw {
+		tree.x, tree.g, tree.l = subtree.x, subtree.g, subtree.l
+	}
+	tree.logw = logw
+	if nuts.merge(tree, subtree, dir) {
+		return nil
+	}
+	return tree
+}
+
+// merge merges subtree, built in direction dir, into tree, and
+// returns true iff the merged tree satisfies the generalized
+// U-turn criterion.
+func (nuts *MNUTS) merge(tree, subtree *mtree, dir float64) bool {
+	if dir == -1 {
+		tree.xl, tree.rl, tree.gl = subtree.xl, subtree.rl, subtree.gl
+	} else {
+		tree.xr, tree.rr, tree.gr = subtree.xr, subtree.rr, subtree.gr
+	}
+	for i := range tree.rho {
+		tree.rho[i] += subtree.rho[i]
+	}
+	return generalizedUTurn(tree.rho,
+		velocity(nuts.metric, tree.rl),
+		velocity(nuts.metric, tree.rr))
+}
+
+// generalizedUTurn returns true iff the trajectory with the
+// sum of momenta rho and velocities vl, vr at the ends turns.
+func generalizedUTurn(rho, vl, vr []float64) bool {
+	dotl, dotr := 0., 0.
+	for i := range rho {
+		dotl += rho[i] * vl[i]
+		dotr += rho[i] * vr[i]
+	}
+	return dotl <= 0 || dotr <= 0
+}
@@ -4,7 +4,7 @@ package infer
 
 import (
 	"bitbucket.org/dtolpin/infergo/ad"
-	. "bitbucket.org/dtolpin/infergo/dist/ad"
+	"bitbucket.org/dtolpin/infergo/dist/ad"
 	"bitbucket.org/dtolpin/infergo/model"
 	"math"
 	"math/rand"
@@ -27,7 +27,7 @@ func TestEnergy(t *testing.T) {
 		{1, []float64{0}, 1},
 		{1, []float64{1, 3}, -4},
 	} {
-		if e := energy(c.l, c.r); math.Abs(e-c.e) > 1e-6 {
+		if e := energy(c.l, c.r, c.r); math.Abs(e-c.e) > 1e-6 {
 			t.Errorf("incorrect energy for l=%v, r=%v: "+
 				"got=%.6g, want=%.6g", c.l, c.r, e, c.e)
 		}
@@ -42,7 +42,7 @@ func TestLeapfrog(t *testing.T) {
 	x, r, eps := []float64{0, 0}, []float64{1, -1}, 0.5
 	m.Observe(x)
 	grad := model.Gradient(m)
-	_, grad = leapfrog(m, grad, x, r, eps)
+	_, grad = leapfrog(m, nil, grad, x, r, eps)
 	xNext, rNext := []float64{0.5625, -0.3125}, []float64{1.25, -0.25}
 	for i := range x {
 		if math.Abs(x[i]-xNext[i]) > 1e-6 {
@@ -78,12 +78,38 @@ func (m *testModel) Observe(x []float64) float64 {
 		ad.Assignment(&ll, ad.Arithmetic(ad.OpAdd,
 			&ll,
 			ad.Call(func(_ []float64) {
-				Normal.Logp(0, 0, 0)
+				dist.Normal.Logp(0, 0, 0)
 			}, 3, &x[0], &stddev, &m.data[i])))
 	}
 	return ad.Return(&ll)
 }
 
+// testModel differentiated with the tape parameter, as by
+// deriv, to run on explicit tapes.
+type tapeTestModel struct {
+	data []float64
+}
+
+func (m *tapeTestModel) ObserveTape(tape *ad.Tape, x []float64) float64 {
+	tape.Setup(x)
+	var ll float64
+	tape.Assignment(&ll, tape.Value(0))
+	var stddev float64
+	tape.Assignment(&stddev, tape.Elemental(math.Exp, &x[1]))
+	for i := range m.data {
+		tape.Assignment(&ll, tape.Arithmetic(ad.OpAdd,
+			&ll,
+			tape.Call(func(_ []float64) {
+				dist.Normal.LogpTape(tape, 0, 0, 0)
+			}, 3, &x[0], &stddev, &m.data[i])))
+	}
+	return tape.Return(&ll)
+}
+
+func (m *tapeTestModel) Observe(x []float64) float64 {
+	return m.ObserveTape(ad.CurrentTape(), x)
+}
+
 // A small data set for testing.
 var (
 	testData             []float64
@@ -129,7 +155,14 @@ func repeatedly(
 func inferMeanStddev(
 	sampler MCMC, niter int,
 ) (mean, stddev float64) {
-	m := &testModel{testData}
+	return inferMeanStddevOf(&testModel{testData}, sampler, niter)
+}
+
+// inferMeanStddevOf infers the mean and standard deviation
+// using model m.
+func inferMeanStddevOf(
+	m model.Model, sampler MCMC, niter int,
+) (mean, stddev float64) {
 	x := []float64{0.1 * rand.NormFloat64(), 0.1 * rand.NormFloat64()}
 	samples := make(chan []float64)
 	sampler.Sample(m, x, samples)
@@ -187,6 +220,21 @@ func TestUTurn(t *testing.T) {
 	}
 }
 
+func TestGeneralizedUTurn(t *testing.T) {
+	for _, c := range []struct {
+		rho, vl, vr []float64
+		uturn       bool
+	}{
+		{[]float64{2, 0}, []float64{1, 0}, []float64{1, 1}, false},
+		{[]float64{2, 0}, []float64{1, 0}, []float64{-1, 1}, true},
+		{[]float64{0, 1}, []float64{-1, 1}, []float64{1, 0}, true},
+	} {
+		if generalizedUTurn(c.rho, c.vl, c.vr) != c.uturn {
+			t.Errorf("wrong uturn for %+v", c)
+		}
+	}
+}
+
 // Basic convergence of MCMC samplers. Empirical mean and stddev
 // should be around the inferred mean and stddev.
 func TestSamplers(t *testing.T) {
@@ -211,6 +259,174 @@ func TestSamplers(t *testing.T) {
 				}
 			},
 		},
+		{
+			func() MCMC {
+				return &HMC{
+					L:      5,
+					Eps:    0.1,
+					Metric: DiagMetric{0.1, 0.05},
+				}
+			},
+		},
+		{
+			func() MCMC {
+				return &MNUTS{NUTS{
+					Eps: 0.1,
+				}}
+			},
+		},
+		{
+			func() MCMC {
+				return &MALA{
+					Eps: 0.3,
+				}
+			},
+		},
+		{
+			func() MCMC {
+				metric, _ := NewDenseMetric(
+					[][]float64{{0.1, 0}, {0, 0.05}})
+				return &NUTS{
+					Eps:    0.1,
+					Metric: metric,
+				}
+			},
+		},
+	} {
+		if !repeatedly(nattempts,
+			func() bool {
+				mean, stddev := inferMeanStddev(c.sampler(), niter)
+				return math.Abs((mean-testMean)/
+					(mean+testMean)) <= prec &&
+					math.Abs((stddev-testStddev)/
+						(stddev+testStddev)) <= prec
+			},
+			true) {
+			t.Errorf("%T did not converge", c.sampler())
+		}
+	}
+}
+
+// Samplers run models with the tape parameter in parallel
+// without multithreading support in the tape store.
+func TestParallelSamplers(t *testing.T) {
+	if ad.IsMTSafe() {
+		t.Skip("the tape store is thread-safe")
+	}
+	nattempts := 10
+	niter := 100
+	prec := 1e-1
+	const N = 4
+	converged := make(chan bool, N)
+	for i := 0; i != N; i++ {
+		go func() {
+			converged <- repeatedly(nattempts,
+				func() bool {
+					mean, stddev := inferMeanStddevOf(
+						&tapeTestModel{testData},
+						&NUTS{Eps: 0.1}, niter)
+					return math.Abs((mean-testMean)/
+						(mean+testMean)) <= prec &&
+						math.Abs((stddev-testStddev)/
+							(stddev+testStddev)) <= prec
+				},
+				true)
+		}()
+	}
+	for i := 0; i != N; i++ {
+		if !<-converged {
+			t.Errorf("parallel NUTS did not converge")
+		}
+	}
+}
+
+// A model with bounded support, log(1 - x^2) is NaN for
+// |x| > 1.
+type boundedModel struct{}
+
+func (m *boundedModel) Observe(x []float64) float64 {
+	ad.Setup(x)
+	return ad.Return(ad.Elemental(math.Log,
+		ad.Arithmetic(ad.OpSub,
+			ad.Value(1), ad.Arithmetic(ad.OpMul, &x[0], &x[0]))))
+}
+
+func TestDiverged(t *testing.T) {
+	for _, c := range []struct {
+		e, e0    float64
+		diverged bool
+	}{
+		{-1, 0, false},
+		{-2000, 0, true},
+		{math.NaN(), 0, true},
+		{math.Inf(-1), 0, true},
+		{math.Inf(1), 0, true},
+	} {
+		if diverged(c.e, c.e0, 1e3) != c.diverged {
+			t.Errorf("wrong divergence for %+v", c)
+		}
+	}
+}
+
+// Divergent transitions are rejected and recorded, up to
+// MaxDivergences, and the chain goes on.
+func TestDivergences(t *testing.T) {
+	hmc := &HMC{L: 10, Eps: 0.3}
+	nuts := &NUTS{Eps: 0.3}
+	mnuts := &MNUTS{NUTS{Eps: 0.3}}
+	mala := &MALA{Eps: 0.3}
+	const maxDivergences = 3
+	for _, c := range []struct {
+		sampler MCMC
+		stats   *Sampler
+	}{
+		{hmc, &hmc.Sampler},
+		{nuts, &nuts.Sampler},
+		{mnuts, &mnuts.Sampler},
+		{mala, &mala.Sampler},
+	} {
+		sampler := c.sampler
+		c.stats.MaxDivergences = maxDivergences
+		samples := make(chan []float64)
+		sampler.Sample(&boundedModel{}, []float64{0}, samples)
+		for i := 0; i != 1000; i++ {
+			x := <-samples
+			if len(x) == 0 {
+				t.Fatalf("%T: chain ended", sampler)
+			}
+			if math.Abs(x[0]) >= 1 {
+				t.Errorf("%T: sample outside support: %v",
+					sampler, x)
+			}
+		}
+		sampler.Stop()
+		ndiv, divergences := c.stats.NDiv, c.stats.Divergences
+		ndivRecorded := ndiv
+		if ndivRecorded > maxDivergences {
+			ndivRecorded = maxDivergences
+		}
+		if ndiv == 0 || len(divergences) != ndivRecorded {
+			t.Errorf("%T: wrong divergences: %d, %v",
+				sampler, ndiv, divergences)
+		}
+		for _, d := range divergences {
+			if math.Abs(d.X[0]) >= 1 {
+				t.Errorf("%T: divergence outside support: %+v",
+					sampler, d)
+			}
+		}
+	}
+}
+
+func TestLangevin(t *testing.T) {
+	nattempts := 10
+	niter := 1000
+	prec := 1e-1
+	for _, c := range []struct {
+		sampler func() MCMC
+	}{
+		{func() MCMC { return &SGLD{Eta: 0.005} }},
+		{func() MCMC { return &PSGLD{Eta: 0.01} }},
 	} {
 		if !repeatedly(nattempts,
 			func() bool {
@@ -226,6 +442,67 @@ func TestSamplers(t *testing.T) {
 	}
 }
 
+func TestReproducible(t *testing.T) {
+	niter := 50
+	for _, c := range []struct {
+		sampler func(rng *rand.Rand) MCMC
+	}{
+		{func(rng *rand.Rand) MCMC {
+			return &HMC{Sampler: Sampler{Rng: rng}, L: 5, Eps: 0.1}
+		}},
+		{func(rng *rand.Rand) MCMC {
+			return &NUTS{Sampler: Sampler{Rng: rng}, Eps: 0.1}
+		}},
+		{func(rng *rand.Rand) MCMC {
+			return &MNUTS{NUTS{Sampler: Sampler{Rng: rng},
+				Metric: DiagMetric{0.1, 0.05}, Eps: 0.1}}
+		}},
+		{func(rng *rand.Rand) MCMC {
+			return &SgHMC{Sampler: Sampler{Rng: rng},
+				Eta: 0.01, Alpha: 0.1, V: 1}
+		}},
+		{func(rng *rand.Rand) MCMC {
+			return &MALA{Sampler: Sampler{Rng: rng}, Eps: 0.1}
+		}},
+		{func(rng *rand.Rand) MCMC {
+			return &SGLD{Sampler: Sampler{Rng: rng}, Eta: 0.01}
+		}},
+		{func(rng *rand.Rand) MCMC {
+			return &PSGLD{Sampler: Sampler{Rng: rng}, Eta: 0.01}
+		}},
+	} {
+		// draws returns niter draws of a sampler seeded with
+		// seed.
+		draws := func(seed int64) [][]float64 {
+			sampler := c.sampler(rand.New(rand.NewSource(seed)))
+			samples := make(chan []float64)
+			sampler.Sample(&testModel{testData}, []float64{0, 0}, samples)
+			draws := make([][]float64, niter)
+			for i := range draws {
+				draws[i] = clone(<-samples)
+			}
+			sampler.Stop()
+			return draws
+		}
+		draws1, draws2, draws3 := draws(1), draws(1), draws(2)
+		same, different := true, false
+		for i := range draws1 {
+			for j := range draws1[i] {
+				same = same && draws1[i][j] == draws2[i][j]
+				different = different || draws1[i][j] != draws3[i][j]
+			}
+		}
+		if !same {
+			t.Errorf("%T: different draws for the same seed",
+				c.sampler(nil))
+		}
+		if !different {
+			t.Errorf("%T: same draws for different seeds",
+				c.sampler(nil))
+		}
+	}
+}
+
 func TestNUTSDepth(t *testing.T) {
 	nuts := &NUTS{}
 	for _, c := range []struct {
@@ -291,6 +568,15 @@ func BenchmarkNutsEps005(b *testing.B) {
 	}
 }
 
+func BenchmarkMNutsEps01(b *testing.B) {
+	for i := 0; i != b.N; i++ {
+		inferMeanStddev(
+			&MNUTS{NUTS{
+				Eps: 0.1,
+			}}, BenchmarkNiter)
+	}
+}
+
 func BenchmarkHmcL10Eps01MTSafe(b *testing.B) {
 	ad.MTSafeOn()
 	BenchmarkHmcL10Eps01(b)
@@ -0,0 +1,130 @@
+package infer
+
+// Metrics of Hamiltonian Monte Carlo variants.
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Metric is the inverse mass matrix of HMC variants. The
+// momentum is distributed as N(0, M), where M is the mass
+// matrix, and the kinetic energy is r'M⁻¹r/2. A nil Metric is
+// the identity matrix.
+type Metric interface {
+	// Momentum draws momentum r using rng.
+	Momentum(rng *rand.Rand, r []float64)
+	// Velocity stores the velocity M⁻¹r into v.
+	Velocity(v, r []float64)
+}
+
+// DiagMetric is the diagonal of a diagonal inverse mass
+// matrix.
+type DiagMetric []float64
+
+// Momentum draws momentum r. A part of the Metric interface.
+func (metric DiagMetric) Momentum(rng *rand.Rand, r []float64) {
+	for i := range r {
+		r[i] = rng.NormFloat64() / math.Sqrt(metric[i])
+	}
+}
+
+// Velocity stores the velocity into v. A part of the Metric
+// interface.
+func (metric DiagMetric) Velocity(v, r []float64) {
+	for i := range r {
+		v[i] = metric[i] * r[i]
+	}
+}
+
+// DenseMetric is a dense inverse mass matrix.
+type DenseMetric struct {
+	Minv [][]float64 // inverse mass matrix
+	l    [][]float64 // Cholesky factor of Minv
+}
+
+// NewDenseMetric returns a dense metric for inverse mass
+// matrix minv, which must be symmetric positive definite.
+func NewDenseMetric(minv [][]float64) (*DenseMetric, error) {
+	l, err := cholesky(minv)
+	if err != nil {
+		return nil, err
+	}
+	return &DenseMetric{Minv: minv, l: l}, nil
+}
+
+// Momentum draws momentum r. A part of the Metric interface.
+func (metric *DenseMetric) Momentum(rng *rand.Rand, r []float64) {
+	// If M⁻¹ = LL', then r = L'⁻¹z, where z ~ N(0, I), has
+	// covariance L'⁻¹L⁻¹ = M. Solve L'r = z by back
+	// substitution.
+	l := metric.l
+	for i := range r {
+		r[i] = rng.NormFloat64()
+	}
+	for i := len(r) - 1; i >= 0; i-- {
+		for j := i + 1; j != len(r); j++ {
+			r[i] -= l[j][i] * r[j]
+		}
+		r[i] /= l[i][i]
+	}
+}
+
+// Velocity stores the velocity into v. A part of the Metric
+// interface.
+func (metric *DenseMetric) Velocity(v, r []float64) {
+	for i, row := range metric.Minv {
+		v[i] = 0
+		for j := range r {
+			v[i] += row[j] * r[j]
+		}
+	}
+}
+
+// cholesky returns the lower-triangular Cholesky factor of
+// symmetric positive definite matrix a.
+func cholesky(a [][]float64) ([][]float64, error) {
+	l := make([][]float64, len(a))
+	for i := range a {
+		l[i] = make([]float64, len(a))
+		for j := 0; j <= i; j++ {
+			s := a[i][j]
+			for k := 0; k != j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if s <= 0 {
+					return nil, fmt.Errorf(
+						"matrix is not positive definite")
+				}
+				l[i][i] = math.Sqrt(s)
+			} else {
+				l[i][j] = s / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
+
+// momentum draws momentum r for the metric using rng.
+func momentum(rng *rand.Rand, metric Metric, r []float64) {
+	if metric == nil {
+		for i := range r {
+			r[i] = rng.NormFloat64()
+		}
+		return
+	}
+	metric.Momentum(rng, r)
+}
+
+// velocity returns the velocity corresponding to momentum r.
+// For the identity metric, the velocity is the momentum.
+func velocity(metric Metric, r []float64) []float64 {
+	if metric == nil {
+		return r
+	}
+	v := make([]float64, len(r))
+	metric.Velocity(v, r)
+	return v
+}
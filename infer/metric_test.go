@@ -0,0 +1,89 @@
+package infer
+
+// Testing metrics.
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCholesky(t *testing.T) {
+	a := [][]float64{{4, 2, -2}, {2, 10, 2}, {-2, 2, 5}}
+	l, err := cholesky(a)
+	if err != nil {
+		t.Fatalf("failed to factorize %v: %v", a, err)
+	}
+	for i := range a {
+		for j := range a {
+			if j > i && l[i][j] != 0 {
+				t.Errorf("l is not lower-triangular: %v", l)
+			}
+			s := 0.
+			for k := range a {
+				s += l[i][k] * l[j][k]
+			}
+			if math.Abs(s-a[i][j]) > 1e-12 {
+				t.Errorf("wrong factor of %v: got %v", a, l)
+			}
+		}
+	}
+	if _, err := cholesky([][]float64{{1, 2}, {2, 1}}); err == nil {
+		t.Errorf("indefinite matrix must not be factorized")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	dense, err := NewDenseMetric([][]float64{{2, 0.5}, {0.5, 1}})
+	if err != nil {
+		t.Fatalf("failed to create dense metric: %v", err)
+	}
+	for _, c := range []struct {
+		metric Metric
+		v      []float64 // velocity for r = (1, 2)
+		m      [][]float64
+	}{
+		{
+			DiagMetric{4, 0.25},
+			[]float64{4, 0.5},
+			[][]float64{{0.25, 0}, {0, 4}},
+		},
+		{
+			dense,
+			[]float64{3, 2.5},
+			// inverse of {{2, 0.5}, {0.5, 1}}
+			[][]float64{{1 / 1.75, -0.5 / 1.75}, {-0.5 / 1.75, 2 / 1.75}},
+		},
+	} {
+		v := make([]float64, 2)
+		c.metric.Velocity(v, []float64{1, 2})
+		for i := range v {
+			if math.Abs(v[i]-c.v[i]) > 1e-12 {
+				t.Errorf("wrong velocity for %v: got %v, want %v",
+					c.metric, v, c.v)
+				break
+			}
+		}
+		// The covariance of momentum is the mass matrix.
+		const N = 100000
+		cov := [][]float64{{0, 0}, {0, 0}}
+		r := make([]float64, 2)
+		rng := rand.New(rand.NewSource(1))
+		for n := 0; n != N; n++ {
+			c.metric.Momentum(rng, r)
+			for i := range r {
+				for j := range r {
+					cov[i][j] += r[i] * r[j] / N
+				}
+			}
+		}
+		for i := range cov {
+			for j := range cov {
+				if math.Abs(cov[i][j]-c.m[i][j]) > 0.05 {
+					t.Errorf("wrong momentum covariance for %v: "+
+						"got %v, want %v", c.metric, cov, c.m)
+				}
+			}
+		}
+	}
+}
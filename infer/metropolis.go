@@ -0,0 +1,218 @@
+package infer
+
+// Gradient-free Metropolis-Hastings samplers, for models with
+// non-differentiable log-likelihoods.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"log"
+	"math"
+)
+
+// Random-walk Metropolis-Hastings with Gaussian proposals.
+type RWM struct {
+	Sampler
+	// Parameters
+	Scale float64 // standard deviation of proposal steps
+}
+
+func (rwm *RWM) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	rwm.setDefaults(len(x))
+	rwm.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: RWM: %v", r)
+			}
+		}()
+		l := observe(m, x)
+		x_ := make([]float64, len(x))
+		for {
+			if rwm.stopped() {
+				break
+			}
+			for i := range x {
+				x_[i] = x[i] + rwm.Scale*rwm.Rng.NormFloat64()
+			}
+			if l_ := observe(m, x_); rwm.accept(l_ - l) {
+				x, x_ = x_, x
+				l = l_
+				rwm.NAcc++
+			} else {
+				rwm.NRej++
+			}
+
+			// Write a sample to the channel.
+			samples <- clone(x)
+		}
+	}()
+}
+
+// setDefaults sets the default value for auxiliary parameters.
+// The default scale is optimal for the standard normal
+// distribution in n dimensions.
+func (rwm *RWM) setDefaults(n int) {
+	rwm.Sampler.setDefaults()
+	if rwm.Scale == 0 {
+		rwm.Scale = 2.38 / math.Sqrt(float64(n))
+	}
+}
+
+// Adaptive Metropolis (https://doi.org/10.2307/3318737). The
+// proposal covariance is the covariance of all samples so far,
+// scaled by 2.38²/n for n parameters and regularized. The
+// adaptation does not stop, and the chain is not Markovian;
+// the sampler is still ergodic for targets of bounded support,
+// and is good in practice for other targets as well.
+type AM struct {
+	Sampler
+	// Parameters
+	Scale float64 // standard deviation of steps before adaptation
+	NInit int     // number of iterations before adaptation
+	Eps   float64 // regularization of the proposal covariance
+}
+
+func (am *AM) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	am.setDefaults(len(x))
+	am.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: AM: %v", r)
+			}
+		}()
+		var (
+			n    = len(x)
+			sd   = 2.38 * 2.38 / float64(n)
+			t    float64     // number of samples
+			mean []float64   // running mean
+			cov  [][]float64 // running sum of squared deviations
+			chol [][]float64 // Cholesky factor of the proposal covariance
+			c    [][]float64 // proposal covariance
+		)
+		mean = make([]float64, n)
+		cov = make([][]float64, n)
+		c = make([][]float64, n)
+		for i := range cov {
+			cov[i] = make([]float64, n)
+			c[i] = make([]float64, n)
+		}
+		z := make([]float64, n)
+		l := observe(m, x)
+		x_ := make([]float64, n)
+		for iter := 0; ; iter++ {
+			if am.stopped() {
+				break
+			}
+			if iter >= am.NInit && t > 1 {
+				// Update the proposal covariance. Keep the
+				// previous factor if the covariance is not
+				// positive definite numerically.
+				for i := range c {
+					for j := range c[i] {
+						c[i][j] = sd * cov[i][j] / (t - 1)
+					}
+					c[i][i] += sd * am.Eps
+				}
+				if l, err := cholesky(c); err == nil {
+					chol = l
+				}
+			}
+			if chol == nil {
+				for i := range x {
+					x_[i] = x[i] + am.Scale*am.Rng.NormFloat64()
+				}
+			} else {
+				for i := range z {
+					z[i] = am.Rng.NormFloat64()
+				}
+				for i := range x {
+					x_[i] = x[i]
+					for j := 0; j <= i; j++ {
+						x_[i] += chol[i][j] * z[j]
+					}
+				}
+			}
+			if l_ := observe(m, x_); am.accept(l_ - l) {
+				x, x_ = x_, x
+				l = l_
+				am.NAcc++
+			} else {
+				am.NRej++
+			}
+
+			// Update the running mean and covariance of
+			// samples (Welford).
+			t++
+			for i := range x {
+				d := (x[i] - mean[i]) / t
+				for j := range x {
+					cov[i][j] += (t - 1) * d * (x[j] - mean[j])
+				}
+			}
+			for i := range x {
+				mean[i] += (x[i] - mean[i]) / t
+			}
+
+			// Write a sample to the channel.
+			samples <- clone(x)
+		}
+	}()
+}
+
+// setDefaults sets the default value for auxiliary parameters.
+func (am *AM) setDefaults(n int) {
+	am.Sampler.setDefaults()
+	if am.Scale == 0 {
+		am.Scale = 0.1 / math.Sqrt(float64(n))
+	}
+	if am.NInit == 0 {
+		am.NInit = 100
+	}
+	if am.Eps == 0 {
+		am.Eps = 1e-6
+	}
+}
+
+// accept returns true if a proposal changing the
+// log-likelihood by dl is accepted.
+func (s *Sampler) accept(dl float64) bool {
+	return dl >= math.Log(1-s.Rng.Float64())
+}
+
+// observe computes the log-likelihood of m at x, dropping the
+// gradient, which Metropolis-Hastings samplers do not need.
+func observe(m model.Model, x []float64) float64 {
+	l := m.Observe(x)
+	model.DropGradient(m)
+	return l
+}
@@ -0,0 +1,104 @@
+package infer
+
+// Testing Metropolis-Hastings samplers.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"math"
+	"testing"
+)
+
+// A correlated bivariate normal, the log-likelihood of which is
+// not differentiable: it is wrapped in ad.Value, as rewrite
+// does for calls to non-elemental functions. The means are
+// 1 and -1, the standard deviations are 1 and 2, and the
+// correlation is rho.
+type valueModel struct {
+	rho float64
+}
+
+func (m *valueModel) Observe(x []float64) float64 {
+	ad.Setup(x)
+	z0, z1 := x[0]-1, (x[1]+1)/2
+	l := -(z0*z0 - 2*m.rho*z0*z1 + z1*z1) / (2 * (1 - m.rho*m.rho))
+	return ad.Return(ad.Value(l))
+}
+
+func TestMetropolis(t *testing.T) {
+	nattempts := 5
+	niter := 5000
+	prec := 0.1
+	for _, c := range []struct {
+		sampler func() MCMC
+		rho     float64
+	}{
+		{func() MCMC { return &RWM{} }, 0},
+		{func() MCMC { return &RWM{Scale: 1} }, 0.5},
+		{func() MCMC { return &AM{} }, 0},
+		{func() MCMC { return &AM{} }, 0.9},
+	} {
+		if !repeatedly(nattempts,
+			func() bool {
+				sampler := c.sampler()
+				samples := make(chan []float64)
+				sampler.Sample(&valueModel{c.rho},
+					[]float64{0, 0}, samples)
+				// Burn
+				for i := 0; i != niter; i++ {
+					<-samples
+				}
+				// Collect after burn-in
+				var mean [2]float64
+				var cov [2][2]float64
+				draws := make([][]float64, niter)
+				for i := range draws {
+					draws[i] = <-samples
+					for j := range mean {
+						mean[j] += draws[i][j] / float64(niter)
+					}
+				}
+				sampler.Stop()
+				for _, x := range draws {
+					for j := range cov {
+						for k := range cov {
+							cov[j][k] += (x[j] - mean[j]) *
+								(x[k] - mean[k]) / float64(niter)
+						}
+					}
+				}
+				rho := cov[0][1] / math.Sqrt(cov[0][0]*cov[1][1])
+				return math.Abs(mean[0]-1) < prec &&
+					math.Abs(mean[1]+1) < 2*prec &&
+					math.Abs(math.Sqrt(cov[0][0])-1) < prec &&
+					math.Abs(math.Sqrt(cov[1][1])-2) < 2*prec &&
+					math.Abs(rho-c.rho) < prec
+			},
+			true) {
+			t.Errorf("%T did not converge for rho=%.2g",
+				c.sampler(), c.rho)
+		}
+	}
+}
+
+func TestAMAdapts(t *testing.T) {
+	// The adapted proposal moves along the correlated
+	// direction, and the acceptance rate of AM is higher than
+	// of RWM with the same initial scale.
+	niter := 2000
+	rate := func(sampler MCMC, stats *Sampler) float64 {
+		samples := make(chan []float64)
+		sampler.Sample(&valueModel{0.99}, []float64{1, -1}, samples)
+		for i := 0; i != niter; i++ {
+			<-samples
+		}
+		sampler.Stop()
+		return float64(stats.NAcc) / float64(stats.NAcc+stats.NRej)
+	}
+	rwm := &RWM{Scale: 1}
+	am := &AM{Scale: 1}
+	if rwmRate, amRate := rate(rwm, &rwm.Sampler),
+		rate(am, &am.Sampler); amRate < 2*rwmRate {
+		t.Errorf("AM did not adapt: acceptance rate %.4g, "+
+			"RWM acceptance rate %.4g", amRate, rwmRate)
+	}
+}
@@ -0,0 +1,199 @@
+package infer
+
+// Running multiple chains in parallel.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Chain is the outcome of running a single chain.
+type Chain struct {
+	Sampler MCMC        // the sampler, for sampler-specific statistics
+	Draws   [][]float64 // draws after warm-up
+	Err     error       // not nil if the sampler failed
+	// Statistics, including warm-up iterations
+	NAcc, NRej  int          // the number of accepted and rejected samples
+	NDiv        int          // the number of divergent transitions
+	Divergences []Divergence // divergent transitions
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	Chains []Chain
+}
+
+// Draws returns the draws of all chains,
+// draws[chain][draw][parameter], in the layout expected by
+// package diag.
+func (r *Result) Draws() [][][]float64 {
+	draws := make([][][]float64, len(r.Chains))
+	for i := range r.Chains {
+		draws[i] = r.Chains[i].Draws
+	}
+	return draws
+}
+
+// Run runs nChains chains of m in parallel, each with a sampler
+// returned by newSampler. The chains start from initial points
+// x, either one point per chain or a single point shared by all
+// chains. The first nWarmup samples of each chain are
+// discarded, and the following nDraws samples are collected.
+//
+// Runs are reproducible if newSampler sets the random number
+// generator of each sampler to a distinct fixed seed; newSampler
+// is called once for each chain, in order. Otherwise, the
+// generators are seeded from the global source.
+//
+// When ctx is cancelled, the chains are stopped, and Run
+// returns the draws collected so far along with the error of
+// the context. A chain ends early if its sampler fails; the
+// error of the chain is recorded in Err of the chain, and Run
+// returns the draws collected so far along with the error of
+// the first failed chain.
+//
+// An elemental model keeps the gradient between Observe and
+// Gradient and cannot be shared by chains running in parallel;
+// use RunModels to run multiple chains of an elemental model.
+func Run(
+	ctx context.Context,
+	newSampler func() MCMC,
+	m model.Model,
+	x [][]float64,
+	nChains, nWarmup, nDraws int,
+) (*Result, error) {
+	if _, ok := m.(model.ElementalModel); ok && nChains > 1 {
+		return nil, errors.New(
+			"cannot run chains in parallel: " +
+				"elemental model is shared by the chains")
+	}
+	return RunModels(ctx, newSampler,
+		func() model.Model { return m },
+		x, nChains, nWarmup, nDraws)
+}
+
+// RunModels is like Run, but each chain runs its own model
+// returned by newModel; newModel is called once for each chain,
+// in order. Elemental models returned by newModel must not
+// share state.
+func RunModels(
+	ctx context.Context,
+	newSampler func() MCMC,
+	newModel func() model.Model,
+	x [][]float64,
+	nChains, nWarmup, nDraws int,
+) (*Result, error) {
+	if len(x) != 1 && len(x) != nChains {
+		return nil, fmt.Errorf(
+			"got %d initial points for %d chains", len(x), nChains)
+	}
+	models := make([]model.Model, nChains)
+	for i := range models {
+		models[i] = newModel()
+		if _, ok := models[i].(model.ElementalModel); ok {
+			// The gradient is kept in the model of the chain.
+			continue
+		}
+		if nChains > 1 && !onOwnTape(models[i]) &&
+			!ad.IsMTSafe() && !ad.MTSafeOn() {
+			return nil, errors.New(
+				"cannot run chains in parallel: " +
+					"differentiation is not thread safe")
+		}
+	}
+
+	result := &Result{Chains: make([]Chain, nChains)}
+	done := make(chan struct{})
+	for i := range result.Chains {
+		x0 := x[0]
+		if len(x) != 1 {
+			x0 = x[i]
+		}
+		// Samplers are created, and their generators seeded,
+		// in the order of chains, for reproducibility.
+		sampler := newSampler()
+		if s, ok := sampler.(embedsSampler); ok {
+			s.stats().setDefaults()
+		}
+		go func(chain *Chain, m model.Model, x0 []float64) {
+			defer func() { done <- struct{}{} }()
+			chain.run(ctx, sampler, m, clone(x0), nWarmup, nDraws)
+		}(&result.Chains[i], models[i], x0)
+	}
+	for range result.Chains {
+		<-done
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	for i := range result.Chains {
+		if err := result.Chains[i].Err; err != nil {
+			return result, fmt.Errorf("chain %d: %v", i, err)
+		}
+	}
+	return result, nil
+}
+
+// run runs a single chain and records the draws and the
+// statistics.
+func (chain *Chain) run(
+	ctx context.Context,
+	sampler MCMC,
+	m model.Model,
+	x []float64,
+	nWarmup, nDraws int,
+) {
+	chain.Sampler = sampler
+	chain.Draws = make([][]float64, 0, nDraws)
+	samples := make(chan []float64)
+	sampler.Sample(m, x, samples)
+	// The sampler is stopped when the chain is complete,
+	// cancelled, or failed; statistics are safe to read once
+	// the sampler is stopped.
+	defer func() {
+		sampler.Stop()
+		if s, ok := sampler.(embedsSampler); ok {
+			s := s.stats()
+			chain.NAcc, chain.NRej = s.NAcc, s.NRej
+			chain.NDiv, chain.Divergences = s.NDiv, s.Divergences
+		}
+	}()
+	for i := 0; i != nWarmup+nDraws; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case x, ok := <-samples:
+			if !ok {
+				// The sampler exits early only on failure.
+				chain.Err = fmt.Errorf(
+					"sampler failed after %d of %d samples",
+					i, nWarmup+nDraws)
+				return
+			}
+			if i >= nWarmup {
+				chain.Draws = append(chain.Draws, clone(x))
+			}
+		}
+	}
+}
+
+// embedsSampler is implemented by samplers embedding Sampler.
+type embedsSampler interface {
+	stats() *Sampler
+}
+
+// onOwnTape returns true if m runs on a tape of its own in each
+// sampler, and chains of m can run in parallel without
+// multithreading support.
+func onOwnTape(m model.Model) bool {
+	if _, ok := m.(model.ElementalModel); ok {
+		// The gradient is kept in the model rather than
+		// on the tape.
+		return false
+	}
+	_, ok := m.(model.TapeModel)
+	return ok
+}
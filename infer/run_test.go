@@ -0,0 +1,225 @@
+package infer
+
+// Testing the multi-chain runner.
+
+import (
+	"bitbucket.org/dtolpin/infergo/model"
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	nChains, nWarmup, nDraws := 4, 100, 200
+	newSampler := func() MCMC { return &NUTS{Eps: 0.1} }
+	x := []float64{0, 0}
+	result, err := Run(context.Background(), newSampler,
+		&tapeTestModel{testData}, [][]float64{x},
+		nChains, nWarmup, nDraws)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if x[0] != 0 || x[1] != 0 {
+		t.Errorf("initial point modified: %v", x)
+	}
+	if len(result.Chains) != nChains {
+		t.Fatalf("wrong number of chains: got %d, want %d",
+			len(result.Chains), nChains)
+	}
+	mean, stddev := 0., 0.
+	for i, chain := range result.Chains {
+		if len(chain.Draws) != nDraws {
+			t.Errorf("wrong number of draws in chain %d: "+
+				"got %d, want %d", i, len(chain.Draws), nDraws)
+		}
+		if chain.NAcc+chain.NRej < nWarmup+nDraws {
+			t.Errorf("wrong statistics of chain %d: "+
+				"%d accepted, %d rejected, want %d iterations",
+				i, chain.NAcc, chain.NRej, nWarmup+nDraws)
+		}
+		if _, ok := chain.Sampler.(*NUTS); !ok {
+			t.Errorf("wrong sampler of chain %d: %T", i, chain.Sampler)
+		}
+		for _, x := range chain.Draws {
+			mean += x[0]
+			stddev += math.Exp(x[1])
+		}
+	}
+	n := float64(nChains * nDraws)
+	mean /= n
+	stddev /= n
+	prec := 0.1
+	if math.Abs((mean-testMean)/(mean+testMean)) > prec ||
+		math.Abs((stddev-testStddev)/(stddev+testStddev)) > prec {
+		t.Errorf("chains did not converge: mean=%.4g, stddev=%.4g, "+
+			"want mean=%.4g, stddev=%.4g",
+			mean, stddev, testMean, testStddev)
+	}
+
+	draws := result.Draws()
+	if len(draws) != nChains || len(draws[0]) != nDraws {
+		t.Errorf("wrong layout of draws: %d chains of %d draws",
+			len(draws), len(draws[0]))
+	}
+}
+
+func TestRunInitialPoints(t *testing.T) {
+	newSampler := func() MCMC { return &HMC{Eps: 0.1} }
+	m := &tapeTestModel{testData}
+	x := [][]float64{{0, 0}, {1, 0}}
+	if _, err := Run(context.Background(), newSampler,
+		m, x, 3, 10, 10); err == nil {
+		t.Errorf("no error for 2 initial points of 3 chains")
+	}
+	result, err := Run(context.Background(), newSampler,
+		m, x, 2, 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Chains[0].Draws) != 1 ||
+		len(result.Chains[1].Draws) != 1 {
+		t.Fatalf("wrong number of draws")
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := Run(ctx, func() MCMC { return &HMC{Eps: 0.1} },
+		&tapeTestModel{testData}, [][]float64{{0, 0}},
+		2, 1000000, 1000000)
+	if err != context.Canceled {
+		t.Errorf("wrong error: got %v, want %v", err, context.Canceled)
+	}
+	for i, chain := range result.Chains {
+		if len(chain.Draws) != 0 {
+			t.Errorf("chain %d not cancelled: %d draws",
+				i, len(chain.Draws))
+		}
+	}
+}
+
+func TestRunReproducible(t *testing.T) {
+	// run runs 3 chains, seeding the chains with seed, seed+1,
+	// and seed+2.
+	run := func(seed int64) *Result {
+		newSampler := func() MCMC {
+			nuts := &NUTS{Eps: 0.1}
+			nuts.Rng = rand.New(rand.NewSource(seed))
+			seed++
+			return nuts
+		}
+		result, err := Run(context.Background(), newSampler,
+			&tapeTestModel{testData}, [][]float64{{0, 0}},
+			3, 10, 20)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+	draws1, draws2 := run(1).Draws(), run(1).Draws()
+	for i := range draws1 {
+		for j := range draws1[i] {
+			for k := range draws1[i][j] {
+				if draws1[i][j][k] != draws2[i][j][k] {
+					t.Fatalf("different draws for the same seed: "+
+						"chain %d, draw %d: %v != %v",
+						i, j, draws1[i][j], draws2[i][j])
+				}
+			}
+		}
+	}
+}
+
+// Chains of an elemental model run in parallel on models of
+// their own; run with -race to detect sharing.
+// A model failing after n calls to Observe.
+type failingModel struct {
+	gaussianModel
+	n int
+}
+
+func (m *failingModel) Observe(x []float64) float64 {
+	m.n--
+	if m.n < 0 {
+		panic("model failed")
+	}
+	return m.gaussianModel.Observe(x)
+}
+
+func TestRunFailed(t *testing.T) {
+	result, err := Run(context.Background(),
+		func() MCMC { return &HMC{Eps: 0.1, L: 10} },
+		&failingModel{
+			gaussianModel: gaussianModel{
+				mu: []float64{0, 0},
+				p:  [][]float64{{1, 0}, {0, 1}},
+			},
+			n: 1000,
+		},
+		[][]float64{{0, 0}}, 1, 10, 1000)
+	if err == nil {
+		t.Errorf("no error for a failed chain")
+	}
+	chain := result.Chains[0]
+	if chain.Err == nil {
+		t.Errorf("no error recorded in the failed chain")
+	}
+	if len(chain.Draws) == 1000 {
+		t.Errorf("all draws collected in the failed chain")
+	}
+}
+
+func TestRunElemental(t *testing.T) {
+	newModel := func() model.Model {
+		return &gaussianModel{
+			mu: []float64{1, -2},
+			p:  [][]float64{{1, 0}, {0, 4}},
+		}
+	}
+	newSampler := func() MCMC { return &HMC{Eps: 0.1, L: 10} }
+	if _, err := Run(context.Background(), newSampler,
+		newModel(), [][]float64{{0, 0}}, 2, 10, 10); err == nil {
+		t.Errorf("no error for a shared elemental model")
+	}
+	if _, err := Run(context.Background(), newSampler,
+		newModel(), [][]float64{{0, 0}}, 1, 10, 10); err != nil {
+		t.Errorf("unexpected error for a single chain: %v", err)
+	}
+
+	nChains, nWarmup, nDraws := 4, 100, 500
+	nModels := 0
+	result, err := RunModels(context.Background(), newSampler,
+		func() model.Model {
+			nModels++
+			return newModel()
+		},
+		[][]float64{{0, 0}}, nChains, nWarmup, nDraws)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nModels != nChains {
+		t.Errorf("wrong number of models: got %d, want %d",
+			nModels, nChains)
+	}
+	mean := []float64{0, 0}
+	for _, chain := range result.Chains {
+		if len(chain.Draws) != nDraws {
+			t.Fatalf("wrong number of draws: got %d, want %d",
+				len(chain.Draws), nDraws)
+		}
+		for _, x := range chain.Draws {
+			for i := range x {
+				mean[i] += x[i] / float64(nChains*nDraws)
+			}
+		}
+	}
+	for i, want := range []float64{1, -2} {
+		if math.Abs(mean[i]-want) > 0.2 {
+			t.Errorf("chains did not converge: mean=%v, want [1 -2]",
+				mean)
+			break
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package infer
+
+// Learning-rate schedules for gradient ascent.
+
+import (
+	"bitbucket.org/dtolpin/infergo/model"
+	"math"
+)
+
+// Schedule is the interface of learning-rate schedules.
+// Scale returns the factor by which the initial learning rate
+// is multiplied at iteration iter.
+type Schedule interface {
+	Scale(iter int) float64
+}
+
+// Observer is implemented by schedules adapting the learning
+// rate to the log-likelihood. Observe receives the
+// log-likelihood ll returned by the step at iteration iter, and
+// returns true if the learning rate is changed in response.
+// Composite schedules forward Observe to their components.
+type Observer interface {
+	Observe(iter int, ll float64) bool
+}
+
+// RateGrad is the interface of gradient-based optimizers with
+// a learning rate.
+type RateGrad interface {
+	Grad
+	LearningRate() float64
+	SetLearningRate(rate float64)
+}
+
+// Scheduled attaches a learning-rate schedule to an optimizer.
+// If the optimizer is a RateGrad, the schedule sets the rate
+// before each step, overriding any decay built into the
+// optimizer. Otherwise, the update made by the optimizer is
+// scaled. Optimizers choosing the step length by line search,
+// such as LBFGS, should not be scheduled.
+type Scheduled struct {
+	Grad              // optimizer
+	Schedule Schedule // learning-rate schedule, constant if nil
+	// Statistics
+	Iter   int     // number of steps
+	Scale  float64 // rate factor of the latest step
+	NAdapt int     // number of rate changes in response to the log-likelihood
+
+	rate float64 // initial learning rate
+}
+
+// Step implements the Optimizer interface.
+func (opt *Scheduled) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	rg, isRateGrad := opt.Grad.(RateGrad)
+	if opt.Iter == 0 {
+		opt.setDefaults()
+		if isRateGrad {
+			opt.rate = rg.LearningRate()
+		}
+	}
+
+	opt.Scale = opt.Schedule.Scale(opt.Iter)
+	if isRateGrad {
+		rg.SetLearningRate(opt.Scale * opt.rate)
+		ll, grad = opt.Grad.Step(m, x)
+	} else {
+		x0 := clone(x)
+		ll, grad = opt.Grad.Step(m, x)
+		for i := range x {
+			x[i] = x0[i] + opt.Scale*(x[i]-x0[i])
+		}
+	}
+
+	if s, ok := opt.Schedule.(Observer); ok && s.Observe(opt.Iter, ll) {
+		opt.NAdapt++
+	}
+	opt.Iter++
+	return ll, grad
+}
+
+// setDefaults sets default parameter values for the scheduled
+// optimizer unless initialized.
+func (opt *Scheduled) setDefaults() {
+	if opt.Schedule == nil {
+		opt.Schedule = &Constant{}
+	}
+	if s, ok := opt.Schedule.(defaulter); ok {
+		s.setDefaults()
+	}
+}
+
+// defaulter is implemented by schedules with default parameter
+// values, set by the scheduled optimizer before the first step.
+type defaulter interface {
+	setDefaults()
+}
+
+// Constant keeps the learning rate constant.
+type Constant struct{}
+
+// Scale implements the Schedule interface.
+func (s *Constant) Scale(iter int) float64 {
+	return 1
+}
+
+// ExpDecay multiplies the learning rate by Decay on every
+// iteration.
+type ExpDecay struct {
+	Decay float64 // rate decay, no decay if 0
+}
+
+// Scale implements the Schedule interface.
+func (s *ExpDecay) Scale(iter int) float64 {
+	if s.Decay == 0 {
+		return 1
+	}
+	return math.Pow(s.Decay, float64(iter))
+}
+
+// StepDecay multiplies the learning rate by Gamma every Every
+// iterations.
+type StepDecay struct {
+	Every int     // number of iterations between decays, no decay if 0
+	Gamma float64 // rate decay
+}
+
+// Scale implements the Schedule interface.
+func (s *StepDecay) Scale(iter int) float64 {
+	if s.Every == 0 {
+		return 1
+	}
+	return math.Pow(s.Gamma, float64(iter/s.Every))
+}
+
+// setDefaults sets default parameter values for the step decay
+// schedule unless initialized.
+func (s *StepDecay) setDefaults() {
+	if s.Gamma == 0 {
+		s.Gamma = 0.1
+	}
+}
+
+// Cosine anneals the learning rate from the initial rate down
+// to Min times the initial rate along a half-period of the
+// cosine in Period iterations
+// (https://arxiv.org/abs/1608.03983), and keeps it at the
+// minimum afterwards.
+type Cosine struct {
+	Period int     // number of annealing iterations, no annealing if 0
+	Min    float64 // minimum scale
+}
+
+// Scale implements the Schedule interface.
+func (s *Cosine) Scale(iter int) float64 {
+	if s.Period == 0 {
+		return 1
+	}
+	t := float64(min(iter, s.Period)) / float64(s.Period)
+	return s.Min + 0.5*(1-s.Min)*(1+math.Cos(math.Pi*t))
+}
+
+// Warmup increases the learning rate linearly during Steps
+// iterations, and then follows schedule Then, counting
+// iterations from the end of the warm-up.
+type Warmup struct {
+	Steps int      // number of warm-up iterations
+	Then  Schedule // schedule after warm-up, constant if nil
+}
+
+// Scale implements the Schedule interface.
+func (s *Warmup) Scale(iter int) float64 {
+	if iter < s.Steps {
+		return float64(iter+1) / float64(s.Steps)
+	}
+	if s.Then == nil {
+		return 1
+	}
+	return s.Then.Scale(iter - s.Steps)
+}
+
+// Observe implements the Observer interface; the
+// log-likelihood is forwarded to Then after the warm-up.
+func (s *Warmup) Observe(iter int, ll float64) bool {
+	if iter < s.Steps {
+		return false
+	}
+	o, ok := s.Then.(Observer)
+	return ok && o.Observe(iter-s.Steps, ll)
+}
+
+// setDefaults sets default parameter values of Then.
+func (s *Warmup) setDefaults() {
+	if d, ok := s.Then.(defaulter); ok {
+		d.setDefaults()
+	}
+}
+
+// Plateau reduces the learning rate by Decay when the
+// log-likelihood does not improve by more than Eps in Patience
+// iterations, down to Min times the initial rate. Optimize
+// restarts early stopping when the rate is reduced; Patience
+// should be less than the number of plateau iterations passed
+// to Optimize for the reduction to take place.
+type Plateau struct {
+	// Parameters
+	Decay    float64 // rate decay
+	Patience int     // number of iterations without improvement
+	Eps      float64 // minimum improvement
+	Min      float64 // minimum scale
+	// Statistics
+	NReduce int // number of reductions
+
+	started bool    // a log-likelihood was observed
+	best    float64 // best log-likelihood
+	wait    int     // number of iterations without improvement
+}
+
+// Scale implements the Schedule interface.
+func (s *Plateau) Scale(iter int) float64 {
+	return math.Max(math.Pow(s.Decay, float64(s.NReduce)), s.Min)
+}
+
+// Observe implements the Observer interface.
+func (s *Plateau) Observe(iter int, ll float64) bool {
+	if !s.started || ll > s.best+s.Eps {
+		s.started = true
+		s.best = ll
+		s.wait = 0
+		return false
+	}
+	s.wait++
+	if s.wait >= s.Patience && s.Scale(iter) > s.Min {
+		s.NReduce++
+		s.wait = 0
+		return true
+	}
+	return false
+}
+
+// setDefaults sets default parameter values for the
+// reduce-on-plateau schedule unless initialized.
+func (s *Plateau) setDefaults() {
+	if s.Decay == 0 {
+		s.Decay = 0.1
+	}
+	if s.Patience == 0 {
+		s.Patience = 10
+	}
+	if s.Min == 0 {
+		s.Min = 1e-3
+	}
+}
+
+// Learning rates of the optimizers, for schedules.
+
+// LearningRate implements the RateGrad interface.
+func (opt *Momentum) LearningRate() float64 { return opt.Rate }
+
+// SetLearningRate implements the RateGrad interface.
+func (opt *Momentum) SetLearningRate(rate float64) { opt.Rate = rate }
+
+// LearningRate implements the RateGrad interface.
+func (opt *Adam) LearningRate() float64 { return opt.Rate }
+
+// SetLearningRate implements the RateGrad interface.
+func (opt *Adam) SetLearningRate(rate float64) { opt.Rate = rate }
+
+// LearningRate implements the RateGrad interface.
+func (opt *RMSProp) LearningRate() float64 { return opt.Rate }
+
+// SetLearningRate implements the RateGrad interface.
+func (opt *RMSProp) SetLearningRate(rate float64) { opt.Rate = rate }
+
+// LearningRate implements the RateGrad interface.
+func (opt *AdaGrad) LearningRate() float64 { return opt.Rate }
+
+// SetLearningRate implements the RateGrad interface.
+func (opt *AdaGrad) SetLearningRate(rate float64) { opt.Rate = rate }
+
+// LearningRate implements the RateGrad interface.
+func (opt *AMSGrad) LearningRate() float64 { return opt.Rate }
+
+// SetLearningRate implements the RateGrad interface.
+func (opt *AMSGrad) SetLearningRate(rate float64) { opt.Rate = rate }
+
+// LearningRate implements the RateGrad interface.
+func (opt *Nadam) LearningRate() float64 { return opt.Rate }
+
+// SetLearningRate implements the RateGrad interface.
+func (opt *Nadam) SetLearningRate(rate float64) { opt.Rate = rate }
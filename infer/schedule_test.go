@@ -0,0 +1,198 @@
+package infer
+
+// Testing learning-rate schedules.
+
+import (
+	"bitbucket.org/dtolpin/infergo/model"
+	"math"
+	"testing"
+)
+
+func TestSchedules(t *testing.T) {
+	for _, c := range []struct {
+		schedule Schedule
+		scale    []float64 // scales at iterations 0, 1, ...
+	}{
+		{&Constant{}, []float64{1, 1, 1}},
+		{&ExpDecay{}, []float64{1, 1, 1}},
+		{&ExpDecay{Decay: 0.5}, []float64{1, 0.5, 0.25, 0.125}},
+		{&StepDecay{Every: 2}, []float64{1, 1, 0.1, 0.1, 0.01}},
+		{&StepDecay{Every: 1, Gamma: 0.5}, []float64{1, 0.5, 0.25}},
+		{&Cosine{Period: 2}, []float64{1, 0.5, 0, 0}},
+		{&Cosine{Period: 2, Min: 0.2}, []float64{1, 0.6, 0.2, 0.2}},
+		{&Warmup{Steps: 4}, []float64{0.25, 0.5, 0.75, 1, 1, 1}},
+		{&Warmup{Steps: 2, Then: &ExpDecay{Decay: 0.5}},
+			[]float64{0.5, 1, 1, 0.5, 0.25}},
+	} {
+		if s, ok := c.schedule.(defaulter); ok {
+			s.setDefaults()
+		}
+		for iter, want := range c.scale {
+			if got := c.schedule.Scale(iter); math.Abs(got-want) > 1e-12 {
+				t.Errorf("%T%+v: wrong scale at iteration %d: got %.6g, want %.6g",
+					c.schedule, c.schedule, iter, got, want)
+			}
+		}
+	}
+}
+
+func TestPlateau(t *testing.T) {
+	s := &Plateau{Decay: 0.5, Patience: 2, Min: 0.2}
+	for iter, c := range []struct {
+		ll, scale float64
+	}{
+		{math.NaN(), 1},
+		{1, 1},
+		{2, 1},
+		{2, 1},   // no improvement
+		{1, 0.5}, // reduced
+		{3, 0.5}, // improvement
+		{3, 0.5},
+		{3, 0.25},
+		{3, 0.25},
+		{3, 0.2}, // minimum
+		{3, 0.2},
+		{3, 0.2},
+	} {
+		// The log-likelihood is returned by the previous step.
+		if !math.IsNaN(c.ll) {
+			scale := s.Scale(iter - 1)
+			if reduced := s.Observe(iter-1, c.ll); reduced !=
+				(s.Scale(iter) < scale) {
+				t.Errorf("wrong reduction at iteration %d: got %v",
+					iter, reduced)
+			}
+		}
+		if got := s.Scale(iter); got != c.scale {
+			t.Errorf("wrong scale at iteration %d: got %.6g, want %.6g",
+				iter, got, c.scale)
+		}
+	}
+	if s.NReduce != 3 {
+		t.Errorf("wrong number of reductions: got %d, want 3", s.NReduce)
+	}
+
+	// After warm-up, the log-likelihood is forwarded to the
+	// plateau schedule.
+	w := &Warmup{Steps: 2, Then: &Plateau{Patience: 1}}
+	w.setDefaults()
+	for iter, ll := range []float64{0, 0, 1, 2} {
+		if w.Observe(iter, ll) {
+			t.Errorf("rate reduced at iteration %d", iter)
+		}
+	}
+	if !w.Observe(4, 2) {
+		t.Errorf("rate not reduced after warm-up")
+	}
+	if got := w.Scale(5); got != 0.1 {
+		t.Errorf("wrong scale after warm-up: got %.6g, want 0.1", got)
+	}
+}
+
+// A plain gradient step, which is not a RateGrad, for testing
+// scaling of updates.
+type gradStep struct{}
+
+func (opt *gradStep) Step(
+	m model.Model,
+	x []float64,
+) (
+	ll float64,
+	grad []float64,
+) {
+	ll, grad = m.Observe(x), model.Gradient(m)
+	for i := range x {
+		x[i] += grad[i]
+	}
+	return ll, grad
+}
+
+func TestScheduled(t *testing.T) {
+	m := &constGrad{
+		grad: []float64{1, 2},
+	}
+	for _, c := range []struct {
+		opt   Grad
+		xNext [][]float64
+	}{
+		// The schedule overrides the decay of Momentum.
+		{&Momentum{Rate: 1, Decay: 0.1},
+			[][]float64{{1, 2}, {1.5, 3}, {1.75, 3.5}}},
+		{&Adam{Rate: 1},
+			[][]float64{{1, 1}, {1.5, 1.5}, {1.75, 1.75}}},
+		{&AdamW{Adam: Adam{Rate: 1}, WeightDecay: 0.1},
+			[][]float64{{1, 1}, {1.45, 1.45}, {1.66375, 1.66375}}},
+		{&gradStep{},
+			[][]float64{{1, 2}, {1.5, 3}, {1.75, 3.5}}},
+	} {
+		opt := &Scheduled{Grad: c.opt, Schedule: &ExpDecay{Decay: 0.5}}
+		x := []float64{0, 0}
+		for k, xNext := range c.xNext {
+			opt.Step(m, x)
+			for i := range x {
+				if math.Abs(xNext[i]-x[i]) > 1e-6 {
+					t.Errorf("%T: wrong update %d: got x[%d] = %.6g, "+
+						"want %.6g", c.opt, k+1, i, x[i], xNext[i])
+				}
+			}
+		}
+		if opt.Iter != len(c.xNext) {
+			t.Errorf("%T: wrong number of iterations: got %d, want %d",
+				c.opt, opt.Iter, len(c.xNext))
+		}
+	}
+
+	// Without a schedule, the rate is constant.
+	opt := &Scheduled{Grad: &Momentum{Rate: 1}}
+	x := []float64{0, 0}
+	opt.Step(m, x)
+	opt.Step(m, x)
+	if x[0] != 2 || x[1] != 4 {
+		t.Errorf("wrong updates with constant rate: got %v, want [2 4]", x)
+	}
+}
+
+// A user-defined schedule halving the rate whenever the
+// log-likelihood decreases.
+type halveOnDecrease struct {
+	scale float64
+	ll    float64
+}
+
+func (s *halveOnDecrease) Scale(iter int) float64 {
+	return s.scale
+}
+
+func (s *halveOnDecrease) Observe(iter int, ll float64) bool {
+	halve := iter > 0 && ll < s.ll
+	if halve {
+		s.scale /= 2
+	}
+	s.ll = ll
+	return halve
+}
+
+func TestOptimizeOnPlateau(t *testing.T) {
+	// With the initial rate, gradient ascent diverges; the
+	// rate is reduced until the ascent converges.
+	for _, schedule := range []Schedule{
+		&Plateau{Patience: 3},
+		&Warmup{Steps: 2, Then: &Plateau{Patience: 3}},
+		&Warmup{Steps: 2, Then: &halveOnDecrease{scale: 1}},
+	} {
+		m := &quadratic{mode: []float64{1, -2}}
+		x := []float64{0, 0}
+		opt := &Scheduled{Grad: &Momentum{Rate: 1.1}, Schedule: schedule}
+		Optimize(opt, m, x, 1000, 5, 1e-12)
+		if opt.NAdapt == 0 {
+			t.Errorf("%T: the rate was not reduced", schedule)
+		}
+		for i := range x {
+			if math.Abs(x[i]-m.mode[i]) > 1e-3 {
+				t.Errorf("%T: wrong optimum: got %v, want %v",
+					schedule, x, m.mode)
+				break
+			}
+		}
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"bitbucket.org/dtolpin/infergo/model"
 	"log"
 	"math"
-	"math/rand"
 )
 
 // Stochastic gradient Hamiltonian Monte Carlo
@@ -30,6 +29,9 @@ func (sghmc *SgHMC) Sample(
 	sghmc.setDefaults()
 	sghmc.Samples = samples // Stop needs access to samples
 	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
 		// On exit:
 		// * drop the tape;
 		defer ad.DropTape()
@@ -56,7 +58,7 @@ func (sghmc *SgHMC) Sample(
 
 		r := make([]float64, len(x))
 		for {
-			if sghmc.Stopped {
+			if sghmc.stopped() {
 				break
 			}
 			// For compatibility with HMC, we advance L steps
@@ -65,21 +67,173 @@ func (sghmc *SgHMC) Sample(
 				_, grad := m.Observe(x), model.Gradient(m)
 				for j := range r {
 					r[j] += sghmc.Eta*grad[j] - sghmc.Alpha*r[j] +
-						rand.NormFloat64()*sigma
+						sghmc.Rng.NormFloat64()*sigma
 					x[j] += r[j]
 				}
 			}
 			sghmc.NAcc++
 
 			// Write a sample to the channel.
-			samples <- x
+			// x is modified in place in the next iteration and
+			// therefore must be cloned.
+			samples <- clone(x)
 		}
 	}()
 }
 
 // setDefaults sets the default value for auxiliary parameters.
 func (sghmc *SgHMC) setDefaults() {
+	sghmc.Sampler.setDefaults()
 	if sghmc.L == 0 {
 		sghmc.L = 10
 	}
 }
+
+// Stochastic gradient Langevin dynamics
+// (https://www.ics.uci.edu/~welling/publications/papers/stoclangevin_v6.pdf).
+type SGLD struct {
+	Sampler
+	// Parameters
+	L   int     // number of steps
+	Eta float64 // step size
+}
+
+func (sgld *SGLD) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	sgld.setDefaults()
+	sgld.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: SGLD: %v", r)
+			}
+		}()
+
+		sigma := math.Sqrt(sgld.Eta)
+		for {
+			if sgld.stopped() {
+				break
+			}
+			// For compatibility with HMC, we advance L steps
+			// before each sample
+			for istep := 0; istep != sgld.L; istep++ {
+				_, grad := m.Observe(x), model.Gradient(m)
+				for j := range x {
+					x[j] += 0.5*sgld.Eta*grad[j] +
+						sgld.Rng.NormFloat64()*sigma
+				}
+			}
+			sgld.NAcc++
+
+			// Write a sample to the channel.
+			// x is modified in place in the next iteration and
+			// therefore must be cloned.
+			samples <- clone(x)
+		}
+	}()
+}
+
+// setDefaults sets the default value for auxiliary parameters.
+func (sgld *SGLD) setDefaults() {
+	sgld.Sampler.setDefaults()
+	if sgld.L == 0 {
+		sgld.L = 10
+	}
+}
+
+// Preconditioned stochastic gradient Langevin dynamics
+// (https://arxiv.org/abs/1512.07666), with RMSprop
+// preconditioner. The correction term of the preconditioner is
+// omitted, as suggested by the authors.
+type PSGLD struct {
+	Sampler
+	// Parameters
+	L      int     // number of steps
+	Eta    float64 // step size
+	Alpha  float64 // decay of the squared gradient average
+	Lambda float64 // regularization of the preconditioner
+}
+
+func (psgld *PSGLD) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	psgld.setDefaults()
+	psgld.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: PSGLD: %v", r)
+			}
+		}()
+
+		v := make([]float64, len(x)) // squared gradient average
+		first := true
+		for {
+			if psgld.stopped() {
+				break
+			}
+			// For compatibility with HMC, we advance L steps
+			// before each sample
+			for istep := 0; istep != psgld.L; istep++ {
+				_, grad := m.Observe(x), model.Gradient(m)
+				for j := range x {
+					if first {
+						v[j] = grad[j] * grad[j]
+					} else {
+						v[j] = psgld.Alpha*v[j] +
+							(1-psgld.Alpha)*grad[j]*grad[j]
+					}
+					g := 1 / (psgld.Lambda + math.Sqrt(v[j]))
+					x[j] += 0.5*psgld.Eta*g*grad[j] +
+						psgld.Rng.NormFloat64()*math.Sqrt(psgld.Eta*g)
+				}
+				first = false
+			}
+			psgld.NAcc++
+
+			// Write a sample to the channel.
+			// x is modified in place in the next iteration and
+			// therefore must be cloned.
+			samples <- clone(x)
+		}
+	}()
+}
+
+// setDefaults sets the default value for auxiliary parameters.
+func (psgld *PSGLD) setDefaults() {
+	psgld.Sampler.setDefaults()
+	if psgld.L == 0 {
+		psgld.L = 10
+	}
+	if psgld.Alpha == 0 {
+		psgld.Alpha = 0.99
+	}
+	if psgld.Lambda == 0 {
+		psgld.Lambda = 1e-5
+	}
+}
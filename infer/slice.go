@@ -0,0 +1,238 @@
+package infer
+
+// Slice samplers (https://doi.org/10.1214/aos/1056562461,
+// https://arxiv.org/abs/1001.0175), gradient-free and with
+// little or no tuning.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"log"
+	"math"
+)
+
+// Coordinate-wise slice sampler with stepping out and
+// shrinkage. Each iteration updates every coordinate in turn.
+type Slice struct {
+	Sampler
+	// Parameters
+	Width    float64 // initial width of the slice interval
+	MaxSteps int     // maximum number of steps out
+	// Statistics
+	NEval int // number of log-likelihood evaluations
+}
+
+func (slice *Slice) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	slice.setDefaults()
+	slice.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: Slice: %v", r)
+			}
+		}()
+		l := slice.observe(m, x)
+		for {
+			if slice.stopped() {
+				break
+			}
+			for i := range x {
+				l = slice.update(m, x, i, l)
+			}
+			slice.NAcc++
+
+			// Write a sample to the channel.
+			samples <- clone(x)
+		}
+	}()
+}
+
+// update updates coordinate i of x, at which the
+// log-likelihood is l, and returns the log-likelihood at the
+// updated x.
+func (slice *Slice) update(
+	m model.Model,
+	x []float64,
+	i int,
+	l float64,
+) float64 {
+	x0 := x[i]
+	// at returns the log-likelihood at x[i] = xi.
+	at := func(xi float64) float64 {
+		x[i] = xi
+		return slice.observe(m, x)
+	}
+	logy := l + math.Log(1-slice.Rng.Float64())
+
+	// Step out, at most MaxSteps in total.
+	left := x0 - slice.Width*slice.Rng.Float64()
+	right := left + slice.Width
+	j := int(float64(slice.MaxSteps) * slice.Rng.Float64())
+	k := slice.MaxSteps - 1 - j
+	for ; j > 0 && at(left) > logy; j-- {
+		left -= slice.Width
+	}
+	for ; k > 0 && at(right) > logy; k-- {
+		right += slice.Width
+	}
+
+	// Shrink until a point inside the slice is found.
+	for {
+		xi := left + (right-left)*slice.Rng.Float64()
+		if l := at(xi); l > logy {
+			return l
+		}
+		if xi < x0 {
+			left = xi
+		} else {
+			right = xi
+		}
+		if left == right {
+			// The interval shrank numerically to x0.
+			x[i] = x0
+			return l
+		}
+	}
+}
+
+// observe computes the log-likelihood and counts evaluations.
+func (slice *Slice) observe(m model.Model, x []float64) float64 {
+	slice.NEval++
+	return observe(m, x)
+}
+
+// setDefaults sets the default value for auxiliary parameters.
+func (slice *Slice) setDefaults() {
+	slice.Sampler.setDefaults()
+	if slice.Width == 0 {
+		slice.Width = 1
+	}
+	if slice.MaxSteps == 0 {
+		slice.MaxSteps = 100
+	}
+}
+
+// Elliptical slice sampler, for models with a Gaussian prior
+// on the parameters. The prior is given by Mean and Cov, and
+// the model must compute the log-likelihood without the prior.
+type Elliptical struct {
+	Sampler
+	// Parameters
+	Mean []float64   // mean of the prior, zero if nil
+	Cov  [][]float64 // covariance of the prior, identity if nil
+	// Statistics
+	NEval int // number of log-likelihood evaluations
+}
+
+func (ell *Elliptical) Sample(
+	m model.Model,
+	x []float64,
+	samples chan []float64,
+) {
+	ell.Sampler.setDefaults()
+	ell.Samples = samples // Stop needs access to samples
+	go func() {
+		// Models differentiated with the tape parameter run
+		// on a tape of their own.
+		m := model.OnTape(m, ad.NewTape())
+		// On exit:
+		// * drop the tape;
+		defer ad.DropTape()
+		// * close samples;
+		defer close(samples)
+		// * intercept errors deep inside the algorithm
+		// and report them.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: Elliptical: %v", r)
+			}
+		}()
+		var chol [][]float64 // Cholesky factor of Cov
+		if ell.Cov != nil {
+			var err error
+			chol, err = cholesky(ell.Cov)
+			if err != nil {
+				panic(err)
+			}
+		}
+		mean := ell.Mean
+		if mean == nil {
+			mean = make([]float64, len(x))
+		}
+
+		l := ell.observe(m, x)
+		z := make([]float64, len(x))
+		nu := make([]float64, len(x))
+		x_ := make([]float64, len(x))
+		for {
+			if ell.stopped() {
+				break
+			}
+			// Draw the ellipse through x and nu ~ N(0, Cov).
+			for i := range z {
+				z[i] = ell.Rng.NormFloat64()
+			}
+			if chol == nil {
+				copy(nu, z)
+			} else {
+				for i := range nu {
+					nu[i] = 0
+					for j := 0; j <= i; j++ {
+						nu[i] += chol[i][j] * z[j]
+					}
+				}
+			}
+			logy := l + math.Log(1-ell.Rng.Float64())
+
+			// Shrink the bracket of angles until a point
+			// inside the slice is found.
+			theta := 2 * math.Pi * ell.Rng.Float64()
+			tmin, tmax := theta-2*math.Pi, theta
+			for {
+				sin, cos := math.Sincos(theta)
+				for i := range x {
+					x_[i] = mean[i] + (x[i]-mean[i])*cos + nu[i]*sin
+				}
+				if l_ := ell.observe(m, x_); l_ > logy {
+					x, x_ = x_, x
+					l = l_
+					break
+				}
+				if theta < 0 {
+					tmin = theta
+				} else {
+					tmax = theta
+				}
+				if tmin == tmax {
+					// The bracket shrank numerically to x.
+					break
+				}
+				theta = tmin + (tmax-tmin)*ell.Rng.Float64()
+			}
+			ell.NAcc++
+
+			// Write a sample to the channel.
+			samples <- clone(x)
+		}
+	}()
+}
+
+// observe computes the log-likelihood and counts evaluations.
+func (ell *Elliptical) observe(m model.Model, x []float64) float64 {
+	ell.NEval++
+	return observe(m, x)
+}
@@ -0,0 +1,123 @@
+package infer
+
+// Testing slice samplers.
+
+import (
+	"bitbucket.org/dtolpin/infergo/ad"
+	"bitbucket.org/dtolpin/infergo/model"
+	"math"
+	"testing"
+)
+
+// A normal likelihood of observations y given x, with unit
+// variance, for testing elliptical slice sampling.
+type normalLikelihood struct {
+	y []float64
+}
+
+func (m *normalLikelihood) Observe(x []float64) float64 {
+	ad.Setup(x)
+	l := 0.
+	for i := range x {
+		l -= 0.5 * (x[i] - m.y[i]) * (x[i] - m.y[i])
+	}
+	return ad.Return(ad.Value(l))
+}
+
+// meanStddev returns the mean and standard deviation of each
+// parameter in niter samples after niter samples of burn-in.
+func meanStddev(
+	sampler MCMC, m model.Model,
+	x []float64, niter int,
+) (mean, stddev []float64) {
+	samples := make(chan []float64)
+	sampler.Sample(m, x, samples)
+	for i := 0; i != niter; i++ {
+		<-samples
+	}
+	mean = make([]float64, len(x))
+	stddev = make([]float64, len(x))
+	for i := 0; i != niter; i++ {
+		x := <-samples
+		for j := range x {
+			mean[j] += x[j] / float64(niter)
+			stddev[j] += x[j] * x[j] / float64(niter)
+		}
+	}
+	sampler.Stop()
+	for j := range mean {
+		stddev[j] = math.Sqrt(stddev[j] - mean[j]*mean[j])
+	}
+	return mean, stddev
+}
+
+func TestSlice(t *testing.T) {
+	nattempts := 5
+	niter := 2000
+	prec := 0.1
+	for _, c := range []struct {
+		slice *Slice
+	}{
+		{&Slice{}},
+		{&Slice{Width: 0.1}},
+		{&Slice{Width: 10, MaxSteps: 1}},
+	} {
+		if !repeatedly(nattempts,
+			func() bool {
+				slice := *c.slice
+				mean, stddev := meanStddev(&slice, &valueModel{0.5},
+					[]float64{0, 0}, niter)
+				return math.Abs(mean[0]-1) < prec &&
+					math.Abs(mean[1]+1) < 2*prec &&
+					math.Abs(stddev[0]-1) < prec &&
+					math.Abs(stddev[1]-2) < 2*prec
+			},
+			true) {
+			t.Errorf("slice sampler %+v did not converge", *c.slice)
+		}
+	}
+
+	// The number of evaluations grows when the width is far
+	// off the scale of the distribution, but stays finite.
+	slice := &Slice{Width: 100}
+	meanStddev(slice, &valueModel{0}, []float64{0, 0}, 100)
+	if evals := float64(slice.NEval) / float64(slice.NAcc); evals > 100 {
+		t.Errorf("too many evaluations per iteration: %.4g", evals)
+	}
+}
+
+func TestElliptical(t *testing.T) {
+	nattempts := 5
+	niter := 2000
+	prec := 0.05
+	y := []float64{1, -1}
+	for _, c := range []struct {
+		mean         []float64
+		cov          [][]float64
+		pmean, pvari []float64 // posterior mean and variance
+	}{
+		{nil, nil, []float64{0.5, -0.5}, []float64{0.5, 0.5}},
+		{nil, [][]float64{{1, 0}, {0, 4}},
+			[]float64{0.5, -0.8}, []float64{0.5, 0.8}},
+		{[]float64{1, 1}, [][]float64{{1, 0}, {0, 4}},
+			[]float64{1, -0.6}, []float64{0.5, 0.8}},
+	} {
+		if !repeatedly(nattempts,
+			func() bool {
+				mean, stddev := meanStddev(
+					&Elliptical{Mean: c.mean, Cov: c.cov},
+					&normalLikelihood{y}, []float64{0, 0}, niter)
+				for i := range mean {
+					if math.Abs(mean[i]-c.pmean[i]) > prec ||
+						math.Abs(stddev[i]-math.Sqrt(c.pvari[i])) > prec {
+						return false
+					}
+				}
+				return true
+			},
+			true) {
+			t.Errorf("elliptical slice sampler with prior N(%v, %v) "+
+				"did not converge", c.mean, c.cov)
+		}
+	}
+}
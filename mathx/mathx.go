@@ -25,11 +25,21 @@ func init() {
 		func(value float64, _ ...float64) []float64 {
 			return []float64{value * (1. - value)}
 		})
+	ad.RegisterElementalHessian(Sigm,
+		// d^2Sigm / dx^2 = Sigm(x) * (1 - Sigm(x)) * (1 - 2 Sigm(x))
+		func(value float64, _ ...float64) [][]float64 {
+			return [][]float64{{value * (1. - value) * (1. - 2*value)}}
+		})
 
 	ad.RegisterElemental(LogDSigm,
 		func(_ float64, params ...float64) []float64 {
 			return []float64{1 - 2*Sigm(params[0])}
 		})
+	ad.RegisterElementalHessian(LogDSigm,
+		func(_ float64, params ...float64) [][]float64 {
+			s := Sigm(params[0])
+			return [][]float64{{-2 * s * (1 - s)}}
+		})
 }
 
 // LogSumExp computes log(exp(x) + exp(y)) robustly.
@@ -52,6 +62,17 @@ func init() {
 			t := 1 / (1 + z)
 			return []float64{t, t * z}
 		})
+	// The second derivatives are all equal up to the sign:
+	// d^2 lse(x, y) / dx^2 = d^2 lse(x, y) / dy^2
+	//                      = - d^2 lse(x, y) / dx dy
+	//                      = t (1 - t), where t = d lse / dx
+	ad.RegisterElementalHessian(LogSumExp,
+		func(_ float64, params ...float64) [][]float64 {
+			z := math.Exp(params[1] - params[0])
+			t := 1 / (1 + z)
+			d := t * (1 - t)
+			return [][]float64{{d, -d}, {-d, d}}
+		})
 }
 
 // LogGamma and digamma are borrowed from the source code of
@@ -97,9 +118,61 @@ func digamma(x float64) float64 {
 		1/(12*math.Pow(x, 14))
 }
 
+// trigamma is the derivative of digamma.
+func trigamma(x float64) float64 {
+	if x < 6 {
+		return trigamma(x+1) + 1/(x*x)
+	}
+	return 1/x +
+		1/(2*math.Pow(x, 2)) +
+		1/(6*math.Pow(x, 3)) -
+		1/(30*math.Pow(x, 5)) +
+		1/(42*math.Pow(x, 7)) -
+		1/(30*math.Pow(x, 9)) +
+		5/(66*math.Pow(x, 11)) -
+		691/(2730*math.Pow(x, 13)) +
+		7/(6*math.Pow(x, 15))
+}
+
 func init() {
 	ad.RegisterElemental(LogGamma,
 		func(_ float64, params ...float64) []float64 {
 			return []float64{digamma(params[0])}
 		})
+	ad.RegisterElementalHessian(LogGamma,
+		func(_ float64, params ...float64) [][]float64 {
+			return [][]float64{{trigamma(params[0])}}
+		})
+}
+
+// LogDet computes the log-determinant of a triangular matrix,
+// such as a Cholesky factor, given its diagonal d. LogDet is a
+// vector elemental, and takes a single record on the tape
+// regardless of the dimension.
+func LogDet(d []float64) float64 {
+	logdet := 0.
+	for i := range d {
+		logdet += math.Log(d[i])
+	}
+	return logdet
+}
+
+func init() {
+	ad.RegisterElemental(LogDet,
+		func(_ float64, params ...float64) []float64 {
+			g := make([]float64, len(params))
+			for i := range params {
+				g[i] = 1 / params[i]
+			}
+			return g
+		})
+	ad.RegisterElementalHessian(LogDet,
+		func(_ float64, params ...float64) [][]float64 {
+			h := make([][]float64, len(params))
+			for i := range params {
+				h[i] = make([]float64, len(params))
+				h[i][i] = -1 / (params[i] * params[i])
+			}
+			return h
+		})
 }
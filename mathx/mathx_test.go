@@ -163,3 +163,108 @@ func TestLogGammaGrad(t *testing.T) {
 		}
 	}
 }
+
+func TestLogGammaHessian(t *testing.T) {
+	hess, ok := ad.ElementalHessian(LogGamma)
+	if !ok {
+		t.Errorf("No Hessian for LogGamma")
+	}
+	for _, c := range []struct {
+		x, h float64
+	}{
+		// trigamma, in closed form
+		{0.5, math.Pi * math.Pi / 2},
+		{1, math.Pi * math.Pi / 6},
+		{1.5, math.Pi*math.Pi/2 - 4},
+		{2, math.Pi*math.Pi/6 - 1},
+		{3, math.Pi*math.Pi/6 - 1.25},
+	} {
+		y := LogGamma(c.x)
+		h := hess(y, c.x)[0][0]
+		if math.Abs(h-c.h) > 1e-6 {
+			t.Errorf("Wrong Hessian of LogGamma(%.4g): "+
+				"got %v, want %v", c.x, h, c.h)
+		}
+	}
+}
+
+func TestLogDet(t *testing.T) {
+	for _, c := range []struct {
+		d    []float64
+		y    float64
+		grad []float64
+	}{
+		{[]float64{}, 0, []float64{}},
+		{[]float64{1}, 0, []float64{1}},
+		{[]float64{2, 0.5}, 0, []float64{0.5, 2}},
+		{[]float64{1, 2, 4}, 2.0794415416798357, []float64{1, 0.5, 0.25}},
+	} {
+		y := LogDet(c.d)
+		if math.Abs(y-c.y) > 1e-6 {
+			t.Errorf("Wrong LogDet(%v): got %v, want %v", c.d, y, c.y)
+		}
+		grad, ok := ad.ElementalGradient(LogDet)
+		if !ok {
+			t.Fatalf("No gradient for LogDet")
+		}
+		g := grad(y, c.d...)
+		for i := range g {
+			if math.Abs(g[i]-c.grad[i]) > 1e-6 {
+				t.Errorf("Wrong gradient of LogDet(%v): got %v, want %v",
+					c.d, g, c.grad)
+				break
+			}
+		}
+	}
+}
+
+// Hessians must agree with finite differences of the
+// gradients.
+func TestHessian(t *testing.T) {
+	for _, c := range []struct {
+		s  string
+		f  interface{}
+		fv func(...float64) float64
+		x  [][]float64
+	}{
+		{"Sigm", Sigm,
+			func(x ...float64) float64 { return Sigm(x[0]) },
+			[][]float64{{0}, {-1}, {2}}},
+		{"LogDSigm", LogDSigm,
+			func(x ...float64) float64 { return LogDSigm(x[0]) },
+			[][]float64{{0}, {-1}, {2}}},
+		{"LogSumExp", LogSumExp,
+			func(x ...float64) float64 { return LogSumExp(x[0], x[1]) },
+			[][]float64{{0, 0}, {0, 0.5}, {1, -2}}},
+		{"LogDet", LogDet,
+			func(x ...float64) float64 { return LogDet(x) },
+			[][]float64{{1}, {0.5, 2}, {1, 3, 0.2}}},
+	} {
+		grad, _ := ad.ElementalGradient(c.f)
+		hess, ok := ad.ElementalHessian(c.f)
+		if !ok {
+			t.Errorf("No Hessian for %v", c.s)
+			continue
+		}
+		const h = 1e-6
+		for _, x := range c.x {
+			got := hess(c.fv(x...), x...)
+			for j := range x {
+				xj := x[j]
+				x[j] = xj + h
+				gp := grad(c.fv(x...), x...)
+				x[j] = xj - h
+				gm := grad(c.fv(x...), x...)
+				x[j] = xj
+				for i := range x {
+					want := (gp[i] - gm[i]) / (2 * h)
+					if math.Abs(got[i][j]-want) > 1e-5 {
+						t.Errorf("Wrong Hessian of %v(%.4g): "+
+							"got %.4g, want %.4g at (%d, %d)",
+							c.s, x, got[i][j], want, i, j)
+					}
+				}
+			}
+		}
+	}
+}
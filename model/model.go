@@ -21,6 +21,40 @@ type ElementalModel interface {
 	Gradient() []float64
 }
 
+// A model differentiated by deriv implements TapeModel as well.
+// Method ObserveTape computes the loglikelihood on the given
+// tape, rather than on the current tape of the goroutine, and
+// allows to run the model in parallel on any platform.
+type TapeModel interface {
+	Model
+	ObserveTape(tape *ad.Tape, parameters []float64) float64
+}
+
+// OnTape returns a model which runs m on tape; Gradient and
+// DropGradient of the returned model use the tape as well. The
+// tape must not be shared by concurrent goroutines. Elemental
+// models and models not implementing TapeModel are returned
+// unchanged.
+func OnTape(m Model, tape *ad.Tape) Model {
+	if _, ok := m.(ElementalModel); ok {
+		return m
+	}
+	if m, ok := m.(TapeModel); ok {
+		return &onTape{m, tape}
+	}
+	return m
+}
+
+// onTape is a model bound to a tape.
+type onTape struct {
+	m    TapeModel
+	tape *ad.Tape
+}
+
+func (m *onTape) Observe(x []float64) float64 {
+	return m.m.ObserveTape(m.tape, x)
+}
+
 // Shift shifts n parameters from x, useful for destructuring
 // the parameter vector.
 func Shift(px *[]float64, n int) []float64 {
@@ -35,6 +69,8 @@ func Gradient(m Model) []float64 {
 	switch m := m.(type) {
 	case ElementalModel:
 		return m.Gradient()
+	case *onTape:
+		return m.tape.Gradient()
 	default:
 		return ad.Gradient()
 	}
@@ -45,9 +81,11 @@ func Gradient(m Model) []float64 {
 // will pop the frame from the tape; for elemental models it will
 // do nothing.
 func DropGradient(m Model) {
-	switch m.(type) {
+	switch m := m.(type) {
 	case ElementalModel:
 		// nothing has to be cleared
+	case *onTape:
+		m.tape.Pop()
 	default:
 		ad.Pop()
 	}
@@ -26,6 +26,19 @@ func (m *elModel) Gradient() []float64 {
 	return m.grad
 }
 
+// A model with the tape parameter, as differentiated by deriv,
+// with gradient 2x.
+type tapeModel struct{}
+
+func (m *tapeModel) ObserveTape(tape *ad.Tape, x []float64) float64 {
+	tape.Setup(x)
+	return tape.Return(tape.Arithmetic(ad.OpMul, &x[0], &x[0]))
+}
+
+func (m *tapeModel) Observe(x []float64) float64 {
+	return m.ObserveTape(ad.CurrentTape(), x)
+}
+
 func TestGradient(t *testing.T) {
 	for i, c := range []struct {
 		m    Model
@@ -42,6 +55,16 @@ func TestGradient(t *testing.T) {
 			[]float64{2., 1.},
 			[]float64{2., 1.},
 		},
+		{
+			&tapeModel{},
+			[]float64{3.},
+			[]float64{6.},
+		},
+		{
+			OnTape(&tapeModel{}, ad.NewTape()),
+			[]float64{3.},
+			[]float64{6.},
+		},
 	} {
 		c.m.Observe(c.x)
 		grad := Gradient(c.m)
@@ -54,6 +77,35 @@ func TestGradient(t *testing.T) {
 	}
 }
 
+func TestOnTape(t *testing.T) {
+	tape := ad.NewTape()
+	for _, m := range []Model{&adModel{}, &elModel{}} {
+		if OnTape(m, tape) != m {
+			t.Errorf("%T must be returned unchanged", m)
+		}
+	}
+	m := OnTape(&tapeModel{}, tape)
+	if _, ok := m.(*onTape); !ok {
+		t.Fatalf("%T must be bound to the tape", m)
+	}
+	// The model runs on the tape, and leaves the current tape
+	// alone.
+	m.Observe([]float64{1.})
+	DropGradient(m)
+	m.Observe([]float64{2.})
+	if grad := Gradient(m); grad[0] != 4. {
+		t.Errorf("wrong gradient: got %v, want [4]", grad)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("the current tape must be empty")
+			}
+		}()
+		ad.Gradient()
+	}()
+}
+
 func TestShift(t *testing.T) {
 	// kicking tyres
 	for i, c := range []struct {